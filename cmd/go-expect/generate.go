@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jesse0michael/go-expect/pkg/expect"
+)
+
+// generateCommand writes a suite with a scenario per operation of an OpenAPI document.
+func generateCommand(args []string, stdout, stderr io.Writer) int {
+	var opts expect.GenerateOptions
+	var out string
+	fs := flag.NewFlagSet("go-expect generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&out, "o", "", "write the suite to `file` instead of stdout")
+	fs.StringVar(&opts.Connection, "connection", "api", "connection `name`")
+	fs.StringVar(&opts.URL, "url", "", "connection `url` (default the document's first server)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "usage: go-expect generate [flags] openapi-file\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(paths) != 1 {
+		fmt.Fprintln(stderr, "go-expect: generate takes a single OpenAPI file")
+		return exitUsage
+	}
+
+	spec, err := expect.LoadOpenAPI(paths[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	opts.OpenAPI, err = specPath(paths[0], out)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	data, err := expect.GenerateScenarios(spec, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	if out == "" {
+		_, err = stdout.Write(data)
+	} else {
+		err = os.WriteFile(out, data, 0o644) //nolint:gosec // a suite file, not a secret
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "go-expect:", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+// specPath returns the path of the OpenAPI document relative to the generated
+// file, as its openapi: setting resolves; out "" is stdout, read from the
+// working directory.
+func specPath(spec, out string) (string, error) {
+	if out == "" {
+		return filepath.ToSlash(spec), nil
+	}
+	absSpec, err := filepath.Abs(spec)
+	if err != nil {
+		return "", fmt.Errorf("go-expect: %w", err)
+	}
+	absOut, err := filepath.Abs(out)
+	if err != nil {
+		return "", fmt.Errorf("go-expect: %w", err)
+	}
+	rel, err := filepath.Rel(filepath.Dir(absOut), absSpec)
+	if err != nil {
+		return "", fmt.Errorf("go-expect: %w", err)
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const generateTestSpec = `
+openapi: 3.0.3
+info: {title: ok service, version: "1"}
+paths:
+  /ok:
+    get:
+      operationId: getOK
+      responses:
+        "200": {description: ok}
+`
+
+func TestGenerate(t *testing.T) {
+	_, url := setupRun(t)
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "api", "openapi.yaml")
+	out := filepath.Join(dir, "tests", "ok.yaml")
+	for _, d := range []string{filepath.Dir(spec), filepath.Dir(out)} {
+		if err := os.MkdirAll(d, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(spec, []byte(generateTestSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"generate", spec, "-o", out, "--connection", "svc", "--url", url}
+	if code := run(t.Context(), args, &stdout, &stderr, os.Getenv); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d\n%s", exitOK, code, &stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"name: svc\n", "openapi: ../api/openapi.yaml\n", "name: getOK\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	stdout.Reset()
+	if code := run(t.Context(), []string{"run", out}, &stdout, &stderr, os.Getenv); code != exitOK {
+		t.Errorf("expected the generated suite to pass, got exit code %d\n%s%s", code, &stdout, &stderr)
+	}
+
+	t.Run("stdout", func(t *testing.T) {
+		var stdout bytes.Buffer
+		if code := run(t.Context(), []string{"generate", spec}, &stdout, &stderr, os.Getenv); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d\n%s", exitOK, code, &stderr)
+		}
+		if !strings.Contains(stdout.String(), "openapi: "+filepath.ToSlash(spec)+"\n") {
+			t.Errorf("expected the spec path as given, got:\n%s", &stdout)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var stderr bytes.Buffer
+		args := []string{"generate", filepath.Join(dir, "nope.yaml")}
+		if code := run(t.Context(), args, &stdout, &stderr, os.Getenv); code != exitUsage {
+			t.Errorf("expected exit code %d, got %d", exitUsage, code)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+// Command go-expect runs go-expect YAML and JSON suites without writing Go.
+//
+//	go-expect run ./testdata --env staging --report junit.xml --filter 'name~checkout'
+//	go-expect generate openapi.yaml -o testdata/api.yaml
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailed  = 1 // at least one scenario failed
+	exitUsage   = 2 // bad arguments, or the suite could not be loaded
+	usageHeader = `usage: go-expect <command> [arguments]
+
+commands:
+  run       run the scenarios in a file or directory
+  generate  write a scenario per operation of an OpenAPI document
+
+Run 'go-expect <command> -h' for details.
+`
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// run dispatches to a subcommand and returns the process exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageHeader)
+		return exitUsage
+	}
+	switch args[0] {
+	case "run":
+		return runCommand(ctx, args[1:], stdout, stderr, getenv)
+	case "generate":
+		return generateCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usageHeader)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "go-expect: unknown command %q\n\n%s", args[0], usageHeader)
+		return exitUsage
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jesse0michael/go-expect/pkg/expect"
+)
+
+// runOptions are the flags of the run command.
+type runOptions struct {
+	env         string
+	reports     stringList
+	filters     stringList
+	urls        stringList
+	parallelism int
+	timeout     time.Duration
+	verbose     bool
+	coverage    bool
+	coverageMin float64
+	seed        *int64
+}
+
+func (o *runOptions) setSeed(v string) error {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return errors.New("expected an integer")
+	}
+	o.seed = &n
+	return nil
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func runCommand(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	var opts runOptions
+	fs := flag.NewFlagSet("go-expect run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.env, "env", "",
+		"environment `name` for environments: blocks, .env.<name> and GO_EXPECT_<ENV>_* URLs (default $GO_EXPECT_ENV)")
+	fs.Var(&opts.reports, "report", "write a report to `file`: .xml for JUnit, .json for JSON (repeatable)")
+	fs.Var(&opts.filters, "filter", "only run scenarios matching `expr`: name=x, name!=x, name~re, name!~re (repeatable)")
+	fs.Var(&opts.urls, "url", "override a connection URL as `name=url` (repeatable)")
+	fs.IntVar(&opts.parallelism, "parallel", 0, "maximum parallel scenarios (default GOMAXPROCS)")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "abort the run after `duration`")
+	fs.BoolVar(&opts.verbose, "v", false, "log every step to stderr")
+	fs.BoolVar(&opts.coverage, "coverage", false, "print which OpenAPI operations and gRPC methods the run called")
+	fs.Float64Var(&opts.coverageMin, "coverage-min", 0,
+		"fail the run below `percent` API coverage; implies --coverage")
+	fs.Func("seed", "seed generated data such as {$uuid} with `n`, to reproduce a run", opts.setSeed)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "usage: go-expect run [flags] [file or directory]\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+
+	paths, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		return exitUsage
+	}
+	if len(paths) > 1 {
+		fmt.Fprintln(stderr, "go-expect: run takes a single file or directory")
+		return exitUsage
+	}
+	path := "."
+	if len(paths) == 1 {
+		path = paths[0]
+	}
+
+	if opts.env == "" {
+		opts.env = getenv("GO_EXPECT_ENV")
+	}
+	suite, err := load(path, expect.WithEnvironment(opts.env))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if err := configure(suite, opts, getenv); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if len(suite.Scenarios()) == 0 {
+		fmt.Fprintln(stderr, "go-expect: no scenarios to run")
+		return exitUsage
+	}
+
+	reports, err := openReports(opts.reports)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	suite.WithReporter(newConsoleReporter(stdout, useColor(stdout, getenv)))
+	for _, r := range reports {
+		suite.WithReporter(r.reporter)
+	}
+	if opts.verbose {
+		suite.WithLogger(slog.New(slog.NewTextHandler(stderr, nil)))
+	}
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	runErr := suite.RunContext(ctx)
+	if cov := suite.Coverage(); cov != nil {
+		if err := cov.WriteText(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+		}
+	}
+
+	code := exitOK
+	if runErr != nil {
+		code = exitFailed
+	}
+	for _, r := range reports {
+		if err := r.close(); err != nil {
+			fmt.Fprintln(stderr, err)
+			code = max(code, exitUsage)
+		}
+	}
+	return code
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional
+// arguments, and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// load reads a suite from a single file or every file in a directory.
+func load(path string, opts ...expect.LoadOption) (*expect.Suite, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: %w", err)
+	}
+	if info.IsDir() {
+		return expect.LoadDir(path, opts...)
+	}
+	return expect.LoadFile(path, opts...)
+}
+
+// configure applies connection URL overrides, filters, parallelism, the seed and coverage to suite.
+// URLs given with --url win over environment variables, and environment
+// variables scoped to --env win over unscoped ones.
+func configure(suite *expect.Suite, opts runOptions, getenv func(string) string) error {
+	for _, conn := range suite.Connections() {
+		name := envName(conn.GetName())
+		keys := []string{"GO_EXPECT_" + name + "_URL"}
+		if opts.env != "" {
+			keys = append([]string{"GO_EXPECT_" + envName(opts.env) + "_" + name + "_URL"}, keys...)
+		}
+		for _, key := range keys {
+			if url := getenv(key); url != "" {
+				if err := suite.WithConnectionURL(conn.GetName(), url); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	for _, u := range opts.urls {
+		name, url, ok := strings.Cut(u, "=")
+		if !ok {
+			return fmt.Errorf("go-expect: --url %q: expected name=url", u)
+		}
+		if err := suite.WithConnectionURL(name, url); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range opts.filters {
+		keep, err := parseFilter(f)
+		if err != nil {
+			return err
+		}
+		suite.Filter(keep)
+	}
+
+	suite.WithParallelism(opts.parallelism)
+	if opts.seed != nil {
+		suite.WithSeed(*opts.seed)
+	}
+	if opts.coverage || opts.coverageMin > 0 {
+		suite.WithCoverage(opts.coverageMin)
+	}
+	return nil
+}
+
+// envName converts a connection or environment name to its environment variable form.
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// parseFilter parses a scenario filter expression such as "name~checkout".
+func parseFilter(expr string) (func(*expect.Scenario) bool, error) {
+	i := strings.IndexAny(expr, "!=~")
+	if i < 0 {
+		return nil, fmt.Errorf("go-expect: --filter %q: expected name=x, name!=x, name~re or name!~re", expr)
+	}
+	field, op := strings.TrimSpace(expr[:i]), expr[i:i+1]
+	if op == "!" {
+		op = expr[i:min(i+2, len(expr))]
+	}
+	value := expr[i+len(op):]
+	if field != "name" {
+		return nil, fmt.Errorf("go-expect: --filter %q: unknown field %q", expr, field)
+	}
+
+	switch op {
+	case "=":
+		return func(sc *expect.Scenario) bool { return sc.Name == value }, nil
+	case "!=":
+		return func(sc *expect.Scenario) bool { return sc.Name != value }, nil
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("go-expect: --filter %q: %w", expr, err)
+		}
+		return func(sc *expect.Scenario) bool { return re.MatchString(sc.Name) == (op == "~") }, nil
+	default:
+		return nil, fmt.Errorf("go-expect: --filter %q: unknown operator %q", expr, op)
+	}
+}
+
+// fileReport is a report being written to a file.
+type fileReport struct {
+	reporter interface {
+		expect.Reporter
+		Err() error
+	}
+	file *os.File
+}
+
+func (r fileReport) close() error {
+	return errors.Join(r.reporter.Err(), r.file.Close())
+}
+
+func openReports(paths []string) ([]fileReport, error) {
+	for _, p := range paths {
+		if ext := strings.ToLower(filepath.Ext(p)); ext != ".xml" && ext != ".json" {
+			return nil, fmt.Errorf("go-expect: --report %q: unknown format, use .xml or .json", p)
+		}
+	}
+
+	reports := make([]fileReport, 0, len(paths))
+	for _, p := range paths {
+		f, err := os.Create(p)
+		if err != nil {
+			for _, r := range reports {
+				_ = r.file.Close()
+			}
+			return nil, fmt.Errorf("go-expect: --report: %w", err)
+		}
+		r := fileReport{file: f}
+		if strings.EqualFold(filepath.Ext(p), ".xml") {
+			r.reporter = expect.NewJUnitReporter(f)
+		} else {
+			r.reporter = expect.NewJSONReporter(f)
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+// consoleReporter prints one line per scenario and a summary, in the style of go test.
+type consoleReporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	color  bool // render body mismatch diffs with ANSI colours
+	passed int
+	failed int
+}
+
+func newConsoleReporter(w io.Writer, color bool) *consoleReporter {
+	return &consoleReporter{w: w, color: color}
+}
+
+// useColor reports whether w is a terminal and NO_COLOR is unset.
+func useColor(w io.Writer, getenv func(string) string) bool {
+	f, ok := w.(*os.File)
+	if !ok || getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *consoleReporter) Report(e expect.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case expect.EventScenarioFinish:
+		if e.Err == nil {
+			r.passed++
+			fmt.Fprintf(r.w, "--- PASS: %s (%.2fs)\n", e.Scenario, e.Duration.Seconds())
+			return
+		}
+		r.failed++
+		fmt.Fprintf(r.w, "--- FAIL: %s (%.2fs)\n", e.Scenario, e.Duration.Seconds())
+		msg := e.Err.Error()
+		var mismatch *expect.MismatchError
+		if r.color && errors.As(e.Err, &mismatch) {
+			if diff := mismatch.Diff(); diff != "" {
+				msg = strings.Replace(msg, diff, mismatch.ColorDiff(), 1)
+			}
+		}
+		for line := range strings.SplitSeq(msg, "\n") {
+			fmt.Fprintf(r.w, "    %s\n", line)
+		}
+	case expect.EventSuiteFinish:
+		if r.failed == 0 && e.Err != nil {
+			// Every scenario passed, but the run failed, e.g. below the coverage threshold.
+			fmt.Fprintf(r.w, "FAIL: %v (%.2fs)\n", e.Err, e.Duration.Seconds())
+			return
+		}
+		if r.failed == 0 {
+			fmt.Fprintf(r.w, "PASS: %d scenarios (%.2fs)\n", r.passed, e.Duration.Seconds())
+			return
+		}
+		fmt.Fprintf(r.w, "FAIL: %d of %d scenarios failed (%.2fs)\n",
+			r.failed, r.passed+r.failed, e.Duration.Seconds())
+	default:
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const runTestSuite = `
+connections:
+  - name: api
+    type: http
+    url: http://localhost:1
+scenarios:
+  - name: checkout ok
+    steps:
+      - request:
+          method: GET
+          endpoint: /ok
+        expect:
+          status: 200
+  - name: checkout missing
+    steps:
+      - request:
+          method: GET
+          endpoint: /missing
+        expect:
+          status: 200
+  - name: login
+    steps:
+      - request:
+          method: GET
+          endpoint: /ok
+        expect:
+          status: 200
+`
+
+func setupRun(t *testing.T) (dir, url string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "expect.yaml"), []byte(runTestSuite), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir, srv.URL
+}
+
+func TestRun(t *testing.T) {
+	dir, url := setupRun(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantCode int
+		wantOut  []string
+	}{
+		{
+			name:     "all scenarios",
+			args:     []string{"run", dir, "--url", "api=" + url},
+			wantCode: exitFailed,
+			wantOut:  []string{"--- PASS: checkout ok", "--- FAIL: checkout missing", "FAIL: 1 of 3 scenarios failed"},
+		},
+		{
+			name:     "filter",
+			args:     []string{"run", "--filter", "name!~missing", dir, "--url", "api=" + url},
+			wantCode: exitOK,
+			wantOut:  []string{"--- PASS: checkout ok", "--- PASS: login", "PASS: 2 scenarios"},
+		},
+		{
+			name:     "filter regexp",
+			args:     []string{"run", dir, "--filter", "name~^check", "--filter", "name!=checkout missing"},
+			env:      map[string]string{"GO_EXPECT_API_URL": url},
+			wantCode: exitOK,
+			wantOut:  []string{"PASS: 1 scenarios"},
+		},
+		{
+			name: "env scoped url",
+			args: []string{"run", dir, "--env", "staging", "--filter", "name=login"},
+			env: map[string]string{
+				"GO_EXPECT_STAGING_API_URL": url,
+				"GO_EXPECT_API_URL":         "http://localhost:1",
+			},
+			wantCode: exitOK,
+			wantOut:  []string{"--- PASS: login"},
+		},
+		{
+			name:     "seed",
+			args:     []string{"run", dir, "--seed", "42", "--filter", "name=login", "--url", "api=" + url},
+			wantCode: exitOK,
+			wantOut:  []string{"--- PASS: login"},
+		},
+		{
+			name:     "bad seed",
+			args:     []string{"run", dir, "--seed", "forty-two"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "no scenarios match",
+			args:     []string{"run", dir, "--filter", "name=nothing"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "bad filter",
+			args:     []string{"run", dir, "--filter", "tag=smoke"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "unknown connection",
+			args:     []string{"run", dir, "--url", "db=postgres://"},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "missing path",
+			args:     []string{"run", filepath.Join(dir, "nope")},
+			wantCode: exitUsage,
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"walk"},
+			wantCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			getenv := func(k string) string { return tt.env[k] }
+			code := run(t.Context(), tt.args, &stdout, &stderr, getenv)
+			if code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d\nstdout:\n%s\nstderr:\n%s", tt.wantCode, code, &stdout, &stderr)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, &stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Reports(t *testing.T) {
+	dir, url := setupRun(t)
+	out := t.TempDir()
+	junit, report := filepath.Join(out, "junit.xml"), filepath.Join(out, "report.json")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"run", dir, "--url", "api=" + url, "--report", junit, "--report", report}
+	if code := run(t.Context(), args, &stdout, &stderr, os.Getenv); code != exitFailed {
+		t.Fatalf("expected exit code %d, got %d\n%s", exitFailed, code, &stderr)
+	}
+
+	xml, err := os.ReadFile(junit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(xml), `<testcase name="checkout missing"`) {
+		t.Errorf("expected junit report to contain the failing scenario, got:\n%s", xml)
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Passed int `json:"passed"`
+		Failed int `json:"failed"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid json report: %v", err)
+	}
+	if doc.Passed != 2 || doc.Failed != 1 {
+		t.Errorf("expected 2 passed and 1 failed, got %+v", doc)
+	}
+}
+
+func TestRun_Coverage(t *testing.T) {
+	dir, url := setupRun(t)
+	spec := `
+openapi: 3.0.3
+paths:
+  /ok: {get: {responses: {"200": {description: ok}}}}
+  /missing: {get: {responses: {"404": {description: missing}}}}
+  /unused: {post: {responses: {"201": {description: created}}}}
+`
+	suite := strings.Replace(runTestSuite, "url: http://localhost:1",
+		"url: http://localhost:1\n    openapi: openapi.yaml", 1)
+	for name, content := range map[string]string{"expect.yaml": suite, "openapi.yaml": spec} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{
+		"run", filepath.Join(dir, "expect.yaml"),
+		"--url", "api=" + url, "--filter", "name~ok|login", "--coverage-min", "50",
+	}
+	if code := run(t.Context(), args, &stdout, &stderr, os.Getenv); code != exitFailed {
+		t.Fatalf("expected exit code %d, got %d\n%s", exitFailed, code, &stderr)
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"FAIL: go-expect: API coverage 33.3% is below the 50.0% threshold",
+		"API coverage: 33.3% (1 of 3 operations)",
+		"GET /ok       2 hits  200=2",
+		"POST /unused  0 hits",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"time"
 )
 
 // buildSuite performs a two-pass build over a set of parsed files:
 // first collecting all connections, then building scenarios with full connection context.
-func buildSuite(files []expectFile) (*Suite, error) {
+// Last, it applies the environment cfg selects.
+func buildSuite(files []expectFile, cfg *loadConfig) (*Suite, error) {
 	var allConns []Connection
 	for _, f := range files {
 		conns, err := buildFileConnections(f)
@@ -29,9 +31,13 @@ func buildSuite(files []expectFile) (*Suite, error) {
 		scenarios = append(scenarios, ss...)
 	}
 
-	return NewSuite().
+	suite := NewSuite().
 		WithConnections(slices.Collect(maps.Values(connMap))...).
-		WithScenarios(scenarios...), nil
+		WithScenarios(scenarios...)
+	if err := cfg.applyEnvironment(suite, files); err != nil {
+		return nil, err
+	}
+	return suite, nil
 }
 
 func buildConnMap(conns []Connection) (map[string]Connection, Connection) {
@@ -50,7 +56,7 @@ func buildConnMap(conns []Connection) (map[string]Connection, Connection) {
 func buildFileConnections(f expectFile) ([]Connection, error) {
 	var conns []Connection
 	for _, c := range f.Connections {
-		conn, err := buildFileConnection(c)
+		conn, err := buildFileConnection(c, f.src)
 		if err != nil {
 			return nil, err
 		}
@@ -63,10 +69,19 @@ func buildFileScenarios(f expectFile, connMap map[string]Connection, defaultConn
 	var scenarios []*Scenario
 	for _, s := range f.Scenarios {
 		sc := NewScenario(s.Name)
+		if s.Parallel {
+			sc.Parallel()
+		}
+		timeout, err := parseFileDuration(s.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %q: timeout: %w", s.Name, err)
+		}
+		sc.WithTimeout(timeout)
 		for _, st := range s.Steps {
 			if st.Request == nil {
 				continue
 			}
+			st.src = f.src
 			step, err := buildFileStep(st, connMap, defaultConn)
 			if err != nil {
 				return nil, fmt.Errorf("scenario %q: %w", s.Name, err)
@@ -78,17 +93,24 @@ func buildFileScenarios(f expectFile, connMap map[string]Connection, defaultConn
 	return scenarios, nil
 }
 
-func buildFileConnection(c fileConnection) (Connection, error) {
-	switch c.Type {
-	case "http", "https", "":
-		return HTTP(c.Name, c.URL), nil
-	case "grpc":
-		return GRPC(c.Name, c.URL), nil
-	case "postgres", "mysql", "sqlite", "sqlite3", "sqlserver":
-		return SQL(c.Name, c.Type, c.URL), nil
-	default:
-		return nil, fmt.Errorf("go-expect: unknown connection type %q", c.Type)
+func buildFileConnection(c fileConnection, src fileSource) (Connection, error) {
+	factory, err := registry.connectionFactory(c.Type)
+	if err != nil {
+		return nil, err
 	}
+	conn, err := factory(c.Name, c.URL)
+	if err != nil || c.OpenAPI == "" {
+		return conn, err
+	}
+	httpConn, ok := conn.(*HTTPConnection)
+	if !ok {
+		return nil, fmt.Errorf("go-expect: connection %q: openapi is only supported for http connections", c.Name)
+	}
+	spec, err := loadOpenAPI(c.OpenAPI, src)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: connection %q: %w", c.Name, err)
+	}
+	return httpConn.WithOpenAPI(spec), nil
 }
 
 func buildFileStep(s fileStep, connMap map[string]Connection, defaultConn Connection) (*StepBuilder, error) {
@@ -96,16 +118,53 @@ func buildFileStep(s fileStep, connMap map[string]Connection, defaultConn Connec
 	if c, ok := connMap[s.Request.Connection]; ok {
 		conn = c
 	}
-	switch conn.(type) {
-	case *HTTPConnection:
-		return buildFileHTTPStep(s)
-	case *GRPCConnection:
-		return buildFileGRPCStep(s)
-	case *SQLConnection:
-		return buildFileSQLStep(s)
+	build, err := registry.stepBuilder(conn)
+	if err != nil {
+		return nil, err
+	}
+	b, err := build(FileStep{
+		Connection: s.Request.Connection,
+		Request:    s.raw.Request,
+		Expect:     s.raw.Expect,
+		parsed:     s,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.Retry != nil {
+		policy, err := buildFileRetry(*s.Retry)
+		if err != nil {
+			return nil, err
+		}
+		b.WithRetry(policy)
+	}
+	return b, nil
+}
+
+func buildFileRetry(r fileRetry) (RetryPolicy, error) {
+	policy := RetryPolicy{Attempts: r.Attempts}
+	switch Backoff(r.Backoff) {
+	case "", BackoffConstant, BackoffExponential:
+		policy.Backoff = Backoff(r.Backoff)
 	default:
-		return nil, fmt.Errorf("go-expect: unsupported connection type %T", conn)
+		return RetryPolicy{}, fmt.Errorf("go-expect: unknown retry backoff %q", r.Backoff)
+	}
+	var err error
+	if policy.Interval, err = parseFileDuration(r.Interval); err != nil {
+		return RetryPolicy{}, fmt.Errorf("go-expect: retry interval: %w", err)
+	}
+	if policy.UntilTimeout, err = parseFileDuration(r.UntilTimeout); err != nil {
+		return RetryPolicy{}, fmt.Errorf("go-expect: retry until_timeout: %w", err)
 	}
+	return policy, nil
+}
+
+// parseFileDuration parses a duration string such as "200ms"; empty means 0.
+func parseFileDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
 }
 
 func buildFileHTTPStep(s fileStep) (*StepBuilder, error) {
@@ -117,7 +176,9 @@ func buildFileHTTPStep(s fileStep) (*StepBuilder, error) {
 	for k, v := range r.Query {
 		b.WithQuery(k, v)
 	}
-	if r.Body != nil {
+	if r.rawBody != nil {
+		b.WithBody(r.rawBody)
+	} else if r.Body != nil {
 		body, err := json.Marshal(r.Body)
 		if err != nil {
 			return nil, fmt.Errorf("marshal request body: %w", err)
@@ -131,14 +192,20 @@ func buildFileHTTPStep(s fileStep) (*StepBuilder, error) {
 			b.ExpectHeader(k, v)
 		}
 		if e.Body != nil {
-			body, err := json.Marshal(e.Body)
+			body, err := marshalExpected(e.Body, e.Match, s.src)
 			if err != nil {
-				return nil, fmt.Errorf("marshal expect body: %w", err)
+				return nil, fmt.Errorf("expect body: %w", err)
 			}
 			b.ExpectBody(body)
 		}
+		if err := applySchema(b, e.Schema, s.src); err != nil {
+			return nil, err
+		}
+		if err := applyAssert(b, e.Assert); err != nil {
+			return nil, err
+		}
 		for _, sv := range e.Save {
-			b.Save(sv.Field, sv.As)
+			b.httpExpect().Save = append(b.httpExpect().Save, sv.entry())
 		}
 	}
 	return b, nil
@@ -156,14 +223,20 @@ func buildFileSQLStep(s fileStep) (*StepBuilder, error) {
 			b.ExpectRowsAffected(int64(*e.RowsAffected))
 		}
 		for _, row := range e.Rows {
-			body, err := json.Marshal(row)
+			body, err := marshalExpected(row, e.Match, s.src)
 			if err != nil {
-				return nil, fmt.Errorf("marshal expect row: %w", err)
+				return nil, fmt.Errorf("expect row: %w", err)
 			}
 			b.ExpectRow(body)
 		}
+		if err := applySchema(b, e.Schema, s.src); err != nil {
+			return nil, err
+		}
+		if err := applyAssert(b, e.Assert); err != nil {
+			return nil, err
+		}
 		for _, sv := range e.Save {
-			b.SaveSQL(sv.Field, sv.As)
+			b.sqlExpect().Save = append(b.sqlExpect().Save, sv.entry())
 		}
 	}
 	return b, nil
@@ -179,7 +252,12 @@ func buildFileGRPCStep(s fileStep) (*StepBuilder, error) {
 			return nil, fmt.Errorf("marshal request body: %w", err)
 		}
 	}
+	messages, err := marshalEach(r.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request messages: %w", err)
+	}
 	b := GRPCRawCall(r.Connection, r.Endpoint, body)
+	b.grpcReq().Messages = messages
 	for k, v := range r.Header {
 		b.WithHeader(k, v)
 	}
@@ -187,15 +265,146 @@ func buildFileGRPCStep(s fileStep) (*StepBuilder, error) {
 		e := s.Expect
 		b.ExpectGRPCCode(e.Code)
 		if e.Body != nil {
-			body, err := json.Marshal(e.Body)
+			body, err := marshalExpected(e.Body, e.Match, s.src)
 			if err != nil {
-				return nil, fmt.Errorf("marshal expect body: %w", err)
+				return nil, fmt.Errorf("expect body: %w", err)
 			}
 			b.ExpectGRPCBody(body)
 		}
+		if e.MessageCount != nil {
+			b.ExpectGRPCMessageCount(*e.MessageCount)
+		}
+		for i, m := range e.Messages {
+			msg, err := marshalExpected(m, e.Match, s.src)
+			if err != nil {
+				return nil, fmt.Errorf("expect messages[%d]: %w", i, err)
+			}
+			b.ExpectGRPCMessages(msg)
+		}
+		b.grpcExpect().Unordered = e.Unordered
+		if err := applySchema(b, e.Schema, s.src); err != nil {
+			return nil, err
+		}
+		if err := applyAssert(b, e.Assert); err != nil {
+			return nil, err
+		}
 		for _, sv := range e.Save {
-			b.SaveGRPC(sv.Field, sv.As)
+			b.grpcExpect().Save = append(b.grpcExpect().Save, sv.entry())
 		}
 	}
 	return b, nil
 }
+
+// applyMatch wraps an expected value in the operator objects for the file's
+// match modes, so they apply to its whole tree.
+func applyMatch(v any, modes fileMatch) (any, error) {
+	for _, mode := range modes {
+		switch mode {
+		case "strict":
+			v = map[string]any{modeStrict.operator(): v}
+		case "ordered":
+			v = map[string]any{modeOrdered.operator(): v}
+		case "exact-length":
+			v = map[string]any{modeExactLength.operator(): v}
+		default:
+			return nil, fmt.Errorf("unknown match mode %q, want strict, ordered or exact-length", mode)
+		}
+	}
+	return v, nil
+}
+
+// applySchema sets the expectation's schema from expect.schema: a schema file
+// path relative to src, or an inline schema.
+func applySchema(b *StepBuilder, schema any, src fileSource) error {
+	if schema == nil {
+		return nil
+	}
+	m, err := newJSONSchema(schema, src)
+	if err != nil {
+		return fmt.Errorf("expect schema: %w", err)
+	}
+	b.expectSchema(m)
+	return nil
+}
+
+// applyAssert adds the expressions of expect.assert, checking that they parse.
+func applyAssert(b *StepBuilder, asserts []string) error {
+	for _, src := range asserts {
+		if err := compileExpr(src).err; err != nil {
+			return fmt.Errorf("expect assert %q: %w", src, err)
+		}
+	}
+	if len(asserts) > 0 {
+		b.ExpectAssert(asserts...)
+	}
+	return nil
+}
+
+// loadFileSchemas loads the schemas of $json_schema operators in v, so file
+// paths resolve relative to src rather than the working directory.
+func loadFileSchemas(v any, src fileSource) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		if op, arg, ok := operatorObject(val); ok && op == "$json_schema" {
+			return newJSONSchema(arg, src)
+		}
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			loaded, err := loadFileSchemas(e, src)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = loaded
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, e := range val {
+			loaded, err := loadFileSchemas(e, src)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = loaded
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// marshalExpected applies the match modes to v and marshals it to JSON,
+// rejecting malformed operator objects up front. src locates schema files.
+func marshalExpected(v any, modes fileMatch, src fileSource) ([]byte, error) {
+	v, err := loadFileSchemas(v, src)
+	if err != nil {
+		return nil, err
+	}
+	v, err = applyMatch(v, modes)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := ExpectBody(data).expected(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// marshalEach marshals every value to JSON.
+func marshalEach(values []any) ([][]byte, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make([][]byte, len(values))
+	for i, v := range values {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		out[i] = data
+	}
+	return out, nil
+}
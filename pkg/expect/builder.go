@@ -2,6 +2,7 @@ package expect
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -12,6 +13,14 @@ type StepBuilder struct {
 	step Step
 }
 
+// NewStep creates a StepBuilder for a custom Requester and Expectation,
+// typically from a StepBuilderFunc registered with RegisterStepBuilder.
+func NewStep(connection string, req Requester, exp Expectation) *StepBuilder {
+	return &StepBuilder{
+		step: Step{Connection: connection, Request: req, Expect: exp},
+	}
+}
+
 // HTTPStep creates a StepBuilder for an HTTP request with any method.
 func HTTPStep(method, path string) *StepBuilder {
 	return &StepBuilder{
@@ -103,18 +112,14 @@ func (b *StepBuilder) ExpectHeader(key, value string) *StepBuilder {
 //   - string  — exact string
 //   - any other value — marshalled to JSON for partial matching
 func (b *StepBuilder) ExpectBody(v any) *StepBuilder {
-	switch val := v.(type) {
-	case []byte:
-		b.httpExpect().Body = ExpectBody(val)
-	case string:
-		b.httpExpect().Body = ExpectBody(val)
-	default:
-		data, err := json.Marshal(v)
-		if err != nil {
-			panic("go-expect: ExpectBody marshal error: " + err.Error())
-		}
-		b.httpExpect().Body = ExpectBody(data)
-	}
+	b.httpExpect().Body = toExpectBody("ExpectBody", v)
+	return b
+}
+
+// ExpectBodyStrict is like ExpectBody but fails on keys the expectation does not
+// list, at any depth. []byte and string values must hold JSON.
+func (b *StepBuilder) ExpectBodyStrict(v any) *StepBuilder {
+	b.httpExpect().Body = toExpectBody("ExpectBodyStrict", Strict(rawJSON(v)))
 	return b
 }
 
@@ -124,6 +129,19 @@ func (b *StepBuilder) Save(field, as string) *StepBuilder {
 	return b
 }
 
+// SaveSecret is like Save, but marks the variable as secret, so its value is
+// masked as *** wherever it is logged or reported.
+func (b *StepBuilder) SaveSecret(field, as string) *StepBuilder {
+	b.httpExpect().Save = append(b.httpExpect().Save, SaveEntry{Field: field, As: as, Secret: true})
+	return b
+}
+
+// WithRetry re-executes the step according to policy until its expectations pass.
+func (b *StepBuilder) WithRetry(policy RetryPolicy) *StepBuilder {
+	b.step.Retry = &policy
+	return b
+}
+
 // Build returns the completed Step.
 func (b *StepBuilder) Build() Step {
 	return b.step
@@ -159,18 +177,14 @@ func (b *StepBuilder) ExpectGRPCCode(code string) *StepBuilder {
 
 // ExpectGRPCBody sets the expected gRPC response body for partial JSON matching.
 func (b *StepBuilder) ExpectGRPCBody(v any) *StepBuilder {
-	switch val := v.(type) {
-	case []byte:
-		b.grpcExpect().Body = ExpectBody(val)
-	case string:
-		b.grpcExpect().Body = ExpectBody(val)
-	default:
-		data, err := json.Marshal(v)
-		if err != nil {
-			panic("go-expect: ExpectGRPCBody marshal error: " + err.Error())
-		}
-		b.grpcExpect().Body = ExpectBody(data)
-	}
+	b.grpcExpect().Body = toExpectBody("ExpectGRPCBody", v)
+	return b
+}
+
+// ExpectGRPCBodyStrict is like ExpectGRPCBody but fails on keys the expectation
+// does not list, at any depth.
+func (b *StepBuilder) ExpectGRPCBodyStrict(v any) *StepBuilder {
+	b.grpcExpect().Body = toExpectBody("ExpectGRPCBodyStrict", Strict(rawJSON(v)))
 	return b
 }
 
@@ -180,6 +194,103 @@ func (b *StepBuilder) SaveGRPC(field, as string) *StepBuilder {
 	return b
 }
 
+// SaveGRPCSecret is like SaveGRPC, but marks the variable as secret, so its
+// value is masked as *** wherever it is logged or reported.
+func (b *StepBuilder) SaveGRPCSecret(field, as string) *StepBuilder {
+	b.grpcExpect().Save = append(b.grpcExpect().Save, SaveEntry{Field: field, As: as, Secret: true})
+	return b
+}
+
+// GRPCStreamCall creates a step that invokes a client, server or bidi streaming gRPC
+// method, sending each raw JSON message on the request stream in order.
+// Server-streaming methods take a single message.
+func GRPCStreamCall(connection, fullMethod string, messages ...[]byte) *StepBuilder {
+	return &StepBuilder{
+		step: Step{
+			Connection: connection,
+			Request: &GRPCRequest{
+				FullMethod: fullMethod,
+				Messages:   messages,
+				Header:     make(map[string]string),
+			},
+			Expect: &GRPCExpect{},
+		},
+	}
+}
+
+// ExpectGRPCMessageCount asserts the exact number of streamed response messages.
+func (b *StepBuilder) ExpectGRPCMessageCount(n int) *StepBuilder {
+	b.grpcExpect().MessageCount = &n
+	return b
+}
+
+// ExpectGRPCMessages sets the expected streamed response messages, matched in order
+// for partial JSON matching. Values are converted as in ExpectGRPCBody.
+func (b *StepBuilder) ExpectGRPCMessages(v ...any) *StepBuilder {
+	for _, m := range v {
+		b.grpcExpect().Messages = append(b.grpcExpect().Messages, toExpectBody("ExpectGRPCMessages", m))
+	}
+	return b
+}
+
+// ExpectGRPCMessagesUnordered is like ExpectGRPCMessages but each expected message
+// may match any distinct received message.
+func (b *StepBuilder) ExpectGRPCMessagesUnordered(v ...any) *StepBuilder {
+	b.grpcExpect().Unordered = true
+	return b.ExpectGRPCMessages(v...)
+}
+
+// SaveGRPCMessage extracts a field from the nth (0-based) streamed response message
+// into a variable for later steps.
+func (b *StepBuilder) SaveGRPCMessage(n int, field, as string) *StepBuilder {
+	return b.SaveGRPC(fmt.Sprintf("%d.%s", n, field), as)
+}
+
+// ExpectJSONSchema asserts the JSON response conforms to a JSON Schema, given as
+// the schema document ([]byte), the path of a schema file (string) or a decoded
+// schema; see JSONSchema. It applies to HTTP and unary gRPC bodies, every
+// streamed gRPC message and every SQL row.
+func (b *StepBuilder) ExpectJSONSchema(schemaBytesOrPath any) *StepBuilder {
+	m, err := newJSONSchema(schemaBytesOrPath, fileSource{})
+	if err != nil {
+		panic("go-expect: ExpectJSONSchema: " + err.Error())
+	}
+	return b.expectSchema(m)
+}
+
+// ExpectAssert asserts that each expression holds for the response, such as
+// "body.total == body.items | sum(price)". Expressions see the scenario's
+// variables and the response: body, status and headers for HTTP; body, or
+// messages when streaming, and code for gRPC; rows and rows_affected for SQL.
+func (b *StepBuilder) ExpectAssert(exprs ...string) *StepBuilder {
+	for _, src := range exprs {
+		if err := compileExpr(src).err; err != nil {
+			panic(fmt.Sprintf("go-expect: ExpectAssert: %q: %v", src, err))
+		}
+	}
+	switch exp := b.step.Expect.(type) {
+	case *HTTPExpect:
+		exp.Assert = append(exp.Assert, exprs...)
+	case *GRPCExpect:
+		exp.Assert = append(exp.Assert, exprs...)
+	case *SQLExpect:
+		exp.Assert = append(exp.Assert, exprs...)
+	}
+	return b
+}
+
+func (b *StepBuilder) expectSchema(m Matcher) *StepBuilder {
+	switch exp := b.step.Expect.(type) {
+	case *HTTPExpect:
+		exp.Schema = m
+	case *GRPCExpect:
+		exp.Schema = m
+	case *SQLExpect:
+		exp.Schema = m
+	}
+	return b
+}
+
 // SQLStep creates a StepBuilder for a SQL request.
 func SQLStep(connection, statement string, params ...any) *StepBuilder {
 	return &StepBuilder{
@@ -211,25 +322,50 @@ func (b *StepBuilder) ExpectRowsAffected(n int64) *StepBuilder {
 
 // ExpectRow adds an expected row for partial JSON matching.
 func (b *StepBuilder) ExpectRow(v any) *StepBuilder {
+	b.sqlExpect().Rows = append(b.sqlExpect().Rows, toExpectBody("ExpectRow", v))
+	return b
+}
+
+// SaveSQL extracts a field from the first SQL result row into a variable for later steps.
+func (b *StepBuilder) SaveSQL(field, as string) *StepBuilder {
+	b.sqlExpect().Save = append(b.sqlExpect().Save, SaveEntry{Field: field, As: as})
+	return b
+}
+
+// SaveSQLSecret is like SaveSQL, but marks the variable as secret, so its
+// value is masked as *** wherever it is logged or reported.
+func (b *StepBuilder) SaveSQLSecret(field, as string) *StepBuilder {
+	b.sqlExpect().Save = append(b.sqlExpect().Save, SaveEntry{Field: field, As: as, Secret: true})
+	return b
+}
+
+// toExpectBody converts v to an ExpectBody: []byte and string are used as-is,
+// any other value is marshalled to JSON. caller names the builder method in panics.
+func toExpectBody(caller string, v any) ExpectBody {
 	switch val := v.(type) {
 	case []byte:
-		b.sqlExpect().Rows = append(b.sqlExpect().Rows, ExpectBody(val))
+		return ExpectBody(val)
 	case string:
-		b.sqlExpect().Rows = append(b.sqlExpect().Rows, ExpectBody(val))
+		return ExpectBody(val)
 	default:
 		data, err := json.Marshal(v)
 		if err != nil {
-			panic("go-expect: ExpectRow marshal error: " + err.Error())
+			panic("go-expect: " + caller + " marshal error: " + err.Error())
 		}
-		b.sqlExpect().Rows = append(b.sqlExpect().Rows, ExpectBody(data))
+		return ExpectBody(data)
 	}
-	return b
 }
 
-// SaveSQL extracts a field from the first SQL result row into a variable for later steps.
-func (b *StepBuilder) SaveSQL(field, as string) *StepBuilder {
-	b.sqlExpect().Save = append(b.sqlExpect().Save, SaveEntry{Field: field, As: as})
-	return b
+// rawJSON lets []byte and string JSON documents be embedded in a marshalled value.
+func rawJSON(v any) any {
+	switch val := v.(type) {
+	case []byte:
+		return json.RawMessage(val)
+	case string:
+		return json.RawMessage(val)
+	default:
+		return v
+	}
 }
 
 func (b *StepBuilder) sqlReq() *SQLRequest {
@@ -248,6 +384,10 @@ func (b *StepBuilder) httpExpect() *HTTPExpect {
 	return b.step.Expect.(*HTTPExpect)
 }
 
+func (b *StepBuilder) grpcReq() *GRPCRequest {
+	return b.step.Request.(*GRPCRequest)
+}
+
 func (b *StepBuilder) grpcExpect() *GRPCExpect {
 	return b.step.Expect.(*GRPCExpect)
 }
@@ -0,0 +1,201 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Coverage reports which operations of the services under test a run called:
+// the operations of the OpenAPI documents attached to HTTP connections, and
+// the methods gRPC connections list through server reflection.
+type Coverage struct {
+	Operations []OperationCoverage `json:"operations"`
+	// Covered is the number of operations called at least once, of Total.
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+	// Percent is Covered as a percentage of Total; 0 when there are no operations.
+	Percent float64 `json:"percent"`
+}
+
+// OperationCoverage is how often a run called one operation.
+type OperationCoverage struct {
+	Connection string `json:"connection"`
+	// Operation is "GET /users/{id}" for HTTP, or "/pkg.Service/Method" for gRPC.
+	Operation string `json:"operation"`
+	Hits      int    `json:"hits"`
+	// Statuses counts the HTTP status codes, or gRPC code names, observed.
+	Statuses map[string]int `json:"statuses,omitempty"`
+}
+
+// WriteText writes the report as a table, one operation per line.
+func (c *Coverage) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "API coverage: %.1f%% (%d of %d operations)\n", c.Percent, c.Covered, c.Total)
+	for _, op := range c.Operations {
+		statuses := make([]string, 0, len(op.Statuses))
+		for _, s := range slices.Sorted(maps.Keys(op.Statuses)) {
+			statuses = append(statuses, fmt.Sprintf("%s=%d", s, op.Statuses[s]))
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%d hits\t%s\n", op.Connection, op.Operation, op.Hits, strings.Join(statuses, " "))
+	}
+	return tw.Flush()
+}
+
+// WithCoverage records the operations each run calls; Coverage returns the
+// report afterwards. A run that covers less than minPercent percent of the
+// operations fails; 0 only reports.
+func (s *Suite) WithCoverage(minPercent float64) *Suite {
+	s.coverageOn = true
+	s.coverageMin = minPercent
+	return s
+}
+
+// Coverage returns the coverage report of the last run, or nil if WithCoverage
+// was not set.
+func (s *Suite) Coverage() *Coverage {
+	return s.coverage
+}
+
+// startCoverage returns ctx carrying a fresh recorder when coverage is enabled.
+func (s *Suite) startCoverage(ctx context.Context) (context.Context, *coverageRecorder) {
+	if !s.coverageOn {
+		return ctx, nil
+	}
+	rec := &coverageRecorder{}
+	return context.WithValue(ctx, coverageKey{}, rec), rec
+}
+
+// finishCoverage builds the report from what rec recorded and checks it
+// against the threshold. Connections that could not be listed are left out of
+// the report.
+func (s *Suite) finishCoverage(ctx context.Context, rec *coverageRecorder) error {
+	if rec == nil {
+		return nil
+	}
+	cov, err := s.buildCoverage(ctx, rec)
+	s.coverage = cov
+	if err != nil {
+		return err
+	}
+	if cov.Percent < s.coverageMin {
+		return fmt.Errorf("go-expect: API coverage %.1f%% is below the %.1f%% threshold", cov.Percent, s.coverageMin)
+	}
+	return nil
+}
+
+func (s *Suite) buildCoverage(ctx context.Context, rec *coverageRecorder) (*Coverage, error) {
+	cov := &Coverage{}
+	var errs []error
+	for _, conn := range s.Connections() {
+		var (
+			ops   []string
+			match func(coverageHit) string
+		)
+		switch c := conn.(type) {
+		case *HTTPConnection:
+			if c.OpenAPI == nil {
+				continue
+			}
+			for _, op := range c.OpenAPI.operations {
+				ops = append(ops, op.method+" "+op.path)
+			}
+			match = func(h coverageHit) string {
+				if op := c.OpenAPI.operation(h.method, h.target, c.URL); op != nil {
+					return op.method + " " + op.path
+				}
+				return ""
+			}
+		case *GRPCConnection:
+			methods, err := c.listMethods(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("go-expect: coverage: connection %q: %w", c.Name, err))
+				continue
+			}
+			ops = methods
+			match = func(h coverageHit) string { return h.target }
+		default:
+			continue
+		}
+		cov.add(conn.GetName(), ops, rec.hitsFor(conn.GetName()), match)
+	}
+	if cov.Total > 0 {
+		cov.Percent = 100 * float64(cov.Covered) / float64(cov.Total)
+	}
+	return cov, errors.Join(errs...)
+}
+
+// add appends the operations of one connection, counting the hits matching each.
+func (c *Coverage) add(conn string, ops []string, hits []coverageHit, match func(coverageHit) string) {
+	byOp := make(map[string]*OperationCoverage, len(ops))
+	start := len(c.Operations)
+	for _, op := range ops {
+		c.Operations = append(c.Operations, OperationCoverage{Connection: conn, Operation: op})
+	}
+	for i := start; i < len(c.Operations); i++ {
+		byOp[c.Operations[i].Operation] = &c.Operations[i]
+	}
+	for _, h := range hits {
+		op, ok := byOp[match(h)]
+		if !ok {
+			continue
+		}
+		if op.Statuses == nil {
+			op.Statuses = make(map[string]int)
+		}
+		op.Hits++
+		op.Statuses[h.status]++
+	}
+	for _, op := range c.Operations[start:] {
+		if op.Hits > 0 {
+			c.Covered++
+		}
+	}
+	c.Total += len(ops)
+}
+
+// coverageKey is the context key of the run's coverageRecorder.
+type coverageKey struct{}
+
+// coverageHit is one call made by a run.
+type coverageHit struct {
+	conn   string
+	method string // HTTP method; "" for gRPC
+	target string // HTTP request path, or gRPC full method
+	status string // HTTP status code, or gRPC code name
+}
+
+// coverageRecorder collects the calls of a run; scenarios may run in parallel.
+type coverageRecorder struct {
+	mu   sync.Mutex
+	hits []coverageHit
+}
+
+// recordCoverage notes a call when ctx carries a coverageRecorder.
+func recordCoverage(ctx context.Context, h coverageHit) {
+	rec, ok := ctx.Value(coverageKey{}).(*coverageRecorder)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.hits = append(rec.hits, h)
+}
+
+func (r *coverageRecorder) hitsFor(conn string) []coverageHit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []coverageHit
+	for _, h := range r.hits {
+		if h.conn == conn {
+			out = append(out, h)
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package expect
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestSuite_coverageHTTP(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := petstore(t)
+
+	newSuite := func(minPercent float64) *Suite {
+		return NewSuite().
+			WithConnections(HTTP("pets", srv.URL+"/v1").WithOpenAPI(spec)).
+			WithScenarios(NewScenario("pets").
+				AddStep(GET("/pets").WithQuery("limit", "1").ExpectStatus(200)).
+				AddStep(GET("/pets/1").ExpectStatus(200)).
+				AddStep(GET("/pets/1").ExpectStatus(200))).
+			WithCoverage(minPercent)
+	}
+
+	suite := newSuite(50)
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cov := suite.Coverage()
+	if cov.Covered != 2 || cov.Total != 4 || cov.Percent != 50 {
+		t.Errorf("expected 2 of 4 operations covered, got %d of %d (%.1f%%)", cov.Covered, cov.Total, cov.Percent)
+	}
+	hits := map[string]int{}
+	for _, op := range cov.Operations {
+		hits[op.Operation] = op.Hits
+		if op.Operation == "GET /pets/{id}" && !maps.Equal(op.Statuses, map[string]int{"200": 2}) {
+			t.Errorf("expected two 200s for GET /pets/{id}, got %v", op.Statuses)
+		}
+	}
+	want := map[string]int{"GET /pets": 1, "POST /pets": 0, "GET /pets/mine": 0, "GET /pets/{id}": 2}
+	if !maps.Equal(hits, want) {
+		t.Errorf("expected hits %v, got %v", want, hits)
+	}
+
+	var out bytes.Buffer
+	if err := cov.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "API coverage: 50.0% (2 of 4 operations)\n") ||
+		!strings.Contains(out.String(), "GET /pets/{id}  2 hits  200=2") {
+		t.Errorf("unexpected text report:\n%s", &out)
+	}
+
+	err = newSuite(75).Run()
+	if err == nil || !strings.Contains(err.Error(), "API coverage 50.0% is below the 75.0% threshold") {
+		t.Errorf("expected threshold error, got %v", err)
+	}
+}
+
+func TestSuite_coverageGRPC(t *testing.T) {
+	conn := GRPC("numbers", startNumbersServer(t))
+	t.Cleanup(func() { conn.Close() })
+
+	suite := NewSuite().
+		WithConnections(conn).
+		WithScenarios(NewScenario("numbers").
+			AddStep(GRPCRawCall("numbers", "/numbers.Numbers/Double", []byte(`{"n":1}`)).ExpectGRPCCode("OK")).
+			AddStep(GRPCRawCall("numbers", "/numbers.Numbers/Count", []byte(`{"n":2}`)).ExpectGRPCCode("OK"))).
+		WithCoverage(0)
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cov := suite.Coverage()
+	if cov.Covered != 2 || cov.Total != 4 {
+		t.Fatalf("expected 2 of 4 methods covered, got %+v", cov)
+	}
+	for _, op := range cov.Operations {
+		if op.Connection != "numbers" || !strings.HasPrefix(op.Operation, "/numbers.Numbers/") {
+			t.Errorf("unexpected operation %+v", op)
+		}
+		if op.Operation == "/numbers.Numbers/Double" && !maps.Equal(op.Statuses, map[string]int{"OK": 1}) {
+			t.Errorf("expected one OK for Double, got %v", op.Statuses)
+		}
+	}
+}
+
+func TestSuite_coverageDisabled(t *testing.T) {
+	suite := NewSuite()
+	if err := suite.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Coverage() != nil {
+		t.Error("expected no coverage report without WithCoverage")
+	}
+}
@@ -0,0 +1,299 @@
+package expect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOption configures how a suite is loaded from files.
+type LoadOption func(*loadConfig)
+
+// WithEnvironment selects the environment whose block in the files'
+// environments: overrides connection URLs and seeds variables, and whose
+// .env.<name> file is loaded. It defaults to the GO_EXPECT_ENV environment
+// variable.
+func WithEnvironment(name string) LoadOption {
+	return func(c *loadConfig) { c.environment = name }
+}
+
+// WithEnvFile loads variables for ${VAR} expansion from a .env file, in
+// addition to the .env files next to the loaded files. Later files win over
+// earlier ones, and the process environment wins over them all.
+func WithEnvFile(path string) LoadOption {
+	return func(c *loadConfig) { c.envFiles = append(c.envFiles, path) }
+}
+
+// loadConfig is what loading files depends on besides the files themselves.
+type loadConfig struct {
+	environment string
+	envFiles    []string
+	dotenv      map[string]string // values from .env files
+	expanded    map[string]string // values of the variables expanded, to mask secrets
+}
+
+func newLoadConfig(opts []LoadOption) *loadConfig {
+	c := &loadConfig{environment: os.Getenv("GO_EXPECT_ENV"), dotenv: map[string]string{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// loadDotEnv reads the .env and .env.<environment> files in the source's
+// directory, if there are any, and then those given with WithEnvFile.
+func (c *loadConfig) loadDotEnv(src fileSource) error {
+	names := []string{".env"}
+	if c.environment != "" {
+		names = append(names, ".env."+c.environment)
+	}
+	for _, name := range names {
+		p := src.join("", name)
+		data, err := src.readFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("go-expect: read %q: %w", p, err)
+		}
+		if err := c.addDotEnv(p, data); err != nil {
+			return err
+		}
+	}
+	return c.loadEnvFiles()
+}
+
+// loadEnvFiles reads the files given with WithEnvFile.
+func (c *loadConfig) loadEnvFiles() error {
+	for _, p := range c.envFiles {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("go-expect: read env file %q: %w", p, err)
+		}
+		if err := c.addDotEnv(p, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *loadConfig) addDotEnv(name string, data []byte) error {
+	values, err := parseDotEnv(data)
+	if err != nil {
+		return fmt.Errorf("go-expect: parse env file %q: %w", name, err)
+	}
+	maps.Copy(c.dotenv, values)
+	return nil
+}
+
+// lookupEnv returns the value of an environment variable, from the process
+// environment or else the .env files.
+func (c *loadConfig) lookupEnv(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	v, ok := c.dotenv[name]
+	return v, ok
+}
+
+//nolint:gochecknoglobals // compiled once
+var envVarRe = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} with the variable's value, or "" when it is not
+// set; ${VAR:-default} with default when VAR is unset or empty; and fails on
+// ${VAR:?message} when it is. $$ is a literal $.
+func (c *loadConfig) expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var err error
+	out := envVarRe.ReplaceAllStringFunc(s, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := envVarRe.FindStringSubmatch(m)
+		name, op, arg := sub[1], sub[2], sub[3]
+		val, _ := c.lookupEnv(name)
+		if val != "" {
+			if c.expanded == nil {
+				c.expanded = map[string]string{}
+			}
+			c.expanded[name] = val
+		}
+		switch {
+		case val != "" || op == "":
+			return val
+		case op == ":?" && err == nil:
+			if arg == "" {
+				arg = "not set"
+			}
+			err = fmt.Errorf("${%s}: %s", name, arg)
+		}
+		return arg
+	})
+	return out, err
+}
+
+// expandYAMLEnv expands ${VAR} in every scalar of a parsed YAML document but
+// mapping keys, which keep $$ for operator escapes such as {$$gt: 0}. Plain
+// scalars are resolved again afterwards, so status: ${STATUS:-200} still
+// decodes as a number.
+func (c *loadConfig) expandYAMLEnv(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "$") {
+		v, err := c.expandEnv(n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if v != n.Value && n.Style == 0 {
+			n.Tag = ""
+		}
+		n.Value = v
+	}
+	for i, child := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if err := c.expandYAMLEnv(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandJSONEnv expands ${VAR} within the string literals of a JSON document
+// but object keys, like expandYAMLEnv.
+func (c *loadConfig) expandJSONEnv(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("$")) || !json.Valid(data) {
+		return data, nil
+	}
+	var out bytes.Buffer
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			out.WriteByte(data[i])
+			i++
+			continue
+		}
+		end := jsonStringEnd(data, i)
+		lit := data[i:end]
+		var s string
+		isKey := bytes.HasPrefix(bytes.TrimLeft(data[end:], " \t\r\n"), []byte(":"))
+		if !isKey && bytes.Contains(lit, []byte("$")) && json.Unmarshal(lit, &s) == nil {
+			expanded, err := c.expandEnv(s)
+			if err != nil {
+				return nil, err
+			}
+			if expanded != s {
+				if lit, err = json.Marshal(expanded); err != nil {
+					return nil, err
+				}
+			}
+		}
+		out.Write(lit)
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+// applyEnvironment overrides the suite's connection URLs and seeds its
+// variables and secrets from the selected environment's blocks in files.
+// Files without environments: blocks are fine, but when any has them, the
+// environment must be one of them. The suite also learns the environment
+// variables expanded, to mask those that are secret.
+func (c *loadConfig) applyEnvironment(suite *Suite, files []expectFile) error {
+	suite.env = c.expanded
+	if c.environment == "" {
+		return nil
+	}
+	var (
+		found    bool
+		declared []string
+		conns    = map[string]string{}
+		vars     = map[string]any{}
+		secrets  = map[string]any{}
+	)
+	for _, f := range files {
+		declared = append(declared, slices.Collect(maps.Keys(f.Environments))...)
+		if env, ok := f.Environments[c.environment]; ok {
+			found = true
+			maps.Copy(conns, env.Connections)
+			maps.Copy(vars, env.Vars)
+			maps.Copy(secrets, env.Secrets)
+		}
+	}
+	if !found {
+		if len(declared) > 0 {
+			slices.Sort(declared)
+			return fmt.Errorf("go-expect: unknown environment %q; files declare %s",
+				c.environment, strings.Join(slices.Compact(declared), ", "))
+		}
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(conns)) {
+		if err := suite.WithConnectionURL(name, conns[name]); err != nil {
+			return fmt.Errorf("go-expect: environment %q: %w", c.environment, err)
+		}
+	}
+	suite.WithVars(vars)
+	if len(secrets) > 0 {
+		suite.WithSecrets(secrets)
+	}
+	return nil
+}
+
+// parseDotEnv parses a .env file: KEY=value lines, optionally prefixed with
+// export, with # comments. Values may be 'single quoted', taken literally, or
+// "double quoted", where \n, \t, \" and \\ are escapes.
+func parseDotEnv(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value, err := dotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		values[key] = value
+	}
+	return values, sc.Err()
+}
+
+func dotEnvValue(v string) (string, error) {
+	if v == "" || (v[0] != '"' && v[0] != '\'') {
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		return v, nil
+	}
+	quote := v[0]
+	end := strings.LastIndexByte(v, quote)
+	if end == 0 {
+		return "", errors.New("unterminated quote")
+	}
+	if rest := strings.TrimSpace(v[end+1:]); rest != "" && rest[0] != '#' {
+		return "", fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	v = v[1:end]
+	if quote == '\'' {
+		return v, nil
+	}
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(v), nil
+}
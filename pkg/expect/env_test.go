@@ -0,0 +1,248 @@
+package expect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadConfig_expandEnv(t *testing.T) {
+	t.Setenv("GO_EXPECT_TEST_HOST", "api.test")
+	t.Setenv("GO_EXPECT_TEST_EMPTY", "")
+	cfg := &loadConfig{dotenv: map[string]string{"GO_EXPECT_TEST_HOST": "ignored", "FROM_DOTENV": "dot"}}
+	tests := []struct {
+		in, want, wantErr string
+	}{
+		{"http://${GO_EXPECT_TEST_HOST}:8080", "http://api.test:8080", ""},
+		{"${FROM_DOTENV}", "dot", ""},
+		{"${GO_EXPECT_TEST_UNSET}", "", ""},
+		{"${GO_EXPECT_TEST_UNSET:-fallback}", "fallback", ""},
+		{"${GO_EXPECT_TEST_EMPTY:-fallback}", "fallback", ""},
+		{"${GO_EXPECT_TEST_HOST:-fallback}", "api.test", ""},
+		{"${GO_EXPECT_TEST_HOST:?required}", "api.test", ""},
+		{"$${GO_EXPECT_TEST_HOST} costs $5", "${GO_EXPECT_TEST_HOST} costs $5", ""},
+		{"{$uuid} ${not a var}", "{$uuid} ${not a var}", ""},
+		{"${GO_EXPECT_TEST_UNSET:?set the token}", "", "${GO_EXPECT_TEST_UNSET}: set the token"},
+		{"${GO_EXPECT_TEST_UNSET:?}", "", "${GO_EXPECT_TEST_UNSET}: not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := cfg.expandEnv(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("expected %q, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestParseDotEnv(t *testing.T) {
+	got, err := parseDotEnv([]byte(`
+# comment
+HOST=localhost
+export PORT = 8080
+EMPTY=
+PLAIN=a b # trailing comment
+SINGLE='no $escapes\n here'
+DOUBLE="line\none" # comment
+URL=http://x/?a=b
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"HOST": "localhost", "PORT": "8080", "EMPTY": "", "PLAIN": "a b",
+		"SINGLE": `no $escapes\n here`, "DOUBLE": "line\none", "URL": "http://x/?a=b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, bad := range []string{"NOEQUALS", "=value", "A B=c", `A="open`, `A="x" y`} {
+		if _, err := parseDotEnv([]byte(bad)); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestLoadYAML_env(t *testing.T) {
+	t.Setenv("GO_EXPECT_TEST_STATUS", "201")
+	suite, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: ${GO_EXPECT_TEST_URL:-http://localhost:8080}
+scenarios:
+  - name: ${GO_EXPECT_TEST_NAME:-create}
+    steps:
+      - request:
+          method: POST
+          endpoint: /items
+          body: {name: "${GO_EXPECT_TEST_STATUS}"}
+        expect:
+          status: ${GO_EXPECT_TEST_STATUS}
+`))
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	if got := suite.connections["api"].(*HTTPConnection).URL; got != "http://localhost:8080" {
+		t.Errorf("expected the default URL, got %q", got)
+	}
+	step := suite.scenarios[0].steps[0]
+	if suite.scenarios[0].Name != "create" || step.Expect.(*HTTPExpect).Status != 201 {
+		t.Errorf("expected scenario create expecting 201, got %q and %d",
+			suite.scenarios[0].Name, step.Expect.(*HTTPExpect).Status)
+	}
+	if body := string(step.Request.(*HTTPRequest).Body); body != `{"name":"201"}` {
+		t.Errorf("expected a quoted string in the body, got %s", body)
+	}
+
+	if _, err := LoadYAML([]byte("connections:\n  - url: ${GO_EXPECT_TEST_UNSET:?no url}\n")); err == nil ||
+		!strings.Contains(err.Error(), "line 2: ${GO_EXPECT_TEST_UNSET}: no url") {
+		t.Errorf("expected a required variable error, got %v", err)
+	}
+}
+
+func TestLoadJSON_env(t *testing.T) {
+	t.Setenv("GO_EXPECT_TEST_URL", `http://"quoted"`)
+	suite, err := LoadJSON([]byte(`{"connections": [{"name": "api", "type": "http", "url": "${GO_EXPECT_TEST_URL}"}]}`))
+	if err != nil {
+		t.Fatalf("LoadJSON error: %v", err)
+	}
+	if got := suite.connections["api"].(*HTTPConnection).URL; got != `http://"quoted"` {
+		t.Errorf("expected the expanded URL, got %q", got)
+	}
+}
+
+func TestLoad_envKeys(t *testing.T) {
+	yamlSuite, err := LoadYAML([]byte(`
+connections:
+  - {name: api, type: http, url: http://localhost:8080}
+scenarios:
+  - name: escaped key
+    steps:
+      - request: {method: GET, endpoint: /}
+        expect:
+          body: {filter: {$$gt: 0}}
+`))
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	jsonSuite, err := LoadJSON([]byte(`{
+	"connections": [{"name": "api", "type": "http", "url": "http://localhost:8080"}],
+	"scenarios": [{"name": "escaped key", "steps": [{
+		"request": {"method": "GET", "endpoint": "/"},
+		"expect": {"body": {"filter": {"$$gt": 0}}}
+	}]}]}`))
+	if err != nil {
+		t.Fatalf("LoadJSON error: %v", err)
+	}
+	for name, suite := range map[string]*Suite{"yaml": yamlSuite, "json": jsonSuite} {
+		exp := suite.scenarios[0].steps[0].Expect.(*HTTPExpect)
+		if err := exp.Body.Validate([]byte(`{"filter":{"$gt":0}}`)); err != nil {
+			t.Errorf("%s: expected the literal $gt key to match, got %v", name, err)
+		}
+	}
+}
+
+const envTestSuite = `
+connections:
+  - name: api
+    type: http
+    url: http://localhost:1
+environments:
+  staging:
+    connections:
+      api: ${STAGING_URL}
+    vars:
+      tenant: ${TENANT:-acme}
+  prod:
+    connections:
+      api: http://localhost:2
+scenarios:
+  - name: tenant
+    steps:
+      - request:
+          method: GET
+          endpoint: /{tenant}
+        expect:
+          status: 200
+`
+
+func TestLoadFile_environment(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { path = r.URL.Path }))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("expect.yaml", envTestSuite)
+	write(".env", "STAGING_URL=http://localhost:3\nTENANT=base\n")
+	write(".env.staging", "STAGING_URL="+srv.URL+"\n")
+	extra := filepath.Join(t.TempDir(), "extra.env")
+	if err := os.WriteFile(extra, []byte("TENANT=initech\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	suite, err := LoadFile(filepath.Join(dir, "expect.yaml"), WithEnvironment("staging"), WithEnvFile(extra))
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	if err := suite.RunContext(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/initech" {
+		t.Errorf("expected the environment's tenant in the path, got %q", path)
+	}
+
+	t.Setenv("GO_EXPECT_ENV", "prod")
+	suite, err = LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir error: %v", err)
+	}
+	if got := suite.connections["api"].(*HTTPConnection).URL; got != "http://localhost:2" {
+		t.Errorf("expected GO_EXPECT_ENV to select prod, got %q", got)
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "expect.yaml"), WithEnvironment("qa")); err == nil ||
+		err.Error() != `go-expect: unknown environment "qa"; files declare prod, staging` {
+		t.Errorf("expected an unknown environment error, got %v", err)
+	}
+	missing := WithEnvFile(filepath.Join(dir, "nope.env"))
+	if _, err := LoadFile(filepath.Join(dir, "expect.yaml"), missing); err == nil {
+		t.Error("expected a missing env file error")
+	}
+}
+
+func TestLoadFS_environment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml": {Data: []byte(envTestSuite)},
+	}
+	suite, err := LoadFS(fsys, WithEnvironment(""))
+	if err != nil {
+		t.Fatalf("LoadFS error: %v", err)
+	}
+	if got := suite.connections["api"].(*HTTPConnection).URL; got != "http://localhost:1" {
+		t.Errorf("expected the file's URL without an environment, got %q", got)
+	}
+	if _, err := LoadFS(fstest.MapFS{".env": {Data: []byte("broken")}}); err == nil ||
+		!strings.Contains(err.Error(), `parse env file ".env": line 1`) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
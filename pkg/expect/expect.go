@@ -2,10 +2,14 @@ package expect
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/tidwall/gjson"
 )
@@ -21,42 +25,136 @@ func (e ExpectBody) structured() (map[string]any, bool) {
 	return result, true
 }
 
+// expected decodes the expected body, turning operator objects such as
+// {"$strict": ...} into matchers. ok is false when the body is not a JSON object
+// and must be compared as raw bytes.
+func (e ExpectBody) expected() (expected any, ok bool, err error) {
+	structured, ok := e.structured()
+	if !ok {
+		return nil, false, nil
+	}
+	expected, err = decodeOperators(structured)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid expected body: %w", err)
+	}
+	return expected, true, nil
+}
+
 // Validate checks that actual matches the expected body (partial JSON match or exact bytes).
+// Mismatches are reported as a *MismatchError.
 func (e ExpectBody) Validate(actual []byte) error {
-	if structuredExpected, ok := e.structured(); ok {
-		if structuredActual, ok := ExpectBody(actual).structured(); ok {
-			return partialMatch(structuredActual, structuredExpected)
+	return e.validate(actual, nil)
+}
+
+// validate is Validate with the scenario's variables available to matchers.
+func (e ExpectBody) validate(actual []byte, vars VarStore) error {
+	expected, ok, err := e.expected()
+	if err != nil {
+		return err
+	}
+	if ok {
+		var structuredActual any
+		if json.Unmarshal(actual, &structuredActual) == nil {
+			return matchBodies(structuredActual, expected, vars)
 		}
 	}
 
 	if string(actual) != string(e) {
-		return fmt.Errorf("unexpected body: %s", string(actual))
+		return (&MismatchError{
+			Path:     "$",
+			Reason:   "unexpected body: " + string(actual),
+			Expected: string(e),
+			Actual:   string(actual),
+		}).withBodies(string(e), string(actual))
 	}
 
 	return nil
 }
 
+// matchBodies is partialMatch over whole documents, attaching them to any
+// *MismatchError for diffing.
+func matchBodies(actual, expected any, vars VarStore) error {
+	err := matchPath("$", actual, expected, matchOpts{vars: vars})
+	var mismatch *MismatchError
+	if errors.As(err, &mismatch) {
+		// A matcher over the whole body, such as a JSON schema, has no document to diff.
+		switch doc := unwrapModes(expected); doc.(type) {
+		case map[string]any, []any:
+			mismatch.withBodies(doc, actual)
+		}
+	}
+	return err
+}
+
+// matchJSON decodes a JSON body and checks it against m.
+func matchJSON(data []byte, m Matcher, vars VarStore) error {
+	var actual any
+	if err := json.Unmarshal(data, &actual); err != nil {
+		return fmt.Errorf("expected JSON body: %w", err)
+	}
+	return matchBodies(actual, m, vars)
+}
+
 // partialMatch recursively checks that actual satisfies expected.
 // expected values may implement Matcher for custom assertions.
 func partialMatch(actual, expected any) error {
+	return matchPath("$", actual, expected, matchOpts{})
+}
+
+// matchOpts carries what matching a sub-tree depends on besides the values.
+type matchOpts struct {
+	mode matchMode // match modes in effect for the sub-tree
+	vars VarStore  // the scenario's variables; nil outside a step
+}
+
+// optsMatcher is implemented by the package's matchers that need matchOpts,
+// either for themselves or to pass on to the values they wrap.
+type optsMatcher interface {
+	matchWith(path string, actual any, o matchOpts) error
+}
+
+// matchPath checks actual against expected at path.
+func matchPath(path string, actual, expected any, o matchOpts) error {
+	if m, ok := expected.(optsMatcher); ok {
+		return m.matchWith(path, actual, o)
+	}
+
 	// If expected is a Matcher, delegate to it.
 	if m, ok := expected.(Matcher); ok {
-		return m.Match(actual)
+		if err := m.Match(actual); err != nil {
+			return mismatchAt(path, err, expected, actual)
+		}
+		return nil
 	}
 
 	switch exp := expected.(type) {
 	case map[string]any:
 		actMap, ok := actual.(map[string]any)
 		if !ok {
-			return fmt.Errorf("expected object, got %T", actual)
+			return &MismatchError{
+				Path:     path,
+				Reason:   "expected object, got " + jsonType(actual),
+				Expected: expected,
+				Actual:   actual,
+			}
 		}
-		for key, expVal := range exp {
+		for _, key := range slices.Sorted(maps.Keys(exp)) {
 			actVal, exists := actMap[key]
 			if !exists {
-				return fmt.Errorf("missing field %q", key)
+				if err := matchAbsent(exp[key]); err != nil {
+					return &MismatchError{Path: keyPath(path, key), Reason: err.Error(), Expected: exp[key]}
+				}
+				continue
 			}
-			if err := partialMatch(actVal, expVal); err != nil {
-				return fmt.Errorf("field %q: %w", key, err)
+			if err := matchPath(keyPath(path, key), actVal, exp[key], o); err != nil {
+				return err
+			}
+		}
+		if o.mode&modeStrict != 0 {
+			for _, key := range slices.Sorted(maps.Keys(actMap)) {
+				if _, ok := exp[key]; !ok {
+					return &MismatchError{Path: keyPath(path, key), Reason: "unexpected field", Actual: actMap[key]}
+				}
 			}
 		}
 		return nil
@@ -64,31 +162,146 @@ func partialMatch(actual, expected any) error {
 	case []any:
 		actSlice, ok := actual.([]any)
 		if !ok {
-			return fmt.Errorf("expected array, got %T", actual)
+			return &MismatchError{
+				Path:     path,
+				Reason:   "expected array, got " + jsonType(actual),
+				Expected: expected,
+				Actual:   actual,
+			}
 		}
-		if len(exp) == 0 {
-			return nil
+		return matchArray(path, actSlice, exp, o)
+
+	default:
+		if !reflect.DeepEqual(actual, expected) {
+			return &MismatchError{
+				Path:     path,
+				Reason:   fmt.Sprintf("expected %s, got %s", formatValue(expected), formatValue(actual)),
+				Expected: expected,
+				Actual:   actual,
+			}
 		}
-		for i, expElem := range exp {
-			found := false
-			for _, actElem := range actSlice {
-				if partialMatch(actElem, expElem) == nil {
-					found = true
-					break
-				}
+		return nil
+	}
+}
+
+// matchArray checks every expected element against actual. By default each
+// expected element may match any actual element; modeOrdered compares position
+// by position and modeExactLength requires equal lengths and distinct matches.
+func matchArray(path string, actual, expected []any, o matchOpts) error {
+	if o.mode&modeExactLength != 0 && len(actual) != len(expected) {
+		return &MismatchError{
+			Path:     path,
+			Reason:   fmt.Sprintf("expected %d elements, got %d", len(expected), len(actual)),
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+
+	if o.mode&modeOrdered != 0 {
+		for i, expElem := range expected {
+			if i >= len(actual) {
+				return &MismatchError{Path: indexPath(path, i), Reason: "missing element", Expected: expElem}
 			}
-			if !found {
-				return fmt.Errorf("array element [%d] not found in actual", i)
+			if err := matchPath(indexPath(path, i), actual[i], expElem, o); err != nil {
+				return err
 			}
 		}
 		return nil
+	}
 
-	default:
-		if !reflect.DeepEqual(actual, expected) {
-			return fmt.Errorf("expected %v, got %v", expected, actual)
+	if o.mode&modeExactLength != 0 {
+		i := matchDistinct(len(expected), len(actual), func(i, j int) bool {
+			return matchPath("$", actual[j], expected[i], o) == nil
+		})
+		if i >= 0 {
+			return elementNotFound(indexPath(path, i), path, actual, expected[i], o)
 		}
 		return nil
 	}
+	for i, expElem := range expected {
+		if !slices.ContainsFunc(actual, func(actElem any) bool { return matchPath("$", actElem, expElem, o) == nil }) {
+			return elementNotFound(indexPath(path, i), path, actual, expElem, o)
+		}
+	}
+	return nil
+}
+
+// matchDistinct pairs each of n expected elements with a distinct one of m
+// actual elements, where match reports whether they match. Earlier pairings
+// are revisited along augmenting paths, so an element matching broadly does
+// not take the only match of a later one. It returns the index of the first
+// expected element left without a match, or -1 when all have one.
+func matchDistinct(n, m int, match func(i, j int) bool) int {
+	known := make([][]int8, n) // 0 unknown, 1 match, -1 no match
+	for i := range known {
+		known[i] = make([]int8, m)
+	}
+	matches := func(i, j int) bool {
+		if known[i][j] == 0 {
+			known[i][j] = -1
+			if match(i, j) {
+				known[i][j] = 1
+			}
+		}
+		return known[i][j] == 1
+	}
+	owner := make([]int, m) // expected element paired with each actual one
+	for j := range owner {
+		owner[j] = -1
+	}
+	var augment func(i int, seen []bool) bool
+	augment = func(i int, seen []bool) bool {
+		for j := range m {
+			if seen[j] || !matches(i, j) {
+				continue
+			}
+			seen[j] = true
+			if owner[j] < 0 || augment(owner[j], seen) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n {
+		if !augment(i, make([]bool, m)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mismatchAt wraps a matcher failure at path, keeping the deepest path when
+// the matcher itself reported a *MismatchError.
+func mismatchAt(path string, err error, expected, actual any) error {
+	var inner *MismatchError
+	if errors.As(err, &inner) {
+		if inner.Path != "$" {
+			inner.Path = path + strings.TrimPrefix(inner.Path, "$")
+		} else {
+			inner.Path = path
+		}
+		return inner
+	}
+	return &MismatchError{Path: path, Reason: err.Error(), Expected: expected, Actual: actual}
+}
+
+// elementNotFound reports an expected array element with no match in actual,
+// describing how the closest candidate element differs.
+func elementNotFound(path, arrayPath string, actual []any, expected any, o matchOpts) error {
+	mismatch := &MismatchError{Path: path, Reason: "element not found in actual array", Expected: expected}
+	i := closestElement(actual, expected, o)
+	if i < 0 {
+		mismatch.Reason = "element not found in empty actual array"
+		return mismatch
+	}
+	mismatch.Candidate = indexPath(arrayPath, i)
+	mismatch.Actual = actual[i]
+	var inner *MismatchError
+	if errors.As(matchPath(mismatch.Candidate, actual[i], expected, o), &inner) {
+		mismatch.Reason += fmt.Sprintf("; closest is %s (%s: %s)", mismatch.Candidate, inner.Path, inner.Reason)
+	}
+	return mismatch
 }
 
 // SaveEntry defines a field to extract from the response body into a variable.
@@ -96,6 +309,14 @@ func partialMatch(actual, expected any) error {
 type SaveEntry struct {
 	Field string
 	As    string
+	// Secret marks the variable as secret, masking its value in logs and
+	// reports.
+	Secret bool
+}
+
+// saver is implemented by the built-in expectations, which save variables.
+type saver interface {
+	saves() []SaveEntry
 }
 
 // saveFromJSON extracts fields from JSON bytes into vars using gjson paths.
@@ -112,10 +333,24 @@ type HTTPExpect struct {
 	Status    int
 	StatusAny AnyOf // if set, status must be one of these codes
 	Body      ExpectBody
+	Schema    Matcher // if set, matched against the whole decoded body, e.g. JSONSchema
 	Header    map[string]string
+	Assert    []string // expressions over body, status and headers that must hold
 	Save      []SaveEntry
 }
 
+// Check implements Expectation; resp must be an *http.Response.
+func (e *HTTPExpect) Check(resp any, err error, vars VarStore) error {
+	if err != nil {
+		return err
+	}
+	httpResp, ok := resp.(*http.Response)
+	if !ok {
+		return mismatchedResponse("HTTP", resp)
+	}
+	return e.Validate(httpResp, vars)
+}
+
 // Validate checks the response against expectations, saving extracted values into vars.
 func (e *HTTPExpect) Validate(resp *http.Response, vars VarStore) error {
 	if len(e.StatusAny) > 0 {
@@ -133,7 +368,7 @@ func (e *HTTPExpect) Validate(resp *http.Response, vars VarStore) error {
 	}
 
 	var bodyBytes []byte
-	if e.Body != nil || len(e.Save) > 0 {
+	if e.Body != nil || e.Schema != nil || len(e.Assert) > 0 || len(e.Save) > 0 {
 		var err error
 		bodyBytes, err = io.ReadAll(resp.Body)
 		if err != nil {
@@ -141,15 +376,42 @@ func (e *HTTPExpect) Validate(resp *http.Response, vars VarStore) error {
 		}
 	}
 
+	if err := e.validateBody(resp, bodyBytes, vars); err != nil {
+		return err
+	}
+
+	if len(e.Save) > 0 && vars != nil {
+		saveFromJSON(bodyBytes, e.Save, vars)
+	}
+
+	return nil
+}
+
+// saves returns the variables the expectation saves.
+func (e *HTTPExpect) saves() []SaveEntry { return e.Save }
+
+// validateBody checks the body against the expected body and schema, and the
+// response against the assertions.
+func (e *HTTPExpect) validateBody(resp *http.Response, body []byte, vars VarStore) error {
 	if e.Body != nil {
-		if err := e.Body.Validate(bodyBytes); err != nil {
+		if err := e.Body.validate(body, vars); err != nil {
 			return err
 		}
 	}
 
-	if len(e.Save) > 0 && vars != nil {
-		saveFromJSON(bodyBytes, e.Save, vars)
+	if e.Schema != nil {
+		if err := matchJSON(body, e.Schema, vars); err != nil {
+			return err
+		}
 	}
 
+	if len(e.Assert) > 0 {
+		headers := make(map[string]any, len(resp.Header))
+		for k := range resp.Header {
+			headers[k] = resp.Header.Get(k)
+		}
+		bindings := map[string]any{"body": exprJSON(body), "status": resp.StatusCode, "headers": headers}
+		return checkAssertions(e.Assert, vars, bindings)
+	}
 	return nil
 }
@@ -0,0 +1,482 @@
+package expect
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Expressions are a small, side-effect free language evaluated against a
+// scenario's variables, inside {placeholders} and in assertions:
+//
+//	count + 1                      arithmetic: + - * / %, and + to join strings
+//	user.address.city, items[0]    paths into saved objects and arrays
+//	upper(name), name | upper      function calls, and pipes into them
+//	len(items) > 0 && ok           comparisons and logic: == != < <= > >= && || !
+//	body.items | sum(price)        per-element arguments of sum, map, filter, ...
+//	n > 1 ? "many" : "one"         conditionals
+//
+// Functions are the fixed set in exprFuncs; expressions cannot reach anything
+// but their variables.
+
+// exprNode is a parsed expression.
+type exprNode interface {
+	eval(s *exprScope) (any, error)
+}
+
+type (
+	exprLit    struct{ v any }
+	exprIdent  struct{ name string }
+	exprMember struct {
+		x    exprNode
+		name string
+	}
+	exprIndex struct{ x, index exprNode }
+	exprList  struct{ items []exprNode }
+	exprUnary struct {
+		op string
+		x  exprNode
+	}
+	exprBinary struct {
+		op   string
+		l, r exprNode
+	}
+	exprCond struct{ cond, then, els exprNode }
+	exprCall struct {
+		name string
+		args []exprNode
+	}
+)
+
+// compiledExpr is a parsed expression, or why it failed to parse.
+type compiledExpr struct {
+	node exprNode
+	refs bool // refers to a variable or function, rather than only literals
+	err  error
+}
+
+//nolint:gochecknoglobals // parsed expressions, shared by every scenario
+var exprCache sync.Map // source -> compiledExpr
+
+// compileExpr parses src. Expressions are cached; other text in braces, such
+// as a JSON object, is not, so it cannot grow the cache.
+func compileExpr(src string) compiledExpr {
+	if c, ok := exprCache.Load(src); ok {
+		return c.(compiledExpr)
+	}
+	p := &exprParser{src: src}
+	c := compiledExpr{}
+	if err := p.lex(); err != nil {
+		c.err = err
+	} else if c.node, c.err = p.parse(); c.err == nil {
+		c.refs = p.refs
+		exprCache.Store(src, c)
+	}
+	return c
+}
+
+// evalExpr evaluates src against vars and, when set, bindings, which take
+// precedence over variables of the same name.
+func evalExpr(src string, vars VarStore, bindings map[string]any) (any, error) {
+	c := compileExpr(src)
+	if c.err != nil {
+		return nil, c.err
+	}
+	scope := &exprScope{vars: vars}
+	if bindings != nil {
+		scope = &exprScope{vars: bindings, parent: scope}
+	}
+	return c.node.eval(scope)
+}
+
+// exprScope resolves identifiers: to fields of the element a per-element
+// argument is evaluated for, then to variables, then to outer scopes.
+type exprScope struct {
+	vars   map[string]any
+	it     any
+	hasIt  bool
+	parent *exprScope
+}
+
+func (s *exprScope) lookup(name string) (any, bool) {
+	for ; s != nil; s = s.parent {
+		if s.hasIt {
+			if name == "it" {
+				return s.it, true
+			}
+			if m, ok := s.it.(map[string]any); ok {
+				if v, ok := m[name]; ok {
+					return v, true
+				}
+			}
+		}
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// element returns a scope for evaluating an argument against one element.
+func (s *exprScope) element(it any) *exprScope {
+	return &exprScope{it: it, hasIt: true, parent: s}
+}
+
+// --- lexing ---
+
+type exprToken struct {
+	kind byte // 'n' number, 's' string, 'i' identifier, 'o' operator
+	text string
+	val  any
+	pos  int
+}
+
+type exprParser struct {
+	src  string
+	toks []exprToken
+	pos  int
+	refs bool
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var exprOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", "[", "]", ",", ".", "|", "?", ":",
+}
+
+func (p *exprParser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isExprDigit(c):
+			j := i + 1
+			for j < len(src) && (isExprDigit(src[j]) || src[j] == '.' && j+1 < len(src) && isExprDigit(src[j+1])) {
+				j++
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", src[i:j])
+			}
+			p.toks = append(p.toks, exprToken{kind: 'n', text: src[i:j], val: f, pos: i})
+			i = j
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return err
+			}
+			p.toks = append(p.toks, exprToken{kind: 's', text: src[i : i+n], val: s, pos: i})
+			i += n
+		case isExprIdent(c):
+			j := i + 1
+			for j < len(src) && (isExprIdent(src[j]) || isExprDigit(src[j])) {
+				j++
+			}
+			p.toks = append(p.toks, exprToken{kind: 'i', text: src[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			p.toks = append(p.toks, exprToken{kind: 'o', text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+func isExprDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isExprIdent(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexString reads a quoted string literal at the start of s, returning its
+// value and length.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// --- parsing ---
+
+func (p *exprParser) peek() exprToken {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return exprToken{pos: len(p.src)}
+}
+
+// accept consumes the next token if it is the operator or keyword op.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != 'o' && t.kind != 'i' {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *exprParser) unexpected() error {
+	t := p.peek()
+	if t.kind == 0 {
+		return errors.New("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	if len(p.toks) == 0 {
+		return nil, errors.New("empty expression")
+	}
+	n, err := p.cond()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+// cond parses a conditional, or any expression without one.
+func (p *exprParser) cond() (exprNode, error) {
+	c, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return c, nil
+	}
+	then, err := p.cond()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.cond()
+	if err != nil {
+		return nil, err
+	}
+	return exprCond{c, then, els}, nil
+}
+
+// exprPrecedence lists binary operators from the loosest binding. Pipes bind
+// tighter than comparisons, so a == b | sum() compares a with the sum.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var exprPrecedence = [][]string{
+	{"||", "or"},
+	{"&&", "and"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"|"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) binary(level int) (exprNode, error) {
+	if level == len(exprPrecedence) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(exprPrecedence[level]...)
+		if !ok {
+			return l, nil
+		}
+		if op == "|" {
+			if l, err = p.pipe(l); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		r, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = exprBinary{strings.NewReplacer("or", "||", "and", "&&").Replace(op), l, r}
+		if level == 2 { // comparisons do not chain
+			return l, nil
+		}
+	}
+}
+
+// pipe parses the function x is piped into, as f or f(args).
+func (p *exprParser) pipe(x exprNode) (exprNode, error) {
+	t := p.peek()
+	if t.kind != 'i' {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	p.refs = true
+	call := exprCall{name: t.text, args: []exprNode{x}}
+	if _, ok := p.accept("("); ok {
+		args, err := p.args(")")
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, args...)
+	}
+	return call, nil
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if op, ok := p.accept("!", "-", "not"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "not" {
+			op = "!"
+		}
+		return exprUnary{op, x}, nil
+	}
+	return p.postfix()
+}
+
+func (p *exprParser) postfix() (exprNode, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.acceptOp("."):
+			t := p.peek()
+			if t.kind != 'i' && t.kind != 'n' {
+				return nil, p.unexpected()
+			}
+			p.pos++
+			if t.kind == 'n' {
+				x = exprIndex{x, exprLit{t.val}}
+			} else {
+				x = exprMember{x, t.text}
+			}
+		case p.acceptOp("["):
+			index, err := p.cond()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = exprIndex{x, index}
+		default:
+			return x, nil
+		}
+	}
+}
+
+// acceptOp consumes the next token if it is the operator op.
+func (p *exprParser) acceptOp(op string) bool {
+	if t := p.peek(); t.kind == 'o' && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	t := p.peek()
+	switch t.kind {
+	case 'n', 's':
+		p.pos++
+		return exprLit{t.val}, nil
+	case 'i':
+		p.pos++
+		switch t.text {
+		case "true", "false":
+			return exprLit{t.text == "true"}, nil
+		case "null", "nil":
+			return exprLit{nil}, nil
+		}
+		p.refs = true
+		if p.acceptOp("(") {
+			args, err := p.args(")")
+			if err != nil {
+				return nil, err
+			}
+			return exprCall{t.text, args}, nil
+		}
+		return exprIdent{t.text}, nil
+	}
+	switch {
+	case p.acceptOp("("):
+		x, err := p.cond()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case p.acceptOp("["):
+		items, err := p.args("]")
+		return exprList{items}, err
+	}
+	return nil, p.unexpected()
+}
+
+// args parses comma-separated expressions up to the closing operator.
+func (p *exprParser) args(closing string) ([]exprNode, error) {
+	var args []exprNode
+	if p.acceptOp(closing) {
+		return args, nil
+	}
+	for {
+		a, err := p.cond()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.acceptOp(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,381 @@
+//nolint:nilnil // null is a value like any other in expressions
+package expect
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+func (n exprLit) eval(*exprScope) (any, error) { return n.v, nil }
+
+func (n exprIdent) eval(s *exprScope) (any, error) {
+	if v, ok := s.lookup(n.name); ok {
+		return v, nil
+	}
+	if n.name == "now" {
+		return time.Now().UTC(), nil
+	}
+	return nil, fmt.Errorf("unknown variable %q", n.name)
+}
+
+func (n exprMember) eval(s *exprScope) (any, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	return exprField(x, n.name)
+}
+
+func (n exprIndex) eval(s *exprScope) (any, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := index.(string); ok {
+		return exprField(x, key)
+	}
+	i, ok := toFloat(index)
+	if !ok {
+		return nil, fmt.Errorf("invalid index %s", formatValue(index))
+	}
+	list, ok := exprAsList(x)
+	if !ok {
+		if x == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot index %s", exprType(x))
+	}
+	if i < 0 {
+		i += float64(len(list))
+	}
+	if i < 0 || int(i) >= len(list) {
+		return nil, nil
+	}
+	return list[int(i)], nil
+}
+
+func (n exprList) eval(s *exprScope) (any, error) {
+	out := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (n exprUnary) eval(s *exprScope) (any, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !exprTruthy(x), nil
+	}
+	f, ok := toFloat(x)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", exprType(x))
+	}
+	return -f, nil
+}
+
+func (n exprCond) eval(s *exprScope) (any, error) {
+	c, err := n.cond.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	if exprTruthy(c) {
+		return n.then.eval(s)
+	}
+	return n.els.eval(s)
+}
+
+func (n exprBinary) eval(s *exprScope) (any, error) {
+	l, err := n.l.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&":
+		if !exprTruthy(l) {
+			return false, nil
+		}
+	case "||":
+		if exprTruthy(l) {
+			return true, nil
+		}
+	}
+	r, err := n.r.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&", "||":
+		return exprTruthy(r), nil
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		return exprCompareOp(n.op, l, r)
+	}
+	return exprArith(n.op, l, r)
+}
+
+func (n exprCall) eval(s *exprScope) (any, error) {
+	f, ok := exprFuncs[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", n.name)
+	}
+	if f.each != nil {
+		return exprEach(s, n, f.each)
+	}
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	if len(args) < f.minArgs || f.maxArgs >= 0 && len(args) > f.maxArgs {
+		return nil, fmt.Errorf("%s: wrong number of arguments: %d", n.name, len(args))
+	}
+	v, err := f.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// exprEach evaluates a call to a per-element function: its first argument is
+// the list and the optional second is evaluated for each element.
+func exprEach(s *exprScope, n exprCall, fn func(list []any, each func(any) (any, error)) (any, error)) (any, error) {
+	if len(n.args) == 0 || len(n.args) > 2 {
+		return nil, fmt.Errorf("%s: wrong number of arguments: %d", n.name, len(n.args))
+	}
+	x, err := n.args[0].eval(s)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := exprAsList(x)
+	if !ok && x != nil {
+		return nil, fmt.Errorf("%s: expected array, got %s", n.name, exprType(x))
+	}
+	each := func(it any) (any, error) { return it, nil }
+	if len(n.args) == 2 {
+		each = func(it any) (any, error) { return n.args[1].eval(s.element(it)) }
+	}
+	v, err := fn(list, each)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// exprField returns the key of an object, or nil when x is null or lacks it.
+func exprField(x any, key string) (any, error) {
+	switch m := x.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return m[key], nil
+	case VarStore:
+		return m[key], nil
+	}
+	if rv := reflect.ValueOf(x); rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		if v := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); v.IsValid() {
+			return v.Interface(), nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cannot access %q of %s", key, exprType(x))
+}
+
+// exprAsList returns x as a list when it is a slice or array, other than bytes.
+func exprAsList(x any) ([]any, bool) {
+	if list, ok := x.([]any); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(x)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// exprTruthy reports whether v counts as true: false, null, zero, "" and
+// empty arrays and objects do not.
+func exprTruthy(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case string:
+		return x != ""
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	if list, ok := exprAsList(v); ok {
+		return len(list) > 0
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+		return rv.Len() > 0
+	}
+	return true
+}
+
+// exprEqual compares numbers by value, whatever their Go type, and everything
+// else deeply.
+func exprEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// exprCompare orders two numbers, strings or times.
+func exprCompare(a, b any) (int, error) {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return cmp.Compare(fa, fb), nil
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb), nil
+		}
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", exprType(a), exprType(b))
+}
+
+func exprCompareOp(op string, a, b any) (bool, error) {
+	c, err := exprCompare(a, b)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// exprArith applies an arithmetic operator. + also joins strings.
+func exprArith(op string, l, r any) (any, error) {
+	_, ls := l.(string)
+	_, rs := r.(string)
+	if op == "+" && (ls || rs) {
+		return varString(l) + varString(r), nil
+	}
+	a, aok := toFloat(l)
+	b, bok := toFloat(r)
+	if !aok || !bok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, exprType(l), exprType(r))
+	}
+	switch op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	}
+	if b == 0 {
+		return nil, errors.New("division by zero")
+	}
+	if op == "%" {
+		return math.Mod(a, b), nil
+	}
+	return a / b, nil
+}
+
+// exprType names the type of a value in error messages.
+func exprType(v any) string {
+	switch v.(type) {
+	case time.Time:
+		return "time"
+	case VarStore:
+		return "object"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	if _, ok := exprAsList(v); ok {
+		return "array"
+	}
+	return jsonType(v)
+}
+
+// exprJSON decodes a response body for expressions: JSON as its value, and
+// anything else as a string.
+func exprJSON(data []byte) any {
+	var v any
+	if json.Unmarshal(data, &v) != nil {
+		return string(data)
+	}
+	return v
+}
+
+// checkAssertions evaluates each assertion against vars and bindings, the
+// values of the response, failing on the first that is not true. A failed
+// comparison reports the values of both sides.
+func checkAssertions(asserts []string, vars VarStore, bindings map[string]any) error {
+	for _, src := range asserts {
+		v, err := evalExpr(src, vars, bindings)
+		if err != nil {
+			return fmt.Errorf("assertion %s: %w", src, err)
+		}
+		if exprTruthy(v) {
+			continue
+		}
+		if b, ok := compileExpr(src).node.(exprBinary); ok && exprIsComparison(b.op) {
+			scope := &exprScope{vars: bindings, parent: &exprScope{vars: vars}}
+			l, _ := b.l.eval(scope)
+			r, _ := b.r.eval(scope)
+			return fmt.Errorf("assertion failed: %s (%s %s %s)", src, formatValue(l), b.op, formatValue(r))
+		}
+		return fmt.Errorf("assertion failed: %s", src)
+	}
+	return nil
+}
+
+func exprIsComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,398 @@
+//nolint:nilnil // null is a value like any other in expressions
+package expect
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// exprFunc is a function callable from expressions. fn takes its evaluated
+// arguments, between minArgs and maxArgs of them (-1 for any number). each
+// functions instead take a list and an optional argument evaluated for every
+// element, with the element's fields in scope, as in sum(items, price).
+type exprFunc struct {
+	minArgs, maxArgs int
+	fn               func(args []any) (any, error)
+	each             func(list []any, each func(any) (any, error)) (any, error)
+}
+
+//nolint:gochecknoglobals // read-only function table
+var exprFuncs = map[string]exprFunc{
+	"len":        {1, 1, exprLen, nil},
+	"upper":      {1, 1, exprStringFunc(strings.ToUpper), nil},
+	"lower":      {1, 1, exprStringFunc(strings.ToLower), nil},
+	"trim":       {1, 1, exprStringFunc(strings.TrimSpace), nil},
+	"string":     {1, 1, exprStringFunc(func(s string) string { return s }), nil},
+	"contains":   {2, 2, exprContains, nil},
+	"startsWith": {2, 2, exprStrings2(strings.HasPrefix), nil},
+	"endsWith":   {2, 2, exprStrings2(strings.HasSuffix), nil},
+	"replace":    {3, 3, exprReplace, nil},
+	"split":      {2, 2, exprSplit, nil},
+	"join":       {1, 2, exprJoin, nil},
+	"default":    {2, 2, exprDefault, nil},
+	"number":     {1, 1, exprNumber, nil},
+	"int":        {1, 1, exprMath(math.Trunc), nil},
+	"abs":        {1, 1, exprMath(math.Abs), nil},
+	"floor":      {1, 1, exprMath(math.Floor), nil},
+	"ceil":       {1, 1, exprMath(math.Ceil), nil},
+	"round":      {1, 2, exprRound, nil},
+	"min":        {1, -1, exprMinMax(-1), nil},
+	"max":        {1, -1, exprMinMax(1), nil},
+	"first":      {1, 1, exprAt(0), nil},
+	"last":       {1, 1, exprAt(-1), nil},
+	"keys":       {1, 1, exprKeys, nil},
+	"values":     {1, 1, exprValues, nil},
+	"now":        {0, 0, func([]any) (any, error) { return time.Now().UTC(), nil }, nil},
+	"time":       {1, 1, exprTimeFunc(func(t time.Time) any { return t }), nil},
+	"unix":       {1, 1, exprTimeFunc(func(t time.Time) any { return t.Unix() }), nil},
+	"unixMilli":  {1, 1, exprTimeFunc(func(t time.Time) any { return t.UnixMilli() }), nil},
+	"rfc3339":    {1, 1, exprTimeFunc(func(t time.Time) any { return t.Format(time.RFC3339) }), nil},
+	"format":     {2, 2, exprFormat, nil},
+	"sum":        {each: exprSum},
+	"avg":        {each: exprAvg},
+	"count":      {each: exprCount},
+	"map":        {each: exprMap},
+	"filter":     {each: exprFilter},
+	"any":        {each: exprAny},
+	"all":        {each: exprAll},
+}
+
+func exprLen(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	}
+	if list, ok := exprAsList(args[0]); ok {
+		return len(list), nil
+	}
+	if m, ok := args[0].(map[string]any); ok {
+		return len(m), nil
+	}
+	return nil, fmt.Errorf("expected string, array or object, got %s", exprType(args[0]))
+}
+
+func exprStringFunc(f func(string) string) func([]any) (any, error) {
+	return func(args []any) (any, error) { return f(varString(args[0])), nil }
+}
+
+func exprStrings2(f func(s, t string) bool) func([]any) (any, error) {
+	return func(args []any) (any, error) { return f(varString(args[0]), varString(args[1])), nil }
+}
+
+// exprContains reports whether a string contains a substring, an array an
+// element or an object a key.
+func exprContains(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		return strings.Contains(v, varString(args[1])), nil
+	case map[string]any:
+		_, ok := v[varString(args[1])]
+		return ok, nil
+	}
+	list, ok := exprAsList(args[0])
+	if !ok {
+		return nil, fmt.Errorf("expected string, array or object, got %s", exprType(args[0]))
+	}
+	return slices.ContainsFunc(list, func(e any) bool { return exprEqual(e, args[1]) }), nil
+}
+
+func exprReplace(args []any) (any, error) {
+	return strings.ReplaceAll(varString(args[0]), varString(args[1]), varString(args[2])), nil
+}
+
+func exprSplit(args []any) (any, error) {
+	parts := strings.Split(varString(args[0]), varString(args[1]))
+	out := make([]any, len(parts))
+	for i, p := range parts {
+		out[i] = p
+	}
+	return out, nil
+}
+
+func exprJoin(args []any) (any, error) {
+	list, ok := exprAsList(args[0])
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %s", exprType(args[0]))
+	}
+	sep := ","
+	if len(args) == 2 {
+		sep = varString(args[1])
+	}
+	parts := make([]string, len(list))
+	for i, e := range list {
+		parts[i] = varString(e)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// exprDefault returns its second argument when the first is null or "".
+func exprDefault(args []any) (any, error) {
+	if args[0] == nil || args[0] == "" {
+		return args[1], nil
+	}
+	return args[0], nil
+}
+
+// exprToNumber converts numbers, and strings holding them, to float64.
+func exprToNumber(v any) (float64, error) {
+	if f, ok := toFloat(v); ok {
+		return f, nil
+	}
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("not a number: %q", s)
+	}
+	return 0, fmt.Errorf("expected number, got %s", exprType(v))
+}
+
+func exprNumber(args []any) (any, error) {
+	return exprToNumber(args[0])
+}
+
+func exprMath(f func(float64) float64) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		n, err := exprToNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(n), nil
+	}
+}
+
+// exprRound rounds to the nearest integer, or to a number of decimal places.
+func exprRound(args []any) (any, error) {
+	n, err := exprToNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return math.Round(n), nil
+	}
+	places, err := exprToNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	scale := math.Pow(10, places)
+	return math.Round(n*scale) / scale, nil
+}
+
+// exprMinMax returns the smallest (sign -1) or largest (sign 1) of its
+// arguments, or of the elements of a single array argument.
+func exprMinMax(sign int) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		if len(args) == 1 {
+			if list, ok := exprAsList(args[0]); ok {
+				args = list
+			}
+		}
+		if len(args) == 0 {
+			return nil, nil
+		}
+		best := args[0]
+		for _, v := range args[1:] {
+			c, err := exprCompare(v, best)
+			if err != nil {
+				return nil, err
+			}
+			if c*sign > 0 {
+				best = v
+			}
+		}
+		return best, nil
+	}
+}
+
+// exprAt returns the element at i, counting from the end when negative, or
+// null for an empty array.
+func exprAt(i int) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		list, ok := exprAsList(args[0])
+		if !ok {
+			if args[0] == nil {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("expected array, got %s", exprType(args[0]))
+		}
+		at := i
+		if at < 0 {
+			at += len(list)
+		}
+		if at < 0 || at >= len(list) {
+			return nil, nil
+		}
+		return list[at], nil
+	}
+}
+
+// exprKeys returns an object's keys, sorted.
+func exprKeys(args []any) (any, error) {
+	m, ok := args[0].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %s", exprType(args[0]))
+	}
+	var out []any
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// exprValues returns an object's values, in the order of their sorted keys.
+func exprValues(args []any) (any, error) {
+	m, ok := args[0].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected object, got %s", exprType(args[0]))
+	}
+	var out []any
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		out = append(out, m[k])
+	}
+	return out, nil
+}
+
+// exprToTime converts a time, an RFC 3339 string or Unix seconds to a time.
+func exprToTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("not an RFC 3339 time: %q", t)
+		}
+		return parsed, nil
+	}
+	if f, ok := toFloat(v); ok {
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("expected time, got %s", exprType(v))
+}
+
+func exprTimeFunc(f func(time.Time) any) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		t, err := exprToTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(t), nil
+	}
+}
+
+// exprFormat formats a time with a Go layout, such as "2006-01-02".
+func exprFormat(args []any) (any, error) {
+	t, err := exprToTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	layout, ok := args[1].(string)
+	if !ok {
+		return nil, errors.New("layout must be a string")
+	}
+	return t.Format(layout), nil
+}
+
+func exprSum(list []any, each func(any) (any, error)) (any, error) {
+	var total float64
+	for _, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		n, err := exprToNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func exprAvg(list []any, each func(any) (any, error)) (any, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	total, err := exprSum(list, each)
+	if err != nil {
+		return nil, err
+	}
+	return total.(float64) / float64(len(list)), nil
+}
+
+// exprCount counts the elements, or those for which the argument is true.
+func exprCount(list []any, each func(any) (any, error)) (any, error) {
+	n := 0
+	for _, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		if exprTruthy(v) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func exprMap(list []any, each func(any) (any, error)) (any, error) {
+	out := make([]any, len(list))
+	for i, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func exprFilter(list []any, each func(any) (any, error)) (any, error) {
+	out := []any{}
+	for _, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		if exprTruthy(v) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func exprAny(list []any, each func(any) (any, error)) (any, error) {
+	for _, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		if exprTruthy(v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func exprAll(list []any, each func(any) (any, error)) (any, error) {
+	for _, e := range list {
+		v, err := each(e)
+		if err != nil {
+			return nil, err
+		}
+		if !exprTruthy(v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
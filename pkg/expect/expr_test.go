@@ -0,0 +1,216 @@
+package expect
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalExpr(t *testing.T) {
+	vars := VarStore{
+		"count": float64(2),
+		"name":  "alice",
+		"user":  map[string]any{"address": map[string]any{"city": "Paris"}},
+		"items": []any{
+			map[string]any{"name": "a", "price": float64(3), "qty": float64(2)},
+			map[string]any{"name": "b", "price": float64(4.5), "qty": float64(1)},
+		},
+		"tags":   []any{"x", "y"},
+		"rows":   []map[string]any{{"id": int64(7)}},
+		"ts":     "2026-01-02T03:04:05Z",
+		"weird":  map[string]any{"content-type": "json"},
+		"absent": nil,
+	}
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{"count + 1", float64(3)},
+		{"count * 3 - 1", float64(5)},
+		{"(count + 1) * 2", float64(6)},
+		{"7 % 4", float64(3)},
+		{"-count", float64(-2)},
+		{`"n=" + count`, "n=2"},
+		{"upper(name)", "ALICE"},
+		{"name | upper", "ALICE"},
+		{"len(items)", 2},
+		{"len(name)", 5},
+		{"user.address.city", "Paris"},
+		{"user.missing.city", nil},
+		{`weird["content-type"]`, "json"},
+		{"items[1].name", "b"},
+		{"items.0.name", "a"},
+		{"items[-1].name", "b"},
+		{"tags[5]", nil},
+		{"rows[0].id", int64(7)},
+		{"items | sum(price)", 7.5},
+		{"items | sum(price * qty)", 10.5},
+		{"sum([1, 2, 3])", float64(6)},
+		{"items | avg(qty)", 1.5},
+		{"items | map(name) | join", "a,b"},
+		{`items | filter(price > 3) | map(name) | join("+")`, "b"},
+		{"items | count(qty > 1)", 1},
+		{"items | any(name == 'b')", true},
+		{"items | all(price > 3)", false},
+		{"items | map(it.name)", []any{"a", "b"}},
+		{"items | map(price + count)", []any{float64(5), 6.5}},
+		{"contains(tags, 'y') && !contains(name, 'z')", true},
+		{`startsWith(name, "al") and endsWith(name, "ce")`, true},
+		{`replace(name, "l", "L")`, "aLice"},
+		{`split("a,b", ",")`, []any{"a", "b"}},
+		{"count == 2", true},
+		{"rows[0].id == 7", true},
+		{"count != '2'", true},
+		{"name < 'bob'", true},
+		{"count >= 2 || missing", true},
+		{"absent == null", true},
+		{"count > 1 ? 'many' : 'one'", "many"},
+		{"default(absent, 'none')", "none"},
+		{"round(10 / 3, 2)", 3.33},
+		{"max(items | map(price))", 4.5},
+		{"min(3, count, 5)", float64(2)},
+		{"first(tags) + last(tags)", "xy"},
+		{"keys(user)", []any{"address"}},
+		{"number('12.5') + 1", 13.5},
+		{"unix(ts)", int64(1767323045)},
+		{"format(ts, '2006-01-02')", "2026-01-02"},
+		{"time(ts) < now", true},
+		{"unix(now) > 0", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := evalExpr(tt.src, vars, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvalExpr_errors(t *testing.T) {
+	vars := VarStore{"n": float64(1), "s": "x", "list": []any{float64(1)}}
+	tests := []struct {
+		src, want string
+	}{
+		{"", "empty expression"},
+		{"n +", "unexpected end of expression"},
+		{"n ) 1", `unexpected ")" at 2`},
+		{"'open", "unterminated string"},
+		{"n # 1", `unexpected '#' at 2`},
+		{"missing + 1", `unknown variable "missing"`},
+		{"exec('rm')", `unknown function "exec"`},
+		{"n / 0", "division by zero"},
+		{"s - 1", "cannot apply - to string and number"},
+		{"s.field", `cannot access "field" of string`},
+		{"n < s", "cannot compare number with string"},
+		{"upper(s, s)", "upper: wrong number of arguments: 2"},
+		{"sum(s)", "sum: expected array, got string"},
+		{"list | sum(missing)", `sum: unknown variable "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := evalExpr(tt.src, vars, nil)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEvalExpr_now(t *testing.T) {
+	got, err := evalExpr("now", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ts, ok := got.(time.Time); !ok || time.Since(ts) > time.Minute {
+		t.Errorf("expected the current time, got %v", got)
+	}
+	// A variable named now takes precedence.
+	if got, _ := evalExpr("now", VarStore{"now": "then"}, nil); got != "then" {
+		t.Errorf("expected the variable, got %v", got)
+	}
+}
+
+func TestHTTPExpect_Assert(t *testing.T) {
+	body := `{"total": 12, "items": [{"price": 5}, {"price": 7}]}`
+	tests := []struct {
+		name    string
+		assert  []string
+		wantErr string
+	}{
+		{"pass", []string{"body.total == body.items | sum(price)", "status == 200", `headers["X-Id"] == id`}, ""},
+		{"comparison", []string{"body.total == (body.items | sum(price)) + 1"}, "assertion failed: " +
+			"body.total == (body.items | sum(price)) + 1 (12 == 13)"},
+		{"not true", []string{"len(body.items) > 1", "body.missing"}, "assertion failed: body.missing"},
+		{"error", []string{"body.items.price"}, `assertion body.items.price: cannot access "price" of array`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Id": {"42"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}
+			err := (&HTTPExpect{Assert: tt.assert}).Validate(resp, VarStore{"id": "42"})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSQLAndGRPCExpect_Assert(t *testing.T) {
+	result := &SQLResult{Rows: []map[string]any{{"id": int64(1), "total": []byte("9.5")}}, RowsAffected: 0}
+	sql := &SQLExpect{Assert: []string{"len(rows) == 1", "rows[0].id == 1", "rows_affected == 0"}}
+	if err := sql.Validate(result, nil); err != nil {
+		t.Errorf("unexpected SQL error: %v", err)
+	}
+
+	grpcExp := &GRPCExpect{Assert: []string{`code == "OK"`, "messages | sum(n) == 3"}}
+	if err := grpcExp.ValidateStream([][]byte{[]byte(`{"n":1}`), []byte(`{"n":2}`)}, nil, nil); err != nil {
+		t.Errorf("unexpected gRPC error: %v", err)
+	}
+	unary := &GRPCExpect{Assert: []string{"body.n == 2"}}
+	if err := unary.Validate([]byte(`{"n":1}`), nil, nil); err == nil {
+		t.Error("expected the assertion to fail")
+	}
+}
+
+func TestBuildScenarios_assert(t *testing.T) {
+	load := func(assert string) (*Suite, error) {
+		return LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: assert
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          assert: ["` + assert + `"]
+`))
+	}
+	suite, err := load("body.total == body.items | sum(price)")
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	exp := suite.scenarios[0].steps[0].Expect.(*HTTPExpect)
+	if want := []string{"body.total == body.items | sum(price)"}; !reflect.DeepEqual(exp.Assert, want) {
+		t.Errorf("expected %v, got %v", want, exp.Assert)
+	}
+	if _, err := load("body.total =="); err == nil || !strings.Contains(err.Error(), "expect assert") {
+		t.Errorf("expected an invalid assertion error, got %v", err)
+	}
+}
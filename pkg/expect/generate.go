@@ -0,0 +1,288 @@
+package expect
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateOptions configures GenerateScenarios.
+type GenerateOptions struct {
+	// Connection names the generated connection; "" means "api".
+	Connection string
+	// URL is the connection URL; "" means the document's first absolute server
+	// URL, or http://localhost:8080.
+	URL string
+	// OpenAPI, if set, is written as the connection's openapi: path, so running
+	// the generated file also checks the contract.
+	OpenAPI string
+}
+
+// GenerateScenarios writes a YAML suite for spec with one connection and one
+// scenario per operation, as a starting point that LoadFile runs as is.
+// Parameters and request bodies are filled from the document's examples, or
+// from placeholder values of the right type, and each step expects the
+// operation's documented success status.
+func GenerateScenarios(spec *OpenAPI, opts GenerateOptions) ([]byte, error) {
+	conn := fileConnection{Name: opts.Connection, Type: "http", URL: opts.URL, OpenAPI: opts.OpenAPI}
+	if conn.Name == "" {
+		conn.Name = "api"
+	}
+	if conn.URL == "" {
+		conn.URL = spec.serverURL()
+	}
+
+	f := expectFile{Connections: []fileConnection{conn}}
+	for _, op := range spec.operations {
+		f.Scenarios = append(f.Scenarios, fileScenario{
+			Name:  op.name(),
+			Steps: []fileStep{spec.generateStep(op)},
+		})
+	}
+
+	var buf bytes.Buffer
+	if title, _ := spec.info()["title"].(string); title != "" {
+		fmt.Fprintf(&buf, "# Generated by go-expect from %s.\n", title)
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return nil, fmt.Errorf("go-expect: generate: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("go-expect: generate: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *OpenAPI) info() map[string]any {
+	info, _ := o.doc["info"].(map[string]any)
+	return info
+}
+
+// serverURL returns the first absolute server URL, or http://localhost:8080.
+func (o *OpenAPI) serverURL() string {
+	servers, _ := o.doc["servers"].([]any)
+	for _, s := range servers {
+		server, _ := s.(map[string]any)
+		if u, ok := server["url"].(string); ok && strings.Contains(u, "://") && !strings.Contains(u, "{") {
+			return strings.TrimRight(u, "/")
+		}
+	}
+	return "http://localhost:8080"
+}
+
+// name is the operation's operationId, or "METHOD path".
+func (op *openAPIOperation) name() string {
+	if id, ok := op.op["operationId"].(string); ok && id != "" {
+		return id
+	}
+	return op.method + " " + op.path
+}
+
+func (o *OpenAPI) generateStep(op *openAPIOperation) fileStep {
+	req := &fileRequest{Method: op.method, Endpoint: op.path}
+	for _, param := range op.parameters {
+		name, _ := param["name"].(string)
+		value := o.paramExample(param)
+		switch param["in"] {
+		case "path":
+			req.Endpoint = strings.ReplaceAll(req.Endpoint, "{"+name+"}", value)
+		case "query":
+			if param["required"] == true {
+				req.Query = setString(req.Query, name, value)
+			}
+		case "header":
+			if param["required"] == true {
+				req.Header = setString(req.Header, name, value)
+			}
+		}
+	}
+	// Path parameters the document does not declare.
+	req.Endpoint = pathParamRe.ReplaceAllString(req.Endpoint, "1")
+
+	if body := o.deref(op.op["requestBody"]); body != nil {
+		content, _ := body["content"].(map[string]any)
+		for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+			if !isJSONMediaType(mediaType) {
+				continue
+			}
+			req.Body = o.mediaExample(o.deref(content[mediaType]))
+			if mediaType != "application/json" {
+				req.Header = setString(req.Header, "Content-Type", mediaType)
+			}
+			break
+		}
+	}
+
+	return fileStep{Request: req, Expect: &fileExpectation{Status: successStatus(op)}}
+}
+
+func setString(m map[string]string, k, v string) map[string]string {
+	if m == nil {
+		m = make(map[string]string)
+	}
+	m[k] = v
+	return m
+}
+
+// successStatus returns the lowest 2xx status the operation documents; 200 for
+// a 2XX range, and 0, which expects no status, when none is documented.
+func successStatus(op *openAPIOperation) int {
+	responses, _ := op.op["responses"].(map[string]any)
+	best := 0
+	for code := range responses {
+		if strings.EqualFold(code, "2XX") && best == 0 {
+			best = 200
+		}
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 && (best == 0 || n < best) {
+			best = n
+		}
+	}
+	return best
+}
+
+// paramExample returns a value for a parameter from its example, or its schema.
+func (o *OpenAPI) paramExample(param map[string]any) string {
+	v, ok := param["example"]
+	if !ok {
+		v, ok = firstExample(o, param["examples"])
+	}
+	if !ok {
+		v = o.schemaExample(param["schema"], 0)
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return formatValue(v)
+}
+
+// mediaExample returns a body for a media type object from its example, or its schema.
+func (o *OpenAPI) mediaExample(media map[string]any) any {
+	if v, ok := media["example"]; ok {
+		return v
+	}
+	if v, ok := firstExample(o, media["examples"]); ok {
+		return v
+	}
+	return o.schemaExample(media["schema"], 0)
+}
+
+// firstExample returns the value of the first, by name, of an examples map.
+func firstExample(o *OpenAPI, examples any) (any, bool) {
+	named, _ := examples.(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(named)) {
+		if v, ok := o.deref(named[name])["value"]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// maxExampleDepth stops generating examples of recursive schemas.
+const maxExampleDepth = 8
+
+// schemaExample builds a value conforming to schema from its examples and
+// defaults, or placeholders of the right type and format.
+func (o *OpenAPI) schemaExample(schema any, depth int) any {
+	s := o.deref(schema)
+	if s == nil || depth > maxExampleDepth {
+		return nil
+	}
+	for _, k := range []string{"example", "const", "default"} {
+		if v, ok := s[k]; ok {
+			return v
+		}
+	}
+	for _, k := range []string{"examples", "enum", "oneOf", "anyOf"} {
+		list, _ := s[k].([]any)
+		if len(list) == 0 {
+			continue
+		}
+		if k == "oneOf" || k == "anyOf" {
+			return o.schemaExample(list[0], depth+1)
+		}
+		return list[0]
+	}
+	if all, ok := s["allOf"].([]any); ok {
+		merged := map[string]any{}
+		for _, sub := range all {
+			if obj, ok := o.schemaExample(sub, depth+1).(map[string]any); ok {
+				maps.Copy(merged, obj)
+			}
+		}
+		return merged
+	}
+
+	typ, _ := s["type"].(string)
+	if types, ok := s["type"].([]any); ok && len(types) > 0 {
+		typ, _ = types[0].(string)
+	}
+	if typ == "" && s["properties"] != nil {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		props, _ := s["properties"].(map[string]any)
+		obj := make(map[string]any, len(props))
+		for name, prop := range props {
+			if p := o.deref(prop); p["readOnly"] == true {
+				continue
+			}
+			obj[name] = o.schemaExample(prop, depth+1)
+		}
+		return obj
+	case "array":
+		if _, ok := s["items"]; !ok {
+			return []any{}
+		}
+		return []any{o.schemaExample(s["items"], depth+1)}
+	case "integer":
+		return minimumExample(s, 1)
+	case "number":
+		return minimumExample(s, 1.5)
+	case "boolean":
+		return true
+	case "string":
+		return stringExample(s)
+	}
+	return nil
+}
+
+// minimumExample returns the schema's minimum, when set, or fallback.
+func minimumExample(s map[string]any, fallback float64) any {
+	if f, ok := toFloat(s["minimum"]); ok {
+		return f
+	}
+	return fallback
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var formatExamples = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "00:00:00Z",
+	"email":     "user@example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"uri":       "https://example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+	"hostname":  "example.com",
+}
+
+func stringExample(s map[string]any) string {
+	format, _ := s["format"].(string)
+	if v, ok := formatExamples[format]; ok {
+		return v
+	}
+	v := "string"
+	if n, ok := toFloat(s["minLength"]); ok && int(n) > len(v) {
+		v += strings.Repeat("x", int(n)-len(v))
+	}
+	return v
+}
@@ -0,0 +1,119 @@
+package expect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateScenarios(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GenerateScenarios(spec, GenerateOptions{OpenAPI: "pets.yaml"})
+	if err != nil {
+		t.Fatalf("GenerateScenarios error: %v", err)
+	}
+	for _, want := range []string{
+		"# Generated by go-expect from pets.\n",
+		"url: https://pets.example.com/v1\n",
+		"endpoint: /pets/1\n",
+		"limit: \"1\"\n",
+		"X-Tenant: string\n",
+		"status: 201\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in:\n%s", want, data)
+		}
+	}
+
+	// The generated file runs as is, and its requests honour the contract.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pets.expect.yaml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pets.yaml"), []byte(petstoreSpec), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	suite, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	if got := len(suite.Scenarios()); got != 4 {
+		t.Errorf("expected a scenario per operation, got %d", got)
+	}
+	if err := suite.WithConnectionURL("api", petstore(t).URL+"/v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Errorf("generated suite failed: %v", err)
+	}
+}
+
+func TestOpenAPI_schemaExample(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(`
+openapi: 3.1.0
+paths: {}
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        next: {$ref: "#/components/schemas/Node"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		schema map[string]any
+		want   any
+	}{
+		{"example wins", map[string]any{"type": "integer", "example": float64(7)}, float64(7)},
+		{"enum", map[string]any{"type": "string", "enum": []any{"a", "b"}}, "a"},
+		{"format", map[string]any{"type": "string", "format": "uuid"}, "00000000-0000-0000-0000-000000000000"},
+		{"min length", map[string]any{"type": "string", "minLength": float64(8)}, "stringxx"},
+		{"minimum", map[string]any{"type": "integer", "minimum": float64(10)}, float64(10)},
+		{"nullable type list", map[string]any{"type": []any{"boolean", "null"}}, true},
+		{"array", map[string]any{"type": "array", "items": map[string]any{"type": "number"}}, []any{1.5}},
+		{
+			"object skips read-only",
+			map[string]any{"properties": map[string]any{
+				"id":   map[string]any{"type": "integer", "readOnly": true},
+				"name": map[string]any{"type": "string"},
+			}},
+			map[string]any{"name": "string"},
+		},
+		{
+			"allOf merges",
+			map[string]any{"allOf": []any{
+				map[string]any{"properties": map[string]any{"a": map[string]any{"const": "x"}}},
+				map[string]any{"properties": map[string]any{"b": map[string]any{"type": "boolean"}}},
+			}},
+			map[string]any{"a": "x", "b": true},
+		},
+		{"oneOf takes the first", map[string]any{"oneOf": []any{map[string]any{"type": "boolean"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spec.schemaExample(tt.schema, 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("recursive", func(t *testing.T) {
+		got := spec.schemaExample(map[string]any{"$ref": "#/components/schemas/Node"}, 0)
+		depth := 0
+		for m, ok := got.(map[string]any); ok; m, ok = m["next"].(map[string]any) {
+			depth++
+		}
+		if depth == 0 || depth > maxExampleDepth+1 {
+			t.Errorf("expected a bounded example, got depth %d", depth)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+//nolint:gosec // generated test data need not be unpredictable
+package expect
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Generator placeholders start with $ and make up test data, such as
+// {$uuid}, {$randInt 1 100} or {$now+1h | rfc3339}. Within a step, the same
+// placeholder has the same value, so a retried step sends it again and its
+// report and replay show it. A trailing "as name" saves the value as a
+// variable, {$uuid as order_id}, for later steps; a later step with the same
+// placeholder draws anew and saves over it.
+
+// generator makes a value from its placeholder's arguments.
+type generator func(r *rand.Rand, args []string) (any, error)
+
+//nolint:gochecknoglobals // read-only generator table
+var generators = map[string]generator{
+	"uuid":         genUUID,
+	"randInt":      genRandInt,
+	"randString":   genRandString,
+	"now":          func(*rand.Rand, []string) (any, error) { return time.Now().UTC(), nil },
+	"timestamp":    func(*rand.Rand, []string) (any, error) { return time.Now().Unix(), nil },
+	"timestamp_ms": func(*rand.Rand, []string) (any, error) { return time.Now().UnixMilli(), nil },
+	"email":        genEmail,
+	"name":         genName,
+	"firstName":    func(r *rand.Rand, _ []string) (any, error) { return pick(r, firstNames), nil },
+	"lastName":     func(r *rand.Rand, _ []string) (any, error) { return pick(r, lastNames), nil },
+
+	// The names the VS Code REST Client and JetBrains HTTP client use, for .http files.
+	"guid":         genUUID,
+	"random.uuid":  genUUID,
+	"randomInt":    genRandInt,
+	"random.email": genEmail,
+	"isoTimestamp": func(*rand.Rand, []string) (any, error) { return time.Now().UTC().Format(time.RFC3339), nil },
+}
+
+//nolint:gochecknoglobals // read-only word lists
+var (
+	firstNames = []string{
+		"Ada", "Alan", "Barbara", "Claude", "Dennis", "Edsger", "Frances", "Grace",
+		"Hedy", "John", "Katherine", "Ken", "Linus", "Margaret", "Niklaus", "Radia",
+	}
+	lastNames = []string{
+		"Allen", "Dijkstra", "Hamilton", "Hopper", "Johnson", "Kernighan", "Lamarr", "Liskov",
+		"Lovelace", "McCarthy", "Perlman", "Ritchie", "Shannon", "Thompson", "Turing", "Wirth",
+	}
+)
+
+//nolint:gochecknoglobals // compiled once
+var (
+	genAsRe     = regexp.MustCompile(`\s+as\s+([A-Za-z_][\w.]*)\s*$`)
+	genOffsetRe = regexp.MustCompile(`^([A-Za-z_.]+)([+-]\d[\w.µ]*)$`)
+)
+
+// unseededRand draws from the process-wide random source. It is safe for
+// concurrent use, as the source is.
+//
+//nolint:gochecknoglobals // stateless
+var unseededRand = rand.New(globalSource{})
+
+type globalSource struct{}
+
+func (globalSource) Uint64() uint64 { return rand.Uint64() }
+
+// seededRand returns the random source of a scenario of a seeded run. Each
+// scenario draws from its own, so parallel scenarios are reproducible too.
+func seededRand(seed int64, scenario string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(scenario))
+	return rand.New(rand.NewPCG(uint64(seed), h.Sum64()))
+}
+
+// generate resolves a generator placeholder's contents, such as
+// "$randInt 1 100 as n". ok is false when key is not a valid generator.
+// Outside a run, the "as" variable is reused if it is already set, so
+// repeated interpolation of one string agrees with itself.
+func (in interpolator) generate(key string) (any, bool) {
+	head, as := key, ""
+	if m := genAsRe.FindStringSubmatchIndex(key); m != nil {
+		head, as = key[:m[0]], key[m[2]:m[3]]
+	}
+	if in.st != nil {
+		return in.st.generate(key, func(r *rand.Rand) (any, bool) { return in.newValue(r, head, as) })
+	}
+	if val, ok := in.vars[as]; ok && as != "" {
+		return val, true
+	}
+	return in.newValue(unseededRand, head, as)
+}
+
+// newValue makes a value for a generator placeholder's contents without its
+// "as" clause, drawing from r, and saves it as the variable as, if any.
+func (in interpolator) newValue(r *rand.Rand, head, as string) (any, bool) {
+	head, pipe, piped := strings.Cut(head, "|")
+	val, err := generateValue(r, strings.Fields(head))
+	if err != nil {
+		return nil, false
+	}
+	if piped {
+		if val, err = evalExpr("$it |"+pipe, in.vars, map[string]any{"$it": val}); err != nil {
+			return nil, false
+		}
+	}
+	if as != "" && in.vars != nil {
+		in.vars[as] = val
+	}
+	return val, true
+}
+
+// generateValue runs the generator named by fields[0], such as "$now+1h",
+// with the rest of fields as its arguments.
+func generateValue(r *rand.Rand, fields []string) (any, error) {
+	if len(fields) == 0 || len(fields[0]) < 2 || fields[0][0] != '$' {
+		return nil, errors.New("not a generator")
+	}
+	name, offset := fields[0][1:], time.Duration(0)
+	if m := genOffsetRe.FindStringSubmatch(name); m != nil {
+		d, err := parseOffset(m[2])
+		if err != nil {
+			return nil, err
+		}
+		name, offset = m[1], d
+	}
+	gen, ok := generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator %q", name)
+	}
+	val, err := gen(r, fields[1:])
+	if err != nil || offset == 0 {
+		return val, err
+	}
+	switch t := val.(type) {
+	case time.Time:
+		return t.Add(offset), nil
+	case int64: // a timestamp, in seconds or milliseconds
+		if name == "timestamp_ms" {
+			return t + offset.Milliseconds(), nil
+		}
+		return t + int64(offset.Seconds()), nil
+	}
+	return nil, fmt.Errorf("generator %q takes no offset", name)
+}
+
+// parseOffset parses a duration such as "+1h" or "-1h30m", also allowing
+// days, "+7d". Offsets beyond the range of time.Duration, about 290 years,
+// are rejected.
+func parseOffset(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		d := n * float64(24*time.Hour)
+		if d >= math.MaxInt64 || d <= math.MinInt64 {
+			return 0, fmt.Errorf("offset %q out of range", s)
+		}
+		return time.Duration(d), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// genUUID makes a random (version 4) UUID.
+func genUUID(r *rand.Rand, _ []string) (any, error) {
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(r.UintN(256))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// genRandInt makes an integer from 0 to 1000, 0 to max or min to max, inclusive.
+func genRandInt(r *rand.Rand, args []string) (any, error) {
+	bounds := []int64{0, 1000}
+	switch len(args) {
+	case 0:
+	case 1, 2:
+		for i, a := range args {
+			n, err := strconv.ParseInt(a, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bound %q", a)
+			}
+			bounds[len(bounds)-len(args)+i] = n
+		}
+	default:
+		return nil, errors.New("randInt takes at most a minimum and a maximum")
+	}
+	span := bounds[1] - bounds[0] + 1
+	if span <= 0 {
+		return nil, fmt.Errorf("invalid range %d to %d", bounds[0], bounds[1])
+	}
+	return bounds[0] + r.Int64N(span), nil
+}
+
+// maxRandStringLength bounds the length of {$randString n}.
+const maxRandStringLength = 1 << 20
+
+// genRandString makes a string of letters and digits, 10 long by default and
+// at most maxRandStringLength.
+func genRandString(r *rand.Rand, args []string) (any, error) {
+	n := 10
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid length %q", args[0])
+		}
+		if n > maxRandStringLength {
+			return nil, fmt.Errorf("length %d is over the maximum of %d", n, maxRandStringLength)
+		}
+	}
+	return randString(r, n, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"), nil
+}
+
+// genEmail makes an address at example.com, unique to the run.
+func genEmail(r *rand.Rand, _ []string) (any, error) {
+	local := strings.ToLower(pick(r, firstNames) + "." + pick(r, lastNames))
+	return local + "." + randString(r, 6, "abcdefghijklmnopqrstuvwxyz0123456789") + "@example.com", nil
+}
+
+// genName makes a full name.
+func genName(r *rand.Rand, _ []string) (any, error) {
+	return pick(r, firstNames) + " " + pick(r, lastNames), nil
+}
+
+func randString(r *rand.Rand, n int, alphabet string) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[r.IntN(len(alphabet))]
+	}
+	return string(b)
+}
+
+func pick(r *rand.Rand, words []string) string {
+	return words[r.IntN(len(words))]
+}
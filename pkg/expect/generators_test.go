@@ -0,0 +1,264 @@
+package expect
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVarStore_generators(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // regexp
+	}{
+		{"{$uuid}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"{$guid}", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"{$randInt 5 5}", `^5$`},
+		{"n={$randInt 3}", `^n=[0-3]$`},
+		{"{$randInt}", `^\d{1,4}$`},
+		{"{$randString 12}", `^[A-Za-z0-9]{12}$`},
+		{"{$randString}", `^[A-Za-z0-9]{10}$`},
+		{"{$now}", `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(\.\d+)?Z$`},
+		{"{$now+1h | rfc3339}", `^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`},
+		{"{$now | format('2006')}", `^\d{4}$`},
+		{"{$timestamp}", `^\d{10}$`},
+		{"{$timestamp_ms}", `^\d{13}$`},
+		{"{$email}", `^[a-z]+\.[a-z]+\.[a-z0-9]{6}@example\.com$`},
+		{"{$name}", `^[A-Z][a-z]+ [A-Z][A-Za-z]+$`},
+		{"{$nope}", `^\{\$nope\}$`},
+		{"{$randInt 5 1}", `^\{\$randInt 5 1\}$`},
+		{"{$uuid+1h}", `^\{\$uuid\+1h\}$`},
+		{"{$randString 100000000000}", `^\{\$randString 100000000000\}$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := (VarStore{}).Interpolate(tt.in); !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("expected %s, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVarStore_generatorOffsets(t *testing.T) {
+	vars := VarStore{}
+	now := time.Now().Unix()
+	for in, want := range map[string]int64{
+		"{$timestamp+1h}":     now + 3600,
+		"{$timestamp-30m}":    now - 1800,
+		"{$timestamp+2d}":     now + 2*86400,
+		"{$now+1h30m | unix}": now + 5400,
+	} {
+		got, err := strconv.ParseInt(vars.Interpolate(in), 10, 64)
+		if err != nil || got < want || got > want+2 {
+			t.Errorf("%s: expected about %d, got %d (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"{$now+99999999999d}", "{$timestamp-1e300d}", "{$now+9999999999h}"} {
+		if got := vars.Interpolate(in); got != in {
+			t.Errorf("expected %s out of range to be left as-is, got %q", in, got)
+		}
+	}
+}
+
+func TestVarStore_generatorAs(t *testing.T) {
+	vars := VarStore{}
+	got := vars.Interpolate("/orders/{$uuid as order_id}?again={order_id}&same={$uuid as order_id}")
+	id, ok := vars["order_id"].(string)
+	if !ok || got != "/orders/"+id+"?again="+id+"&same="+id {
+		t.Errorf("expected the saved id %q throughout, got %q", id, got)
+	}
+
+	body := string(vars.InterpolateJSON([]byte(`{"n": "{$randInt 7 7 as n}", "id": "{$uuid}"}`)))
+	if vars["n"] != int64(7) || !regexp.MustCompile(`^\{"n": 7, "id": "[0-9a-f-]{36}"\}$`).MatchString(body) {
+		t.Errorf("unexpected body %s and n %v", body, vars["n"])
+	}
+}
+
+func TestSuite_WithSeed(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	run := func(seed int64, scenario string) string {
+		paths = nil
+		suite := NewSuite().WithSeed(seed).WithConnections(HTTP("api", srv.URL)).
+			WithScenarios(NewScenario(scenario).AddStep(GET("/{$uuid}/{$randString 8}/{$email}")))
+		if err := suite.RunContext(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return paths[0]
+	}
+	first := run(42, "a")
+	if again := run(42, "a"); again != first {
+		t.Errorf("expected the same seed to generate %q, got %q", first, again)
+	}
+	if other := run(42, "b"); other == first {
+		t.Errorf("expected another scenario to generate other values than %q", first)
+	}
+	if other := run(7, "a"); other == first {
+		t.Errorf("expected another seed to generate other values than %q", first)
+	}
+}
+
+func TestScenario_run_sharedVars(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	seed := int64(7)
+	env := scenarioEnv{
+		log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		defaultConn: HTTP("api", srv.URL),
+		runStep:     runStepDirect,
+		seed:        &seed,
+	}
+	sc := NewScenario("ids").AddStep(GET("/{$uuid}")).AddStep(GET("/{$randInt 1 1000000}"))
+	if err := sc.run(context.Background(), env, VarStore{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := maps.Clone(paths)
+
+	// Runs sharing a VarStore each keep their own state, so each draws what
+	// a run alone does.
+	vars := VarStore{}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			if err := sc.run(context.Background(), env, vars); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	for path, n := range want {
+		if paths[path] != 5*n {
+			t.Errorf("expected every run to request %s, got %v", path, paths)
+		}
+	}
+}
+
+func TestVarState(t *testing.T) {
+	seed := int64(42)
+	env := scenarioEnv{seed: &seed}
+	vars := VarStore{"id": "x"}
+	a, b := env.newVarState("a", vars), env.newVarState("a", vars)
+	first, again := a.interpolator().interpolate("{$uuid}"), b.interpolator().interpolate("{$uuid}")
+	if first != again {
+		t.Errorf("expected the same seed to generate %q, got %q", first, again)
+	}
+	a.markSecret("id")
+	if got := b.redact("x-id"); got != "x-id" {
+		t.Errorf("expected states of one store to keep their own secrets, got %q", got)
+	}
+	if len(vars) != 1 {
+		t.Errorf("expected the variables untouched, got %v", vars)
+	}
+}
+
+func TestSuite_generatedPerStep(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/fail/") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	rec := &recordingReporter{}
+	err := NewSuite().WithReporter(rec).WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(NewScenario("ids").
+			AddStep(GET("/ok/{$uuid}/{$uuid}")).
+			AddStep(GET("/fail/{$uuid}").ExpectStatus(200).
+				WithRetry(RetryPolicy{Attempts: 3, Interval: time.Millisecond}))).
+		RunContext(context.Background())
+	if err == nil {
+		t.Fatal("expected the second step to fail")
+	}
+	if len(paths) != 4 {
+		t.Fatalf("expected 4 requests, got %v", paths)
+	}
+	if first := strings.Split(paths[0], "/"); first[2] != first[3] {
+		t.Errorf("expected a placeholder to keep its value within a step, got %s", paths[0])
+	}
+	failing := paths[1]
+	if strings.Contains(paths[0], strings.TrimPrefix(failing, "/fail/")) {
+		t.Errorf("expected the next step to draw a new value, got %s and %s", paths[0], failing)
+	}
+	if paths[2] != failing || paths[3] != failing {
+		t.Errorf("expected retries to send the same value, got %v", paths[1:])
+	}
+	if !strings.Contains(err.Error(), srv.URL+failing) {
+		t.Errorf("expected the replay to show %s, got %v", failing, err)
+	}
+	var summaries []string
+	for _, e := range rec.events {
+		if e.Kind == EventStepStart {
+			summaries = append(summaries, e.Request)
+		}
+	}
+	if len(summaries) != 2 || summaries[1] != "GET "+failing {
+		t.Errorf("expected the report to show %s, got %q", failing, summaries)
+	}
+}
+
+func TestSuite_generatorAsPerStep(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		paths []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	err := NewSuite().WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(NewScenario("ids").
+			AddStep(GET("/a/{$uuid as id}")).
+			AddStep(GET("/b/{$uuid as id}")).
+			AddStep(GET("/c/{id}"))).
+		RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 requests, got %v", paths)
+	}
+	a, b := strings.TrimPrefix(paths[0], "/a/"), strings.TrimPrefix(paths[1], "/b/")
+	if a == b {
+		t.Errorf("expected each step to draw a new id, got %s twice", a)
+	}
+	if paths[2] != "/c/"+b {
+		t.Errorf("expected the last value saved, got %s after %s", paths[2], b)
+	}
+}
@@ -3,6 +3,7 @@ package expect
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
@@ -20,7 +21,9 @@ type GRPCConnection struct {
 	Name string
 	Addr string
 	opts []grpc.DialOption
-	conn *grpc.ClientConn
+
+	connMu sync.Mutex
+	conn   *grpc.ClientConn
 
 	mu      sync.Mutex
 	methods map[string]protoreflect.MethodDescriptor
@@ -36,39 +39,55 @@ func (c *GRPCConnection) Type() string    { return "grpc" }
 func (c *GRPCConnection) GetName() string { return c.Name }
 
 // Dial opens the underlying gRPC client connection (lazy — called on first use).
+// It is safe for concurrent use.
 func (c *GRPCConnection) Dial() error {
+	_, err := c.ClientConn()
+	return err
+}
+
+// ClientConn returns the raw *grpc.ClientConn, dialling if necessary.
+func (c *GRPCConnection) ClientConn() (*grpc.ClientConn, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
 	if c.conn != nil {
-		return nil
+		return c.conn, nil
 	}
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 	opts = append(opts, c.opts...)
 	conn, err := grpc.NewClient(c.Addr, opts...)
 	if err != nil {
-		return fmt.Errorf("grpc dial %q: %w", c.Addr, err)
+		return nil, fmt.Errorf("grpc dial %q: %w", c.Addr, err)
 	}
 	c.conn = conn
-	return nil
-}
-
-// ClientConn returns the raw *grpc.ClientConn, dialling if necessary.
-func (c *GRPCConnection) ClientConn() (*grpc.ClientConn, error) {
-	if err := c.Dial(); err != nil {
-		return nil, err
-	}
-	return c.conn, nil
+	return conn, nil
 }
 
 // Close tears down the gRPC connection.
 func (c *GRPCConnection) Close() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
 	if c.conn != nil {
-		return c.conn.Close()
+		err := c.conn.Close()
+		c.conn = nil
+		return err
 	}
 	return nil
 }
 
+// withURL returns a new connection to addr, keeping the dial options, such
+// as credentials and interceptors.
+func (c *GRPCConnection) withURL(addr string) Connection {
+	return GRPC(c.Name, addr, slices.Clone(c.opts)...)
+}
+
 // resolveMethod uses gRPC server reflection to look up the MethodDescriptor for fullMethod.
 // Results are cached for the lifetime of the connection.
 func (c *GRPCConnection) resolveMethod(ctx context.Context, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	cc, err := c.ClientConn()
+	if err != nil {
+		return nil, err
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -87,31 +106,102 @@ func (c *GRPCConnection) resolveMethod(ctx context.Context, fullMethod string) (
 	}
 	serviceSymbol, methodName := parts[0], parts[1]
 
-	refClient := grpc_reflection_v1.NewServerReflectionClient(c.conn)
-	stream, err := refClient.ServerReflectionInfo(ctx)
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(cc).ServerReflectionInfo(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("reflection stream: %w", err)
 	}
 	defer stream.CloseSend() //nolint:errcheck
 
-	if err := stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
-		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_FileContainingSymbol{
-			FileContainingSymbol: serviceSymbol,
-		},
-	}); err != nil {
-		return nil, fmt.Errorf("reflection send: %w", err)
+	svcDesc, err := reflectService(stream, serviceSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	methodDesc := svcDesc.Methods().ByName(protoreflect.Name(methodName))
+	if methodDesc == nil {
+		return nil, fmt.Errorf("method %q not found in service %q", methodName, serviceSymbol)
 	}
 
+	c.methods[fullMethod] = methodDesc
+	return methodDesc, nil
+}
+
+// listMethods uses gRPC server reflection to list the full method names of
+// every service the server exposes, except the reflection service itself.
+func (c *GRPCConnection) listMethods(ctx context.Context) ([]string, error) {
+	cc, err := c.ClientConn()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(cc).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection stream: %w", err)
+	}
+	defer stream.CloseSend() //nolint:errcheck
+
+	resp, err := reflectionCall(stream, &grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list, ok := resp.MessageResponse.(*grpc_reflection_v1.ServerReflectionResponse_ListServicesResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reflection response type")
+	}
+
+	var methods []string
+	for _, svc := range list.ListServicesResponse.GetService() {
+		name := svc.GetName()
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		svcDesc, err := reflectService(stream, name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range svcDesc.Methods().Len() {
+			methods = append(methods, "/"+name+"/"+string(svcDesc.Methods().Get(i).Name()))
+		}
+	}
+	return methods, nil
+}
+
+// reflectionCall sends req on the reflection stream and returns the response, or the
+// server's error response as an error.
+func reflectionCall(
+	stream grpc_reflection_v1.ServerReflection_ServerReflectionInfoClient,
+	req *grpc_reflection_v1.ServerReflectionRequest,
+) (*grpc_reflection_v1.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, fmt.Errorf("reflection send: %w", err)
+	}
 	resp, err := stream.Recv()
 	if err != nil {
 		return nil, fmt.Errorf("reflection recv: %w", err)
 	}
+	if errMsg, ok := resp.MessageResponse.(*grpc_reflection_v1.ServerReflectionResponse_ErrorResponse); ok {
+		return nil, fmt.Errorf("reflection error: %s", errMsg.ErrorResponse.ErrorMessage)
+	}
+	return resp, nil
+}
 
+// reflectService looks up the descriptor of the service named serviceSymbol on
+// the reflection stream.
+func reflectService(
+	stream grpc_reflection_v1.ServerReflection_ServerReflectionInfoClient,
+	serviceSymbol string,
+) (protoreflect.ServiceDescriptor, error) {
+	resp, err := reflectionCall(stream, &grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: serviceSymbol,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
 	fdResp, ok := resp.MessageResponse.(*grpc_reflection_v1.ServerReflectionResponse_FileDescriptorResponse)
 	if !ok {
-		if errMsg, ok := resp.MessageResponse.(*grpc_reflection_v1.ServerReflectionResponse_ErrorResponse); ok {
-			return nil, fmt.Errorf("reflection error: %s", errMsg.ErrorResponse.ErrorMessage)
-		}
 		return nil, fmt.Errorf("unexpected reflection response type")
 	}
 
@@ -143,12 +233,5 @@ func (c *GRPCConnection) resolveMethod(ctx context.Context, fullMethod string) (
 	if !ok {
 		return nil, fmt.Errorf("%q is not a service descriptor", serviceSymbol)
 	}
-
-	methodDesc := svcDesc.Methods().ByName(protoreflect.Name(methodName))
-	if methodDesc == nil {
-		return nil, fmt.Errorf("method %q not found in service %q", methodName, serviceSymbol)
-	}
-
-	c.methods[fullMethod] = methodDesc
-	return methodDesc, nil
+	return svcDesc, nil
 }
@@ -2,68 +2,269 @@ package expect
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
-// GRPCRequest invokes a unary gRPC method using server reflection to resolve
+// GRPCRequest invokes a gRPC method using server reflection to resolve
 // proto descriptors. Body is the JSON-encoded request; an empty body sends {}.
+// Streaming methods are detected from the descriptor and send Messages as the
+// request stream (or Body as the single request when Messages is empty).
 type GRPCRequest struct {
 	// FullMethod is the full gRPC method path, e.g. "/mypackage.MyService/MyMethod".
 	FullMethod string
 	// Body is the JSON-encoded request body.
 	Body []byte
+	// Messages are the JSON-encoded messages sent on a client or bidi stream.
+	Messages [][]byte
 	// Header is outgoing metadata to attach to the call.
 	Header map[string]string
 }
 
-// Run invokes the gRPC method and returns the raw JSON response bytes.
-func (r *GRPCRequest) Run(conn *GRPCConnection, vars VarStore) ([]byte, error) {
-	cc, err := conn.ClientConn()
+// Label describes the request as "grpc /pkg.Service/Method".
+func (r *GRPCRequest) Label() string {
+	return "grpc " + r.FullMethod
+}
+
+// Do implements Requester; conn must be a *GRPCConnection. Unary methods return
+// the JSON response as []byte, streaming methods the received messages as [][]byte.
+func (r *GRPCRequest) Do(ctx context.Context, conn Connection, vars VarStore) (any, error) {
+	grpcConn, ok := conn.(*GRPCConnection)
+	if !ok {
+		return nil, mismatchedConnection("gRPC", conn)
+	}
+	in := newInterpolator(ctx, vars)
+	ctx, call, err := r.prepare(ctx, grpcConn, in)
+	if err != nil {
+		return nil, err
+	}
+	if call.method.IsStreamingClient() || call.method.IsStreamingServer() {
+		return call.stream(ctx, r.streamMessages(in))
+	}
+	return call.unary(ctx, r.body(in))
+}
+
+// Run invokes a unary gRPC method and returns the raw JSON response bytes.
+func (r *GRPCRequest) Run(ctx context.Context, conn *GRPCConnection, vars VarStore) ([]byte, error) {
+	in := newInterpolator(ctx, vars)
+	ctx, call, err := r.prepare(ctx, conn, in)
 	if err != nil {
 		return nil, err
 	}
+	return call.unary(ctx, r.body(in))
+}
+
+// RunStream invokes a client, server or bidi streaming gRPC method and returns
+// the JSON encoding of every received message, in order. Messages received
+// before a failure are returned alongside the error.
+func (r *GRPCRequest) RunStream(ctx context.Context, conn *GRPCConnection, vars VarStore) ([][]byte, error) {
+	in := newInterpolator(ctx, vars)
+	ctx, call, err := r.prepare(ctx, conn, in)
+	if err != nil {
+		return nil, err
+	}
+	return call.stream(ctx, r.streamMessages(in))
+}
+
+// Script implements Scripter as a grpcurl command. Connections without dial
+// options are taken to be plaintext.
+func (r *GRPCRequest) Script(conn Connection, vars VarStore) string {
+	return r.script(conn, interpolator{vars: vars})
+}
 
-	fullMethod := vars.Interpolate(r.FullMethod)
+func (r *GRPCRequest) script(conn Connection, in interpolator) string {
+	head := "grpcurl"
+	addr := "localhost:50051"
+	if c, ok := conn.(*GRPCConnection); ok {
+		addr = c.Addr
+		if len(c.opts) == 0 {
+			head += " -plaintext"
+		}
+	}
+	parts := []string{head}
+	for _, k := range slices.Sorted(maps.Keys(r.Header)) {
+		parts = append(parts, "-H "+shellQuote(k+": "+in.interpolate(r.Header[k])))
+	}
+	body := string(r.body(in))
+	if len(r.Messages) > 0 {
+		msgs := make([]string, len(r.Messages))
+		for i, m := range r.Messages {
+			msgs[i] = string(in.interpolateJSON(m))
+		}
+		body = strings.Join(msgs, "\n")
+	}
+	parts = append(parts, "-d "+shellQuote(body),
+		shellQuote(addr)+" "+shellQuote(strings.TrimPrefix(in.interpolate(r.FullMethod), "/")))
+	return shellCommand(parts...)
+}
+
+func (r *GRPCRequest) body(in interpolator) []byte {
+	body := in.interpolateJSON(r.Body)
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+	return body
+}
+
+func (r *GRPCRequest) streamMessages(in interpolator) [][]byte {
+	if len(r.Messages) == 0 {
+		return [][]byte{r.body(in)}
+	}
+	msgs := make([][]byte, len(r.Messages))
+	for i, m := range r.Messages {
+		msgs[i] = in.interpolateJSON(m)
+	}
+	return msgs
+}
+
+// grpcCall is a resolved gRPC method ready to be invoked.
+type grpcCall struct {
+	cc         *grpc.ClientConn
+	conn       string // connection name, for coverage
+	fullMethod string
+	method     protoreflect.MethodDescriptor
+}
+
+// prepare dials conn, attaches outgoing metadata to ctx and resolves the method descriptor.
+func (r *GRPCRequest) prepare(ctx context.Context, conn *GRPCConnection, in interpolator) (context.Context, *grpcCall, error) {
+	cc, err := conn.ClientConn()
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	fullMethod := in.interpolate(r.FullMethod)
 
-	ctx := context.Background()
 	if len(r.Header) > 0 {
 		md := metadata.New(nil)
 		for k, v := range r.Header {
-			md.Append(k, vars.Interpolate(v))
+			md.Append(k, in.interpolate(v))
 		}
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
 	methodDesc, err := conn.resolveMethod(ctx, fullMethod)
 	if err != nil {
-		return nil, fmt.Errorf("resolve method: %w", err)
-	}
-
-	body := vars.InterpolateBytes(r.Body)
-	if len(body) == 0 {
-		body = []byte("{}")
+		return ctx, nil, fmt.Errorf("resolve method: %w", err)
 	}
+	return ctx, &grpcCall{cc: cc, conn: conn.Name, fullMethod: fullMethod, method: methodDesc}, nil
+}
 
-	reqMsg := dynamicpb.NewMessage(methodDesc.Input())
+func (c *grpcCall) newRequest(body []byte) (*dynamicpb.Message, error) {
+	reqMsg := dynamicpb.NewMessage(c.method.Input())
 	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, reqMsg); err != nil {
 		return nil, fmt.Errorf("unmarshal request body: %w", err)
 	}
+	return reqMsg, nil
+}
+
+func marshalResponse(msg *dynamicpb.Message) ([]byte, error) {
+	respBytes, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal response: %w", err)
+	}
+	return respBytes, nil
+}
+
+func (c *grpcCall) unary(ctx context.Context, body []byte) ([]byte, error) {
+	reqMsg, err := c.newRequest(body)
+	if err != nil {
+		return nil, err
+	}
 
-	respMsg := dynamicpb.NewMessage(methodDesc.Output())
-	if err := cc.Invoke(ctx, fullMethod, reqMsg, respMsg); err != nil {
+	respMsg := dynamicpb.NewMessage(c.method.Output())
+	err = c.cc.Invoke(ctx, c.fullMethod, reqMsg, respMsg)
+	c.record(ctx, err)
+	if err != nil {
 		return nil, err
 	}
+	return marshalResponse(respMsg)
+}
+
+// record notes the call and its status code for coverage.
+func (c *grpcCall) record(ctx context.Context, err error) {
+	recordCoverage(ctx, coverageHit{conn: c.conn, target: c.fullMethod, status: status.Code(err).String()})
+}
 
-	respBytes, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(respMsg)
+// stream sends msgs on a new stream while concurrently receiving responses until
+// the server closes it.
+func (c *grpcCall) stream(ctx context.Context, msgs [][]byte) ([][]byte, error) {
+	reqs := make([]*dynamicpb.Message, len(msgs))
+	for i, m := range msgs {
+		req, err := c.newRequest(m)
+		if err != nil {
+			return nil, fmt.Errorf("message [%d]: %w", i, err)
+		}
+		reqs[i] = req
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	desc := &grpc.StreamDesc{
+		StreamName:    string(c.method.Name()),
+		ServerStreams: c.method.IsStreamingServer(),
+		ClientStreams: c.method.IsStreamingClient(),
+	}
+	stream, err := c.cc.NewStream(ctx, desc, c.fullMethod)
 	if err != nil {
-		return nil, fmt.Errorf("marshal response: %w", err)
+		c.record(ctx, err)
+		return nil, err
 	}
-	return respBytes, nil
+	received, err := c.exchange(stream, desc.ServerStreams, reqs)
+	c.record(ctx, err)
+	return received, err
+}
+
+// exchange sends reqs on stream while receiving responses until the server
+// closes it, or after the first response when it does not stream.
+func (c *grpcCall) exchange(stream grpc.ClientStream, serverStreams bool, reqs []*dynamicpb.Message) ([][]byte, error) {
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, req := range reqs {
+			if err := stream.SendMsg(req); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	var received [][]byte
+	for {
+		respMsg := dynamicpb.NewMessage(c.method.Output())
+		if err := stream.RecvMsg(respMsg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return received, err
+		}
+		respBytes, err := marshalResponse(respMsg)
+		if err != nil {
+			return received, err
+		}
+		received = append(received, respBytes)
+		if !serverStreams {
+			break
+		}
+	}
+
+	// io.EOF from SendMsg means the server closed the stream; its status was read above.
+	if err := <-sendErr; err != nil && !errors.Is(err, io.EOF) {
+		return received, err
+	}
+	return received, nil
 }
 
 // GRPCExpect validates a gRPC response.
@@ -71,25 +272,70 @@ type GRPCExpect struct {
 	// Code is the expected gRPC status code name (e.g. "OK", "NOT_FOUND").
 	// If empty, any code is accepted.
 	Code string
-	// Body is the expected response body for partial JSON matching.
+	// Body is the expected response body for partial JSON matching (unary calls).
 	Body ExpectBody
-	// Save extracts fields from the JSON response into variables.
+	// Schema, if set, is matched against the whole decoded response body, or
+	// against every streamed message, e.g. JSONSchema.
+	Schema Matcher
+	// Messages are the expected streamed response messages for partial JSON matching.
+	// They are matched position by position unless Unordered is set.
+	Messages []ExpectBody
+	// Unordered matches each expected message against any distinct received message.
+	Unordered bool
+	// MessageCount, if set, is the exact number of streamed messages expected.
+	MessageCount *int
+	// Assert are expressions that must hold, over the decoded body of a unary
+	// call, or the messages of a streaming call, and the status code name.
+	Assert []string
+	// Save extracts fields from the JSON response into variables. For streaming
+	// calls fields are resolved against the array of received messages, e.g. "0.id".
 	Save []SaveEntry
 }
 
+// Check implements Expectation; resp must be the []byte returned by GRPCRequest.Run
+// or the [][]byte returned by GRPCRequest.RunStream.
+// Errors that do not carry a gRPC status (e.g. a mismatched connection) fail directly.
+func (e *GRPCExpect) Check(resp any, err error, vars VarStore) error {
+	if _, ok := status.FromError(err); !ok {
+		return err
+	}
+	switch r := resp.(type) {
+	case nil:
+		return e.Validate(nil, err, vars)
+	case []byte:
+		return e.Validate(r, err, vars)
+	case [][]byte:
+		return e.ValidateStream(r, err, vars)
+	default:
+		return mismatchedResponse("gRPC", resp)
+	}
+}
+
 // Validate checks the gRPC response bytes against expectations.
 func (e *GRPCExpect) Validate(respBytes []byte, grpcErr error, vars VarStore) error {
-	if e.Code != "" {
-		st, _ := status.FromError(grpcErr)
-		if st.Code().String() != e.Code {
-			return fmt.Errorf("unexpected grpc code: %s", st.Code().String())
-		}
-	} else if grpcErr != nil {
-		return fmt.Errorf("unexpected grpc error: %w", grpcErr)
+	if err := e.validateCode(grpcErr); err != nil {
+		return err
 	}
 
 	if e.Body != nil && respBytes != nil {
-		if err := e.Body.Validate(respBytes); err != nil {
+		if err := e.Body.validate(respBytes, vars); err != nil {
+			return err
+		}
+	}
+
+	if e.Schema != nil && respBytes != nil {
+		if err := matchJSON(respBytes, e.Schema, vars); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Assert) > 0 {
+		var body any
+		if respBytes != nil {
+			body = exprJSON(respBytes)
+		}
+		bindings := map[string]any{"body": body, "code": status.Code(grpcErr).String()}
+		if err := checkAssertions(e.Assert, vars, bindings); err != nil {
 			return err
 		}
 	}
@@ -100,3 +346,86 @@ func (e *GRPCExpect) Validate(respBytes []byte, grpcErr error, vars VarStore) er
 
 	return nil
 }
+
+// ValidateStream checks the received stream messages against expectations.
+func (e *GRPCExpect) ValidateStream(messages [][]byte, grpcErr error, vars VarStore) error {
+	if err := e.validateCode(grpcErr); err != nil {
+		return err
+	}
+
+	if e.MessageCount != nil && len(messages) != *e.MessageCount {
+		return fmt.Errorf("unexpected message count: got %d, want %d", len(messages), *e.MessageCount)
+	}
+
+	if e.Schema != nil {
+		for i, msg := range messages {
+			if err := matchJSON(msg, e.Schema, vars); err != nil {
+				return fmt.Errorf("message [%d]: %w", i, err)
+			}
+		}
+	}
+
+	if e.Unordered {
+		if err := matchUnordered(messages, e.Messages, vars); err != nil {
+			return err
+		}
+	} else {
+		for i, expected := range e.Messages {
+			if i >= len(messages) {
+				return fmt.Errorf("expected message [%d] but only got %d messages", i, len(messages))
+			}
+			if err := expected.validate(messages[i], vars); err != nil {
+				return fmt.Errorf("message [%d]: %w", i, err)
+			}
+		}
+	}
+
+	if len(e.Assert) > 0 {
+		decoded := make([]any, len(messages))
+		for i, m := range messages {
+			decoded[i] = exprJSON(m)
+		}
+		bindings := map[string]any{"messages": decoded, "code": status.Code(grpcErr).String()}
+		if err := checkAssertions(e.Assert, vars, bindings); err != nil {
+			return err
+		}
+	}
+
+	if len(e.Save) > 0 && vars != nil {
+		raw := make([]json.RawMessage, len(messages))
+		for i, m := range messages {
+			raw[i] = m
+		}
+		if arr, err := json.Marshal(raw); err == nil {
+			saveFromJSON(arr, e.Save, vars)
+		}
+	}
+
+	return nil
+}
+
+// saves returns the variables the expectation saves.
+func (e *GRPCExpect) saves() []SaveEntry { return e.Save }
+
+func (e *GRPCExpect) validateCode(grpcErr error) error {
+	if e.Code != "" {
+		st, _ := status.FromError(grpcErr)
+		if st.Code().String() != e.Code {
+			return fmt.Errorf("unexpected grpc code: %s", st.Code().String())
+		}
+	} else if grpcErr != nil {
+		return fmt.Errorf("unexpected grpc error: %w", grpcErr)
+	}
+	return nil
+}
+
+// matchUnordered checks that every expected message matches a distinct received message.
+func matchUnordered(messages [][]byte, expected []ExpectBody, vars VarStore) error {
+	i := matchDistinct(len(expected), len(messages), func(i, j int) bool {
+		return expected[i].validate(messages[j], vars) == nil
+	})
+	if i >= 0 {
+		return fmt.Errorf("expected message [%d] not found in %d received messages", i, len(messages))
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	grpc_reflection_v1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// startNumbersServer serves a reflection-enabled numbers.Numbers service built
+// from a dynamic descriptor:
+//
+//	rpc Double(Num) returns (Num);               // n * 2
+//	rpc Count(Num) returns (stream Num);         // 1..n
+//	rpc Sum(stream Num) returns (Num);           // sum of n
+//	rpc Echo(stream Num) returns (stream Num);   // echoes each n
+func startNumbersServer(t *testing.T) string {
+	t.Helper()
+
+	num := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("n"),
+		JsonName: proto.String("n"),
+		Number:   proto.Int32(1),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	method := func(name string, clientStream, serverStream bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(".numbers.Num"),
+			OutputType:      proto.String(".numbers.Num"),
+			ClientStreaming: proto.Bool(clientStream),
+			ServerStreaming: proto.Bool(serverStream),
+		}
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("numbers.proto"),
+		Package:     proto.String("numbers"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Num"), Field: []*descriptorpb.FieldDescriptorProto{num}}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Numbers"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Double", false, false),
+				method("Count", false, true),
+				method("Sum", true, false),
+				method("Echo", true, true),
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(fd); err != nil {
+		t.Fatalf("register descriptor: %v", err)
+	}
+
+	msgDesc := fd.Messages().ByName("Num")
+	field := msgDesc.Fields().ByName("n")
+	newNum := func(n int32) *dynamicpb.Message {
+		m := dynamicpb.NewMessage(msgDesc)
+		m.Set(field, protoreflect.ValueOfInt32(n))
+		return m
+	}
+	getN := func(m *dynamicpb.Message) int32 { return int32(m.Get(field).Int()) }
+
+	srv := grpc.NewServer()
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "numbers.Numbers",
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Double",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(msgDesc)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return newNum(getN(in) * 2), nil
+			},
+		}},
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName:    "Count",
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					in := dynamicpb.NewMessage(msgDesc)
+					if err := stream.RecvMsg(in); err != nil {
+						return err
+					}
+					for i := int32(1); i <= getN(in); i++ {
+						if err := stream.SendMsg(newNum(i)); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+			{
+				StreamName:    "Sum",
+				ClientStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					var sum int32
+					for {
+						in := dynamicpb.NewMessage(msgDesc)
+						if err := stream.RecvMsg(in); errors.Is(err, io.EOF) {
+							return stream.SendMsg(newNum(sum))
+						} else if err != nil {
+							return err
+						}
+						sum += getN(in)
+					}
+				},
+			},
+			{
+				StreamName:    "Echo",
+				ClientStreams: true,
+				ServerStreams: true,
+				Handler: func(_ any, stream grpc.ServerStream) error {
+					for {
+						in := dynamicpb.NewMessage(msgDesc)
+						if err := stream.RecvMsg(in); errors.Is(err, io.EOF) {
+							return nil
+						} else if err != nil {
+							return err
+						}
+						if err := stream.SendMsg(newNum(getN(in))); err != nil {
+							return err
+						}
+					}
+				},
+			},
+		},
+	}, nil)
+	grpc_reflection_v1.RegisterServerReflectionServer(srv, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           srv,
+		DescriptorResolver: files,
+	}))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestGRPCRequest_streaming(t *testing.T) {
+	conn := GRPC("numbers", startNumbersServer(t))
+	t.Cleanup(func() { conn.Close() })
+
+	tests := []struct {
+		name string
+		step *StepBuilder
+		want VarStore
+	}{
+		{
+			name: "unary",
+			step: GRPCRawCall("numbers", "/numbers.Numbers/Double", []byte(`{"n":21}`)).
+				ExpectGRPCCode("OK").
+				ExpectGRPCBody(map[string]any{"n": float64(42)}),
+		},
+		{
+			name: "server streaming",
+			step: GRPCStreamCall("numbers", "/numbers.Numbers/Count", []byte(`{"n":3}`)).
+				ExpectGRPCCode("OK").
+				ExpectGRPCMessageCount(3).
+				ExpectGRPCMessages(
+					map[string]any{"n": float64(1)},
+					map[string]any{"n": float64(2)},
+					map[string]any{"n": float64(3)},
+				).
+				SaveGRPCMessage(2, "n", "last"),
+			want: VarStore{"last": float64(3)},
+		},
+		{
+			name: "client streaming",
+			step: GRPCStreamCall("numbers", "/numbers.Numbers/Sum",
+				[]byte(`{"n":1}`), []byte(`{"n":2}`), []byte(`{"n":{base}}`)).
+				ExpectGRPCMessageCount(1).
+				ExpectGRPCMessages(map[string]any{"n": float64(13)}),
+		},
+		{
+			name: "bidi streaming unordered",
+			step: GRPCStreamCall("numbers", "/numbers.Numbers/Echo",
+				[]byte(`{"n":5}`), []byte(`{"n":6}`)).
+				ExpectGRPCMessagesUnordered(map[string]any{"n": float64(6)}, map[string]any{"n": float64(5)}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := VarStore{"base": 10}
+			step := tt.step.Build()
+			if err := step.Run(t.Context(), conn, vars); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for k, v := range tt.want {
+				if vars[k] != v {
+					t.Errorf("expected %s=%v, got %v", k, v, vars[k])
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCExpect_ValidateStream(t *testing.T) {
+	messages := [][]byte{[]byte(`{"n":1}`), []byte(`{"n":2}`)}
+	count := 2
+
+	tests := []struct {
+		name    string
+		expect  GRPCExpect
+		wantErr bool
+	}{
+		{"count match", GRPCExpect{MessageCount: &count}, false},
+		{"ordered match", GRPCExpect{Messages: []ExpectBody{ExpectBody(`{"n":1}`), ExpectBody(`{"n":2}`)}}, false},
+		{"ordered mismatch", GRPCExpect{Messages: []ExpectBody{ExpectBody(`{"n":2}`)}}, true},
+		{"too few messages", GRPCExpect{Messages: []ExpectBody{ExpectBody(`{}`), ExpectBody(`{}`), ExpectBody(`{}`)}}, true},
+		{"unordered match", GRPCExpect{Unordered: true, Messages: []ExpectBody{ExpectBody(`{"n":2}`)}}, false},
+		{"unordered distinct", GRPCExpect{Unordered: true, Messages: []ExpectBody{ExpectBody(`{"n":2}`), ExpectBody(`{"n":2}`)}}, true},
+		{"unordered revisits earlier matches", GRPCExpect{
+			Unordered: true, Messages: []ExpectBody{ExpectBody(`{}`), ExpectBody(`{"n":1}`)},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.expect.ValidateStream(messages, nil, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package expect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// LoadHAR converts the entries of a HAR (HTTP Archive) file, as saved by
+// browser devtools, into a Suite. The entries of each page become a scenario
+// named after the page title, in recorded order, and entries without a page
+// form a scenario named "har". Every origin becomes an HTTP connection named
+// after its host. Each step replays the recorded method, path, query, headers
+// and body, and expects the recorded status code.
+//
+// Headers the HTTP client sets itself, such as Host, Content-Length and
+// Accept-Encoding, and HTTP/2 pseudo-headers are not replayed.
+func LoadHAR(data []byte) (*Suite, error) {
+	var h harFile
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("go-expect: parse har: %w", err)
+	}
+
+	var (
+		conns     importedConnections
+		scenarios []*Scenario
+	)
+	byPage := make(map[string]*Scenario)
+	scenario := func(pageref string) *Scenario {
+		if sc, ok := byPage[pageref]; ok {
+			return sc
+		}
+		name := "har"
+		for _, page := range h.Log.Pages {
+			if page.ID == pageref && pageref != "" {
+				name = page.Title
+			}
+		}
+		sc := NewScenario(name)
+		byPage[pageref] = sc
+		scenarios = append(scenarios, sc)
+		return sc
+	}
+	// Scenarios follow the order of the pages, then of entries without one.
+	for _, page := range h.Log.Pages {
+		scenario(page.ID)
+	}
+
+	for i, e := range h.Log.Entries {
+		step, err := e.step(&conns)
+		if err != nil {
+			return nil, fmt.Errorf("go-expect: har: entry [%d]: %w", i, err)
+		}
+		scenario(e.PageRef).AddStep(step)
+	}
+
+	var nonEmpty []*Scenario
+	for _, sc := range scenarios {
+		if len(sc.steps) > 0 {
+			nonEmpty = append(nonEmpty, sc)
+		}
+	}
+	return NewSuite().WithConnections(conns.conns...).WithScenarios(nonEmpty...), nil
+}
+
+type harFile struct {
+	Log struct {
+		Pages []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	PageRef string `json:"pageref"`
+	Request struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harRecord `json:"headers"`
+		PostData *struct {
+			MimeType string      `json:"mimeType"`
+			Text     string      `json:"text"`
+			Params   []harRecord `json:"params"`
+			Encoding string      `json:"encoding"` // "base64" for binary bodies, in some recorders
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status int `json:"status"`
+	} `json:"response"`
+}
+
+type harRecord struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (e harEntry) step(conns *importedConnections) (*StepBuilder, error) {
+	if e.Request.URL == "" {
+		return nil, errors.New("request has no url")
+	}
+	origin, host, path, query := splitURL(e.Request.URL)
+	method := strings.ToUpper(e.Request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	b := HTTPStep(method, path).WithConnection(conns.name(origin, host))
+	for _, q := range query {
+		b.WithQuery(q[0], q[1])
+	}
+	for _, h := range e.Request.Headers {
+		if importHeader(h.Name) {
+			b.WithHeader(http.CanonicalHeaderKey(h.Name), h.Value)
+		}
+	}
+
+	if pd := e.Request.PostData; pd != nil {
+		body := []byte(pd.Text)
+		if pd.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(pd.Text)
+			if err != nil {
+				return nil, fmt.Errorf("post data: %w", err)
+			}
+			body = decoded
+		}
+		if len(body) == 0 && len(pd.Params) > 0 {
+			pairs := make([]string, len(pd.Params))
+			for i, p := range pd.Params {
+				pairs[i] = escapeForm(p.Name) + "=" + escapeForm(p.Value)
+			}
+			body = []byte(strings.Join(pairs, "&"))
+		}
+		b.WithBody(body)
+		if _, ok := b.httpReq().Header["Content-Type"]; !ok && pd.MimeType != "" {
+			b.WithHeader("Content-Type", pd.MimeType)
+		}
+	}
+
+	if e.Response.Status > 0 {
+		b.ExpectStatus(e.Response.Status)
+	}
+	return b, nil
+}
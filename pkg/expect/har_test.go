@@ -0,0 +1,106 @@
+package expect
+
+import (
+	"strings"
+	"testing"
+)
+
+const harJSON = `{
+  "log": {
+    "version": "1.2",
+    "pages": [{"id": "page_1", "title": "Checkout"}],
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "http://shop.example.com/health", "headers": []},
+        "response": {"status": 200}
+      },
+      {
+        "pageref": "page_1",
+        "request": {
+          "method": "POST",
+          "url": "http://shop.example.com/login?next=%2Fcart#top",
+          "headers": [
+            {"name": ":authority", "value": "shop.example.com"},
+            {"name": "accept-encoding", "value": "gzip, br"},
+            {"name": "authorization", "value": "Bearer abc"}
+          ],
+          "postData": {"mimeType": "application/json", "text": "{\"user\":\"acme\"}"}
+        },
+        "response": {"status": 201}
+      },
+      {
+        "pageref": "page_1",
+        "request": {
+          "method": "POST",
+          "url": "http://shop.example.com/notes",
+          "headers": [{"name": "Authorization", "value": "Bearer abc"}],
+          "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "text", "value": "a b"}]}
+        },
+        "response": {"status": 200}
+      }
+    ]
+  }
+}`
+
+func TestLoadHAR(t *testing.T) {
+	suite, err := LoadHAR([]byte(harJSON))
+	if err != nil {
+		t.Fatalf("LoadHAR error: %v", err)
+	}
+	if got := scenarioNames(suite); got != "Checkout,har" {
+		t.Errorf("expected scenarios Checkout,har, got %s", got)
+	}
+
+	rec, srv := newRecorder(t)
+	if err := suite.WithConnectionURL("shop.example.com", srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Fatalf("imported suite failed: %v", err)
+	}
+	want := []string{
+		`POST /login?next=%2Fcart application/json Bearer abc {"user":"acme"}`,
+		`POST /notes application/x-www-form-urlencoded Bearer abc text=a+b`,
+		`GET /health   `,
+	}
+	if got := strings.Join(rec.reqs, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("expected requests:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+}
+
+func TestLoadHAR_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"invalid json", `[`, "parse har"},
+		{"missing url", `{"log": {"entries": [{"request": {"method": "GET"}}]}}`, "entry [0]: request has no url"},
+		{
+			"invalid base64",
+			`{"log": {"entries": [{"request": {"url": "http://h/", "postData": {"text": "!", "encoding": "base64"}}}]}}`,
+			"post data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadHAR([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestImportedConnections(t *testing.T) {
+	var c importedConnections
+	a := c.name("http://api.example.com", "api.example.com")
+	b := c.name("https://api.example.com", "api.example.com")
+	again := c.name("http://api.example.com", "other")
+	if a != "api.example.com" || b != "api.example.com-2" || again != a {
+		t.Errorf("unexpected names %q, %q, %q", a, b, again)
+	}
+	if len(c.conns) != 2 {
+		t.Errorf("expected 2 connections, got %d", len(c.conns))
+	}
+}
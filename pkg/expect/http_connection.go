@@ -11,6 +11,7 @@ type HTTPConnection struct {
 	URL     string
 	Timeout time.Duration // per-request timeout; 0 means use DefaultHTTPTimeout
 	Client  *http.Client  // nil means use http.DefaultClient
+	OpenAPI *OpenAPI      // if set, every request and response is checked against it
 }
 
 func (c *HTTPConnection) Type() string    { return "http" }
@@ -20,3 +21,17 @@ func (c *HTTPConnection) GetName() string { return c.Name }
 func HTTP(name, url string) *HTTPConnection {
 	return &HTTPConnection{Name: name, URL: url}
 }
+
+// WithOpenAPI checks every request on the connection, and its response, against
+// the operation they match in spec. Undocumented endpoints and responses fail.
+func (c *HTTPConnection) WithOpenAPI(spec *OpenAPI) *HTTPConnection {
+	c.OpenAPI = spec
+	return c
+}
+
+// withURL returns a copy of the connection pointed at url, keeping its settings.
+func (c *HTTPConnection) withURL(url string) Connection {
+	conn := *c
+	conn.URL = url
+	return &conn
+}
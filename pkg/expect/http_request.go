@@ -3,7 +3,13 @@ package expect
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,12 +27,30 @@ type HTTPRequest struct {
 	Timeout time.Duration // 0 means use DefaultHTTPTimeout
 }
 
+// Label describes the request as "METHOD path".
+func (r *HTTPRequest) Label() string {
+	return r.Method + " " + r.Path
+}
+
+// Do implements Requester; conn must be an *HTTPConnection.
+func (r *HTTPRequest) Do(ctx context.Context, conn Connection, vars VarStore) (any, error) {
+	httpConn, ok := conn.(*HTTPConnection)
+	if !ok {
+		return nil, mismatchedConnection("HTTP", conn)
+	}
+	return r.Run(ctx, httpConn, vars)
+}
+
 // Run executes the HTTP request against conn, interpolating variables from vars.
-func (r *HTTPRequest) Run(conn *HTTPConnection, vars VarStore) (*http.Response, error) {
-	path := vars.Interpolate(r.Path)
+// The request timeout is applied on top of ctx. The response body is read in
+// full and buffered, so callers need not close it. If conn has an OpenAPI
+// document, a request or response breaking it is a *ContractError.
+func (r *HTTPRequest) Run(ctx context.Context, conn *HTTPConnection, vars VarStore) (*http.Response, error) {
+	in := newInterpolator(ctx, vars)
+	path := in.interpolate(r.Path)
 	url := strings.TrimRight(conn.URL, "/") + "/" + strings.TrimLeft(path, "/")
 
-	body := vars.InterpolateBytes(r.Body)
+	body := in.interpolateJSON(r.Body)
 
 	timeout := r.Timeout
 	if timeout == 0 {
@@ -36,7 +60,7 @@ func (r *HTTPRequest) Run(conn *HTTPConnection, vars VarStore) (*http.Response,
 		timeout = DefaultHTTPTimeout
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, r.Method, url, bytes.NewReader(body))
@@ -46,12 +70,12 @@ func (r *HTTPRequest) Run(conn *HTTPConnection, vars VarStore) (*http.Response,
 
 	q := req.URL.Query()
 	for k, v := range r.Query {
-		q.Add(k, vars.Interpolate(v))
+		q.Add(k, in.interpolate(v))
 	}
 	req.URL.RawQuery = q.Encode()
 
 	for k, v := range r.Header {
-		req.Header.Set(k, vars.Interpolate(v))
+		req.Header.Set(k, in.interpolate(v))
 	}
 
 	client := conn.Client
@@ -59,5 +83,54 @@ func (r *HTTPRequest) Run(conn *HTTPConnection, vars VarStore) (*http.Response,
 		client = http.DefaultClient
 	}
 
-	return client.Do(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	recordCoverage(ctx, coverageHit{
+		conn: conn.Name, method: req.Method, target: req.URL.Path, status: strconv.Itoa(resp.StatusCode),
+	})
+
+	if conn.OpenAPI != nil {
+		if err := conn.OpenAPI.check(conn.URL, req, body, resp, data); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Script implements Scripter as a curl command.
+func (r *HTTPRequest) Script(conn Connection, vars VarStore) string {
+	return r.script(conn, interpolator{vars: vars})
+}
+
+func (r *HTTPRequest) script(conn Connection, in interpolator) string {
+	target := in.interpolate(r.Path)
+	if c, ok := conn.(*HTTPConnection); ok {
+		target = strings.TrimRight(c.URL, "/") + "/" + strings.TrimLeft(target, "/")
+	}
+	if u, err := url.Parse(target); err == nil && len(r.Query) > 0 {
+		q := u.Query()
+		for k, v := range r.Query {
+			q.Add(k, in.interpolate(v))
+		}
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+
+	parts := []string{"curl -sS -i -X " + r.Method + " " + shellQuote(target)}
+	for _, k := range slices.Sorted(maps.Keys(r.Header)) {
+		parts = append(parts, "-H "+shellQuote(k+": "+in.interpolate(r.Header[k])))
+	}
+	if len(r.Body) > 0 {
+		parts = append(parts, "--data-raw "+shellQuote(string(in.interpolateJSON(r.Body))))
+	}
+	return shellCommand(parts...)
 }
@@ -0,0 +1,408 @@
+package expect
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//nolint:gochecknoglobals // compiled once
+var (
+	httpFileVarDeclRe   = regexp.MustCompile(`^@([A-Za-z_][\w.-]*)\s*=\s*(.*)$`)
+	httpFileDirectiveRe = regexp.MustCompile(`^(?:#|//)\s*@([\w-]+)\s*(.*)$`)
+	httpFileRequestRe   = regexp.MustCompile(
+		`^(?:(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|TRACE|CONNECT)\s+)?(\S+)(?:\s+HTTP/[\d.]+)?$`)
+	httpFileHeaderRe = regexp.MustCompile(`^([\w!#$%&'*+.^|~-]+)\s*:\s*(.*)$`)
+	httpFileSaveRe   = regexp.MustCompile(`^(\S+)\s+as\s+(\S+)(\s+secret)?$`)
+	httpFileRefRe    = regexp.MustCompile(`^([\w-]+)\.response\.body\.(?:\$\.?)?(.+)$`)
+)
+
+// httpFileRequest is a request of a .http file, as written.
+type httpFileRequest struct {
+	line      int    // line of the request line, for errors
+	name      string // "# @name", for request variables
+	method    string
+	target    string
+	header    [][2]string
+	body      []string
+	expect    fileExpectation
+	expectSet bool
+
+	expectBody, expectSchema string // YAML, parsed once variables are expanded
+}
+
+// httpFileParser parses .http files, the REST Client and JetBrains HTTP
+// client format, into the YAML file model.
+type httpFileParser struct {
+	vars     map[string]string // @name = value declarations
+	saved    map[string]bool   // variables set by "# @save" or request variables
+	requests []*httpFileRequest
+	src      fileSource
+}
+
+// parseHTTPFile parses a .http file into one scenario, named after the file,
+// with a step per request. Requests are separated by lines starting with ###.
+// Directive comments add what the format lacks:
+//
+//	# @expect status 201
+//	# @expect header Content-Type application/json
+//	# @expect body {"name": "rex"}
+//	# @expect schema ./schemas/pet.json
+//	# @expect assert len(body.tags) > 0
+//	# @save id as pet_id
+//
+// @name = value declarations are inlined wherever {{name}} appears; other
+// {{name}}s become {name} placeholders, so saved and runtime variables fill
+// them. Request variables, such as {{login.response.body.$.token}} after a
+// request marked # @name login, save the field from that request's response.
+func parseHTTPFile(data []byte, name string, src fileSource) (expectFile, error) {
+	p := &httpFileParser{vars: make(map[string]string), saved: make(map[string]bool), src: src}
+	if err := p.parse(string(data)); err != nil {
+		return expectFile{}, fmt.Errorf("go-expect: parse http file %q: %w", name, err)
+	}
+	f, err := p.build(strings.TrimSuffix(path.Base(name), path.Ext(name)))
+	if err != nil {
+		return expectFile{}, fmt.Errorf("go-expect: parse http file %q: %w", name, err)
+	}
+	f.src = src
+	return f, nil
+}
+
+const (
+	httpFilePre    = iota // comments and variables before the request line
+	httpFileHeader        // header lines
+	httpFileBody          // body lines, up to the next ###
+	httpFileScript        // a > {% response handler %} script, which is skipped
+)
+
+func (p *httpFileParser) parse(data string) error {
+	var (
+		req   = &httpFileRequest{}
+		state = httpFilePre
+	)
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "###"):
+			p.add(req)
+			req, state = &httpFileRequest{}, httpFilePre
+			continue
+		case state == httpFileScript:
+			if strings.Contains(trimmed, "%}") {
+				state = httpFileBody
+			}
+			continue
+		case strings.HasPrefix(trimmed, "> {%"):
+			if !strings.Contains(trimmed, "%}") {
+				state = httpFileScript
+			}
+			continue
+		case strings.HasPrefix(trimmed, ">>"):
+			continue
+		}
+		if m := httpFileDirectiveRe.FindStringSubmatch(trimmed); m != nil {
+			if err := p.directive(req, m[1], strings.TrimSpace(m[2])); err != nil {
+				return fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		var err error
+		state, err = p.line(req, state, line, trimmed)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if state == httpFileHeader && req.line == 0 {
+			req.line = i + 1
+		}
+	}
+	p.add(req)
+	return nil
+}
+
+// line handles a line that is not a separator, script or directive, and
+// returns the parser's next state.
+func (p *httpFileParser) line(req *httpFileRequest, state int, line, trimmed string) (int, error) {
+	isComment := strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//")
+	switch state {
+	case httpFilePre:
+		if trimmed == "" || isComment {
+			return state, nil
+		}
+		if m := httpFileVarDeclRe.FindStringSubmatch(trimmed); m != nil {
+			p.vars[m[1]] = strings.TrimSpace(m[2])
+			return state, nil
+		}
+		m := httpFileRequestRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			return state, fmt.Errorf("invalid request line %q", trimmed)
+		}
+		req.method, req.target = m[1], m[2]
+		if req.method == "" {
+			req.method = http.MethodGet
+		}
+		return httpFileHeader, nil
+	case httpFileHeader:
+		switch {
+		case trimmed == "":
+			return httpFileBody, nil
+		case isComment:
+			return state, nil
+		case len(req.header) == 0 && (strings.HasPrefix(trimmed, "?") || strings.HasPrefix(trimmed, "&")):
+			req.target += trimmed // query parameters continued over lines
+			return state, nil
+		}
+		m := httpFileHeaderRe.FindStringSubmatch(trimmed)
+		if m == nil {
+			return state, fmt.Errorf("invalid header %q", trimmed)
+		}
+		req.header = append(req.header, [2]string{m[1], strings.TrimSpace(m[2])})
+		return state, nil
+	default:
+		req.body = append(req.body, line)
+		return state, nil
+	}
+}
+
+// directive applies a # @directive comment to req.
+func (p *httpFileParser) directive(req *httpFileRequest, name, arg string) error {
+	switch name {
+	case "name":
+		req.name = arg
+	case "save":
+		m := httpFileSaveRe.FindStringSubmatch(arg)
+		if m == nil {
+			return fmt.Errorf("@save: expected \"<field> as <variable>\", got %q", arg)
+		}
+		req.expect.Save = append(req.expect.Save, fileSaveEntry{Field: m[1], As: m[2], Secret: m[3] != ""})
+		req.expectSet, p.saved[m[2]] = true, true
+	case "expect":
+		kind, value, _ := strings.Cut(arg, " ")
+		value = strings.TrimSpace(value)
+		req.expectSet = true
+		switch kind {
+		case "status":
+			status, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("@expect status: invalid status %q", value)
+			}
+			req.expect.Status = status
+		case "header":
+			k, v, _ := strings.Cut(value, " ")
+			if req.expect.Header == nil {
+				req.expect.Header = make(map[string]string)
+			}
+			req.expect.Header[k] = strings.TrimSpace(v)
+		case "body":
+			req.expectBody = value
+		case "schema":
+			req.expectSchema = value
+		case "assert":
+			req.expect.Assert = append(req.expect.Assert, value)
+		default:
+			return fmt.Errorf("unknown @expect %q; use status, header, body, schema or assert", kind)
+		}
+	}
+	// Other directives, such as @no-redirect, configure the editor's client.
+	return nil
+}
+
+// add records req, unless the block held no request.
+func (p *httpFileParser) add(req *httpFileRequest) {
+	if req.target != "" {
+		p.requests = append(p.requests, req)
+	}
+}
+
+func (p *httpFileParser) build(scenario string) (expectFile, error) {
+	var conns importedConnections
+	steps := make([]fileStep, len(p.requests))
+	for i, req := range p.requests {
+		step, err := p.step(i, req, &conns)
+		if err != nil {
+			return expectFile{}, fmt.Errorf("line %d: %w", req.line, err)
+		}
+		steps[i] = step
+	}
+	// Request variables add saves to earlier steps, so expectations are set last.
+	for i, req := range p.requests {
+		if req.expectSet {
+			steps[i].Expect = &req.expect
+		}
+	}
+
+	f := expectFile{Scenarios: []fileScenario{{Name: scenario, Steps: steps}}}
+	for _, c := range conns.conns {
+		hc, _ := c.(*HTTPConnection)
+		f.Connections = append(f.Connections, fileConnection{Name: hc.Name, Type: "http", URL: hc.URL})
+	}
+	return f, nil
+}
+
+func (p *httpFileParser) step(i int, req *httpFileRequest, conns *importedConnections) (fileStep, error) {
+	r := &fileRequest{Method: req.method}
+	var host string
+	for _, h := range req.header {
+		if strings.EqualFold(h[0], "Host") {
+			host = p.expand(i, h[1])
+		}
+		if importHeader(h[0]) {
+			r.Header = setString(r.Header, http.CanonicalHeaderKey(h[0]), p.expand(i, h[1]))
+		}
+	}
+
+	var query [][2]string
+	if loc := postmanVarRe.FindStringSubmatchIndex(req.target); loc != nil && loc[0] == 0 && p.isConnVar(i, req) {
+		// A leading {{variable}}, such as {{host}}, names the connection.
+		name := req.target[loc[2]:loc[3]]
+		origin := "http://localhost"
+		if v, ok := p.vars[name]; ok {
+			origin = strings.TrimRight(p.expand(i, v), "/")
+		}
+		r.Endpoint, query = splitPath(p.expand(i, req.target[loc[1]:]))
+		r.Connection = conns.name(origin, name)
+	} else {
+		target := p.expand(i, req.target)
+		if strings.HasPrefix(target, "/") && host != "" {
+			target = host + target
+		}
+		if strings.HasPrefix(target, "/") {
+			r.Endpoint, query = splitPath(target) // the default connection
+		} else {
+			var origin string
+			origin, host, r.Endpoint, query = splitURL(target)
+			r.Connection = conns.name(origin, host)
+		}
+	}
+	for _, q := range query {
+		r.Query = setString(r.Query, q[0], q[1])
+	}
+
+	body, err := p.body(i, req.body)
+	if err != nil {
+		return fileStep{}, err
+	}
+	r.rawBody = body
+	if err := p.expectations(i, req); err != nil {
+		return fileStep{}, err
+	}
+	return fileStep{Request: r, raw: rawFileStep{Request: map[string]any{
+		"connection": r.Connection, "method": r.Method, "endpoint": r.Endpoint, "body": string(body),
+	}}}, nil
+}
+
+// expectations expands the variables of the request's expected header and
+// body values.
+func (p *httpFileParser) expectations(i int, req *httpFileRequest) error {
+	for k, v := range req.expect.Header {
+		req.expect.Header[k] = p.expand(i, v)
+	}
+	for _, e := range []struct {
+		kind string
+		src  string
+		dst  *any
+	}{{"body", req.expectBody, &req.expect.Body}, {"schema", req.expectSchema, &req.expect.Schema}} {
+		if e.src == "" {
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(p.expand(i, e.src)), e.dst); err != nil {
+			return fmt.Errorf("@expect %s: %w", e.kind, err)
+		}
+	}
+	return nil
+}
+
+// isConnVar reports whether the request's target starts with a variable that
+// holds a base URL, or is not declared, rather than a path or a request variable.
+func (p *httpFileParser) isConnVar(i int, req *httpFileRequest) bool {
+	name := postmanVarRe.FindStringSubmatch(req.target)[1]
+	if v, ok := p.vars[name]; ok {
+		return strings.Contains(p.expand(i, v), "://")
+	}
+	return !strings.HasPrefix(name, "$") && !p.saved[name] && !httpFileRefRe.MatchString(name)
+}
+
+// body joins the body lines without trailing blank lines. A body of just
+// "< path" is read from that file, relative to the .http file, and "<@ path"
+// also expands its variables.
+func (p *httpFileParser) body(i int, lines []string) ([]byte, error) {
+	text := strings.TrimRight(strings.Join(lines, "\n"), " \t\n")
+	if text == "" {
+		return nil, nil
+	}
+	file, expand := strings.CutPrefix(text, "<@")
+	if !expand {
+		file, _ = strings.CutPrefix(text, "<")
+	}
+	if file == text || strings.Contains(file, "\n") {
+		return []byte(p.expand(i, text)), nil
+	}
+	name := p.src.join("", strings.TrimSpace(file))
+	data, err := p.src.readFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if expand {
+		return []byte(p.expand(i, string(data))), nil
+	}
+	return data, nil
+}
+
+// expand rewrites the {{name}} references of the request at index i.
+func (p *httpFileParser) expand(i int, s string) string {
+	return p.expandDepth(i, s, 0)
+}
+
+// maxHTTPFileVarDepth stops expanding variables that refer to each other.
+const maxHTTPFileVarDepth = 10
+
+func (p *httpFileParser) expandDepth(i int, s string, depth int) string {
+	return postmanVarRe.ReplaceAllStringFunc(s, func(m string) string {
+		name := postmanVarRe.FindStringSubmatch(m)[1]
+		if v, ok := p.vars[name]; ok && !p.saved[name] && depth < maxHTTPFileVarDepth {
+			return p.expandDepth(i, v, depth+1)
+		}
+		if ref := p.requestVar(i, name); ref != "" {
+			return "{" + ref + "}"
+		}
+		return "{" + name + "}"
+	})
+}
+
+// requestVar maps a request variable reference, such as
+// login.response.body.$.token, onto a save of the field by the earlier request
+// named login, and returns the variable it saves to; "" if name is not one.
+func (p *httpFileParser) requestVar(i int, name string) string {
+	m := httpFileRefRe.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	for j := i - 1; j >= 0; j-- {
+		req := p.requests[j]
+		if req.name != m[1] {
+			continue
+		}
+		field := jsonPathToGJSON(m[2])
+		for _, sv := range req.expect.Save {
+			if sv.As == name {
+				return name
+			}
+		}
+		req.expect.Save = append(req.expect.Save, fileSaveEntry{Field: field, As: name})
+		req.expectSet = true
+		return name
+	}
+	return ""
+}
+
+// jsonPathToGJSON converts a simple JSONPath, such as data.items[0].id, to a
+// gjson path, such as data.items.0.id.
+func jsonPathToGJSON(p string) string {
+	p = strings.NewReplacer("[", ".", "]", "", "'", "", `"`, "").Replace(p)
+	return strings.Trim(strings.ReplaceAll(p, "..", "."), ".")
+}
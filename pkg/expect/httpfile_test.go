@@ -0,0 +1,145 @@
+package expect
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const shopHTTPFile = `@host = http://shop.example.com
+@tenant = acme
+
+### Log in
+# @name login
+# @expect status 201
+# @expect header Content-Type application/json
+# @expect body {"data": {"token": "abc"}}
+# @save data.token as token
+POST {{host}}/login HTTP/1.1
+Content-Type: application/json
+Host: shop.example.com
+
+{"user": "{{tenant}}"}
+
+> {%
+  client.global.set("token", response.body.data.token);
+%}
+
+### Orders
+GET {{host}}/orders
+    ?page=2
+    &size=10
+Authorization: Bearer {{login.response.body.$.data.token}}
+
+###
+// @expect status 200
+POST {{host}}/notes
+Authorization: Bearer {{token}}
+Content-Type: application/x-www-form-urlencoded
+
+text=hello
+`
+
+func TestLoadFS_httpFile(t *testing.T) {
+	suite, err := LoadFS(fstest.MapFS{
+		"shop.http":   {Data: []byte(shopHTTPFile)},
+		"README.md":   {Data: []byte("not an expectation file")},
+		"other.jsonc": {Data: []byte("{")},
+	})
+	if err != nil {
+		t.Fatalf("LoadFS error: %v", err)
+	}
+	if got := scenarioNames(suite); got != "shop" {
+		t.Errorf("expected a scenario named after the file, got %s", got)
+	}
+
+	rec, srv := newRecorder(t)
+	if err := suite.WithConnectionURL("host", srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Fatalf("suite failed: %v\n%s", err, strings.Join(rec.reqs, "\n"))
+	}
+	want := []string{
+		`POST /login application/json  {"user": "acme"}`,
+		`GET /orders?page=2&size=10  Bearer abc `,
+		`POST /notes application/x-www-form-urlencoded Bearer abc text=hello`,
+	}
+	if got := strings.Join(rec.reqs, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("expected requests:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+}
+
+func TestParseHTTPFile(t *testing.T) {
+	f, err := parseHTTPFile([]byte(`
+GET https://api.example.com:8443/users/{{$uuid}}?q=a%20b
+
+### no method, Host header
+/health
+Host: localhost:8080
+Accept-Encoding: gzip
+
+###
+@base = /v1
+# @expect schema {type: object}
+# @expect assert status == 204
+# @save session as session secret
+DELETE {{base}}/pets/{{pet_id}}
+`), "dir/pets.http", fileSource{})
+	if err != nil {
+		t.Fatalf("parseHTTPFile error: %v", err)
+	}
+	if len(f.Connections) != 2 || f.Connections[0].URL != "https://api.example.com:8443" ||
+		f.Connections[1].Name != "localhost:8080" {
+		t.Errorf("unexpected connections %+v", f.Connections)
+	}
+	steps := f.Scenarios[0].Steps
+	tests := []struct {
+		got, want string
+	}{
+		{f.Scenarios[0].Name, "pets"},
+		{steps[0].Request.Connection, "api.example.com:8443"},
+		{steps[0].Request.Endpoint, "/users/{$uuid}"},
+		{steps[0].Request.Query["q"], "a b"},
+		{steps[1].Request.Method, "GET"},
+		{steps[1].Request.Endpoint, "/health"},
+		{fmt.Sprint(steps[1].Request.Header), "map[]"},
+		{steps[2].Request.Connection, ""},
+		{steps[2].Request.Endpoint, "/v1/pets/{pet_id}"},
+		{fmt.Sprint(steps[2].Expect.Assert), "[status == 204]"},
+		{fmt.Sprint(steps[2].Expect.Save), "[{session session <nil> true}]"},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("[%d] expected %q, got %q", i, tt.want, tt.got)
+		}
+	}
+	if steps[2].Expect == nil || steps[2].Expect.Schema == nil {
+		t.Errorf("expected a schema expectation, got %+v", steps[2].Expect)
+	}
+}
+
+func TestParseHTTPFile_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"request line", "GET /a b c", `line 1: invalid request line "GET /a b c"`},
+		{"header", "GET /a\nnot a header", `line 2: invalid header "not a header"`},
+		{"status", "# @expect status ok\nGET /a", `line 1: @expect status: invalid status "ok"`},
+		{"expect kind", "# @expect cookie a\nGET /a", `line 1: unknown @expect "cookie"`},
+		{"save", "GET /a\n# @save id", `line 2: @save: expected "<field> as <variable>"`},
+		{"body", "# @expect body {a: [\nGET /a", `line 2: @expect body`},
+		{"body file", "POST /a\n\n< ./missing.json", `line 1: read body`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseHTTPFile([]byte(tt.data), "a.http", fileSource{dir: t.TempDir()})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
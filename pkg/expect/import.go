@@ -0,0 +1,91 @@
+package expect
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// importedConnections names one HTTP connection per origin of the requests of
+// a Postman collection or HAR file.
+type importedConnections struct {
+	byOrigin map[string]string // origin -> connection name
+	taken    map[string]bool
+	conns    []Connection
+}
+
+// name returns the connection for origin, creating it under preferred, or
+// preferred with a numeric suffix when another origin already took it.
+func (c *importedConnections) name(origin, preferred string) string {
+	if name, ok := c.byOrigin[origin]; ok {
+		return name
+	}
+	if c.byOrigin == nil {
+		c.byOrigin, c.taken = make(map[string]string), make(map[string]bool)
+	}
+	name := preferred
+	for i := 2; c.taken[name]; i++ {
+		name = preferred + "-" + strconv.Itoa(i)
+	}
+	c.byOrigin[origin], c.taken[name] = name, true
+	c.conns = append(c.conns, HTTP(name, origin))
+	return name
+}
+
+// splitURL splits an absolute URL into its origin, e.g. "https://host:8080",
+// its host, its path and its query parameters. A URL without a scheme is
+// taken to be http.
+func splitURL(raw string) (origin, host, path string, query [][2]string) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	scheme, rest, _ := strings.Cut(raw, "://")
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	host = rest[:end]
+	path, query = splitPath(rest[end:])
+	return scheme + "://" + host, host, path, query
+}
+
+// splitPath splits "/path?query#fragment" into its path, "/" when empty, and
+// its query parameters in order.
+func splitPath(s string) (string, [][2]string) {
+	s, _, _ = strings.Cut(s, "#")
+	path, rawQuery, _ := strings.Cut(s, "?")
+	if path == "" {
+		path = "/"
+	}
+	var query [][2]string
+	for pair := range strings.SplitSeq(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		query = append(query, [2]string{queryUnescape(k), queryUnescape(v)})
+	}
+	return path, query
+}
+
+// queryUnescape decodes a query component, keeping it as is when malformed.
+func queryUnescape(s string) string {
+	if u, err := url.QueryUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// hopHeaders are request headers a recording carries that the HTTP client sets
+// itself; setting Accept-Encoding would also stop it decompressing responses.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var hopHeaders = map[string]bool{
+	"host": true, "content-length": true, "connection": true, "accept-encoding": true,
+	"keep-alive": true, "transfer-encoding": true, "upgrade": true, "te": true,
+}
+
+// importHeader reports whether a recorded request header should be replayed.
+func importHeader(name string) bool {
+	return !strings.HasPrefix(name, ":") && !hopHeaders[strings.ToLower(name)]
+}
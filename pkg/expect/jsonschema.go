@@ -0,0 +1,773 @@
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/netip"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON Schema support covers the validation keywords of drafts 7 to 2020-12,
+// except unevaluatedProperties, unevaluatedItems and $dynamicRef. $ref may point
+// within a document or to other schema files, resolved relative to the file
+// that refers to them rather than to $id; remote URLs and anchors are not
+// supported. Formats are checked for date-time, date, time, email, uri, uuid,
+// ipv4, ipv6 and hostname, and ignored otherwise.
+
+// JSONSchema asserts the value conforms to a JSON Schema. schemaBytesOrPath is
+// the schema document as []byte, the path of a JSON or YAML schema file as a
+// string, optionally with a JSON pointer fragment such as "defs.json#/$defs/user",
+// or a decoded schema such as a map[string]any. A schema that cannot be
+// loaded fails every match.
+func JSONSchema(schemaBytesOrPath any) Matcher {
+	m, err := newJSONSchema(schemaBytesOrPath, fileSource{})
+	if err != nil {
+		return schemaMatcher{err: err}
+	}
+	return m
+}
+
+// schemaMatcher holds a self-contained schema: documents it refers to are
+// bundled under $defs, so it survives marshalling.
+type schemaMatcher struct {
+	schema any
+	err    error
+}
+
+func newJSONSchema(schemaBytesOrPath any, src fileSource) (schemaMatcher, error) {
+	b := &schemaBundler{src: src, defs: map[string]any{}}
+	var (
+		root any
+		err  error
+	)
+	switch v := schemaBytesOrPath.(type) {
+	case string:
+		if strings.Contains(v, "#") {
+			root = map[string]any{"$ref": v} // a schema within the file, e.g. "defs.json#/$defs/user"
+			break
+		}
+		b.root = src.join("", v)
+		root, err = b.read(b.root)
+	case []byte:
+		root, err = decodeSchema(v, ".json")
+	case json.RawMessage:
+		root, err = decodeSchema(v, ".json")
+	case map[string]any, bool:
+		root, err = normalizeJSON(v)
+	default:
+		err = fmt.Errorf("expected schema bytes, path or document, got %T", schemaBytesOrPath)
+	}
+	if err != nil {
+		return schemaMatcher{}, fmt.Errorf("json schema: %w", err)
+	}
+	schema, err := b.bundle(root)
+	if err != nil {
+		return schemaMatcher{}, fmt.Errorf("json schema: %w", err)
+	}
+	return schemaMatcher{schema: schema}, nil
+}
+
+func (m schemaMatcher) Match(actual any) error {
+	if m.err != nil {
+		return m.err
+	}
+	v := &schemaValidator{root: m.schema, patterns: map[string]*regexp.Regexp{}}
+	v.validate(m.schema, actual, "")
+	if len(v.violations) > 0 {
+		return v.violations
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$json_schema": schema}.
+func (m schemaMatcher) MarshalJSON() ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return operatorJSON("json_schema", m.schema)
+}
+
+// decodeSchema parses a JSON or YAML schema document.
+func decodeSchema(data []byte, ext string) (any, error) {
+	var doc any
+	if ext == ".yaml" || ext == ".yml" {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return normalizeJSON(stringKeys(doc))
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// stringKeys converts the map[any]any YAML decodes for mappings with
+// non-string keys, such as OpenAPI response codes, to map[string]any.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return out
+	case map[string]any:
+		for k, e := range v {
+			v[k] = stringKeys(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = stringKeys(e)
+		}
+	}
+	return v
+}
+
+// normalizeJSON round-trips v through JSON so numbers are float64, as in
+// decoded bodies.
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// ---- Bundling ----
+
+// schemaBundler rewrites $refs to other files as refs into the root's $defs,
+// where it copies the referenced documents.
+type schemaBundler struct {
+	src  fileSource
+	root string         // file name of the root document; "" when inline
+	defs map[string]any // file name -> rewritten document
+}
+
+func (b *schemaBundler) bundle(root any) (any, error) {
+	out, err := b.rewrite(root, b.root)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.defs) > 0 {
+		obj, ok := out.(map[string]any)
+		if !ok {
+			return nil, errors.New("root schema must be an object to refer to other files")
+		}
+		defs, _ := obj["$defs"].(map[string]any)
+		merged := make(map[string]any, len(defs)+len(b.defs))
+		maps.Copy(merged, defs)
+		maps.Copy(merged, b.defs)
+		obj["$defs"] = merged
+	}
+	if err := checkRefs(out, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (b *schemaBundler) read(name string) (any, error) {
+	data, err := b.src.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeSchema(data, path.Ext(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return doc, nil
+}
+
+// Keywords whose values are not schemas, and keywords whose values map names
+// to schemas; a property may be called "enum" or "$ref".
+//
+//nolint:gochecknoglobals // read-only lookup tables
+var (
+	schemaValueKeywords = []string{"enum", "const", "default", "examples"}
+	schemaMapKeywords   = []string{
+		"properties", "patternProperties", "$defs", "definitions", "dependentSchemas", "dependencies",
+	}
+)
+
+// rewrite copies schema, from the document named from, with its $refs rewritten.
+func (b *schemaBundler) rewrite(schema any, from string) (any, error) {
+	switch v := schema.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			var err error
+			switch {
+			case k == "$ref":
+				ref, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("$ref: expected string, got %s", jsonType(e))
+				}
+				out[k], err = b.ref(from, ref)
+			case slices.Contains(schemaValueKeywords, k):
+				out[k] = e
+			case slices.Contains(schemaMapKeywords, k):
+				out[k], err = b.rewriteEach(e, from)
+			default:
+				out[k], err = b.rewrite(e, from)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			rewritten, err := b.rewrite(e, from)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rewritten
+		}
+		return out, nil
+	default:
+		return schema, nil
+	}
+}
+
+// rewriteEach rewrites every schema in a map of names to schemas.
+func (b *schemaBundler) rewriteEach(v any, from string) (any, error) {
+	named, ok := v.(map[string]any)
+	if !ok {
+		return b.rewrite(v, from)
+	}
+	out := make(map[string]any, len(named))
+	for name, schema := range named {
+		rewritten, err := b.rewrite(schema, from)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = rewritten
+	}
+	return out, nil
+}
+
+// ref rewrites a $ref found in the document named from.
+func (b *schemaBundler) ref(from, ref string) (string, error) {
+	file, fragment, _ := strings.Cut(ref, "#")
+	if u, err := url.Parse(file); err != nil || u.Scheme != "" {
+		return "", fmt.Errorf("$ref %q: only local files are supported", ref)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		return "", fmt.Errorf("$ref %q: only JSON pointer fragments are supported", ref)
+	}
+	target := from
+	if file != "" {
+		target = b.src.join(from, file)
+	}
+	if target == b.root {
+		return "#" + fragment, nil
+	}
+	if err := b.load(target); err != nil {
+		return "", fmt.Errorf("$ref %q: %w", ref, err)
+	}
+	return "#/$defs/" + escapePointer(target) + fragment, nil
+}
+
+// load bundles the document named name under $defs, keyed by its name.
+func (b *schemaBundler) load(name string) error {
+	if _, ok := b.defs[name]; ok {
+		return nil
+	}
+	b.defs[name] = nil // before rewriting, for cyclic refs
+	doc, err := b.read(name)
+	if err != nil {
+		return err
+	}
+	b.defs[name], err = b.rewrite(doc, name)
+	return err
+}
+
+// checkRefs reports the first $ref in schema that does not resolve in root.
+func checkRefs(root, schema any) error {
+	switch v := schema.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			switch {
+			case k == "$ref":
+				if _, ok := resolvePointer(root, v[k].(string)); !ok {
+					return fmt.Errorf("$ref %q does not resolve", v[k])
+				}
+			case slices.Contains(schemaValueKeywords, k):
+			case slices.Contains(schemaMapKeywords, k):
+				named, _ := v[k].(map[string]any)
+				for _, name := range slices.Sorted(maps.Keys(named)) {
+					if err := checkRefs(root, named[name]); err != nil {
+						return err
+					}
+				}
+			default:
+				if err := checkRefs(root, v[k]); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if err := checkRefs(root, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePointer resolves a "#/a/b" reference within root.
+func resolvePointer(root any, ref string) (any, bool) {
+	ptr, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, false
+	}
+	cur := root
+	if ptr == "" {
+		return cur, true
+	}
+	for _, tok := range strings.Split(ptr, "/")[1:] {
+		tok = unescapePointer(tok)
+		switch v := cur.(type) {
+		case map[string]any:
+			if cur, ok = v[tok]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+//nolint:gochecknoglobals // stateless replacers
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func escapePointer(s string) string   { return pointerEscaper.Replace(s) }
+func unescapePointer(s string) string { return pointerUnescaper.Replace(s) }
+
+// ---- Validation ----
+
+// maxSchemaDepth bounds $ref recursion.
+const maxSchemaDepth = 64
+
+type schemaViolation struct {
+	pointer string // JSON pointer into the validated value
+	message string
+}
+
+// schemaViolations is the error of a failed JSON Schema match.
+type schemaViolations []schemaViolation
+
+func (e schemaViolations) Error() string {
+	var b strings.Builder
+	if len(e) == 1 {
+		b.WriteString("does not conform to JSON schema (1 violation):")
+	} else {
+		fmt.Fprintf(&b, "does not conform to JSON schema (%d violations):", len(e))
+	}
+	for _, v := range e {
+		fmt.Fprintf(&b, "\n  #%s: %s", v.pointer, v.message)
+	}
+	return b.String()
+}
+
+type schemaValidator struct {
+	root       any
+	violations schemaViolations
+	patterns   map[string]*regexp.Regexp
+	depth      int
+}
+
+func (v *schemaValidator) fail(ptr, format string, args ...any) {
+	v.violations = append(v.violations, schemaViolation{pointer: ptr, message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether inst conforms to schema, without recording violations.
+func (v *schemaValidator) valid(schema, inst any, ptr string) bool {
+	sub := &schemaValidator{root: v.root, patterns: v.patterns, depth: v.depth}
+	sub.validate(schema, inst, ptr)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(schema, inst any, ptr string) {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		if schema == false {
+			v.fail(ptr, "not allowed by schema")
+		}
+		return
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		v.validateRef(ref, inst, ptr)
+	}
+	v.validateValue(s, inst, ptr)
+	v.validateNumber(s, inst, ptr)
+	v.validateString(s, inst, ptr)
+	v.validateArray(s, inst, ptr)
+	v.validateObject(s, inst, ptr)
+	v.validateApplicators(s, inst, ptr)
+}
+
+func (v *schemaValidator) validateRef(ref string, inst any, ptr string) {
+	target, ok := resolvePointer(v.root, ref)
+	switch {
+	case !ok:
+		v.fail(ptr, "$ref %q does not resolve", ref)
+	case v.depth >= maxSchemaDepth:
+		v.fail(ptr, "$ref %q nests too deeply", ref)
+	default:
+		v.depth++
+		v.validate(target, inst, ptr)
+		v.depth--
+	}
+}
+
+// validateValue checks type, enum and const. OpenAPI 3.0's nullable admits
+// null regardless.
+func (v *schemaValidator) validateValue(s map[string]any, inst any, ptr string) {
+	if inst == nil && s["nullable"] == true {
+		return
+	}
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, e := range t {
+			if name, ok := e.(string); ok {
+				types = append(types, name)
+			}
+		}
+	}
+	if len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasSchemaType(t, inst) }) {
+		v.fail(ptr, "expected %s, got %s", strings.Join(types, " or "), jsonType(inst))
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(e any) bool { return reflect.DeepEqual(e, inst) }) {
+			v.fail(ptr, "expected one of %s, got %s", formatValue(enum), formatValue(inst))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, inst) {
+		v.fail(ptr, "expected %s, got %s", formatValue(c), formatValue(inst))
+	}
+}
+
+func hasSchemaType(t string, inst any) bool {
+	if t == "integer" {
+		f, ok := toFloat(inst)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+	return jsonType(inst) == t
+}
+
+func (v *schemaValidator) validateNumber(s map[string]any, inst any, ptr string) {
+	n, ok := toFloat(inst)
+	if !ok {
+		return
+	}
+	if m, ok := toFloat(s["multipleOf"]); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(ptr, "expected a multiple of %v, got %v", m, n)
+		}
+	}
+	// Draft 4 spelled exclusive bounds as booleans beside minimum and maximum.
+	exclusiveMin, exclusiveMax := s["exclusiveMinimum"] == true, s["exclusiveMaximum"] == true
+	if lim, ok := toFloat(s["minimum"]); ok {
+		if exclusiveMin && !(n > lim) {
+			v.fail(ptr, "expected > %v, got %v", lim, n)
+		} else if n < lim {
+			v.fail(ptr, "expected >= %v, got %v", lim, n)
+		}
+	}
+	if lim, ok := toFloat(s["maximum"]); ok {
+		if exclusiveMax && !(n < lim) {
+			v.fail(ptr, "expected < %v, got %v", lim, n)
+		} else if n > lim {
+			v.fail(ptr, "expected <= %v, got %v", lim, n)
+		}
+	}
+	if lim, ok := toFloat(s["exclusiveMinimum"]); ok && !(n > lim) {
+		v.fail(ptr, "expected > %v, got %v", lim, n)
+	}
+	if lim, ok := toFloat(s["exclusiveMaximum"]); ok && !(n < lim) {
+		v.fail(ptr, "expected < %v, got %v", lim, n)
+	}
+}
+
+func (v *schemaValidator) validateString(s map[string]any, inst any, ptr string) {
+	str, ok := inst.(string)
+	if !ok {
+		return
+	}
+	length := len([]rune(str))
+	if lim, ok := toFloat(s["minLength"]); ok && float64(length) < lim {
+		v.fail(ptr, "expected at least %v characters, got %d", lim, length)
+	}
+	if lim, ok := toFloat(s["maxLength"]); ok && float64(length) > lim {
+		v.fail(ptr, "expected at most %v characters, got %d", lim, length)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := v.pattern(pattern)
+		switch {
+		case err != nil:
+			v.fail(ptr, "invalid pattern %q: %v", pattern, err)
+		case !re.MatchString(str):
+			v.fail(ptr, "%q does not match pattern %q", str, pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok {
+		if err := checkFormat(format, str); err != nil {
+			v.fail(ptr, "%v", err)
+		}
+	}
+}
+
+func (v *schemaValidator) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err == nil {
+		v.patterns[pattern] = re
+	}
+	return re, err
+}
+
+//nolint:gochecknoglobals // compiled once
+var hostnameRe = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// checkFormat checks the formats it knows; others are annotations only.
+func checkFormat(format, s string) error {
+	switch format {
+	case "date-time":
+		return RFC3339.Match(s)
+	case "date":
+		return TimeFormat(time.DateOnly).Match(s)
+	case "time":
+		return TimeFormat("15:04:05Z07:00").Match(s)
+	case "email":
+		return Email{}.Match(s)
+	case "uuid":
+		return UUID(0).Match(s)
+	case "uri":
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" {
+			return fmt.Errorf("%q is not a URI", s)
+		}
+	case "ipv4", "ipv6":
+		if addr, err := netip.ParseAddr(s); err != nil || addr.Is4() != (format == "ipv4") {
+			return fmt.Errorf("%q is not an %s address", s, format)
+		}
+	case "hostname":
+		if len(s) > 253 || !hostnameRe.MatchString(s) {
+			return fmt.Errorf("%q is not a hostname", s)
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateArray(s map[string]any, inst any, ptr string) {
+	arr, ok := inst.([]any)
+	if !ok {
+		return
+	}
+	if lim, ok := toFloat(s["minItems"]); ok && float64(len(arr)) < lim {
+		v.fail(ptr, "expected at least %v elements, got %d", lim, len(arr))
+	}
+	if lim, ok := toFloat(s["maxItems"]); ok && float64(len(arr)) > lim {
+		v.fail(ptr, "expected at most %v elements, got %d", lim, len(arr))
+	}
+	if s["uniqueItems"] == true {
+		v.validateUnique(arr, ptr)
+	}
+
+	// prefixItems (or a draft 7 items array) match by position; items (or
+	// additionalItems) match the rest.
+	prefix, _ := s["prefixItems"].([]any)
+	rest, hasRest := s["items"]
+	if tuple, ok := rest.([]any); ok {
+		prefix = tuple
+		rest, hasRest = s["additionalItems"]
+	}
+	for i, elem := range arr {
+		elemPtr := ptr + "/" + fmt.Sprint(i)
+		switch {
+		case i < len(prefix):
+			v.validate(prefix[i], elem, elemPtr)
+		case hasRest:
+			v.validate(rest, elem, elemPtr)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		n := 0
+		for i, elem := range arr {
+			if v.valid(contains, elem, ptr+"/"+fmt.Sprint(i)) {
+				n++
+			}
+		}
+		minContains := 1.0
+		if lim, ok := toFloat(s["minContains"]); ok {
+			minContains = lim
+		}
+		if float64(n) < minContains {
+			v.fail(ptr, "expected at least %v elements matching contains, got %d", minContains, n)
+		}
+		if lim, ok := toFloat(s["maxContains"]); ok && float64(n) > lim {
+			v.fail(ptr, "expected at most %v elements matching contains, got %d", lim, n)
+		}
+	}
+}
+
+func (v *schemaValidator) validateUnique(arr []any, ptr string) {
+	for i := range arr {
+		for j := i + 1; j < len(arr); j++ {
+			if reflect.DeepEqual(arr[i], arr[j]) {
+				v.fail(ptr, "expected unique elements, [%d] and [%d] are equal", i, j)
+				return
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]any, inst any, ptr string) {
+	obj, ok := inst.(map[string]any)
+	if !ok {
+		return
+	}
+	if lim, ok := toFloat(s["minProperties"]); ok && float64(len(obj)) < lim {
+		v.fail(ptr, "expected at least %v properties, got %d", lim, len(obj))
+	}
+	if lim, ok := toFloat(s["maxProperties"]); ok && float64(len(obj)) > lim {
+		v.fail(ptr, "expected at most %v properties, got %d", lim, len(obj))
+	}
+	if required, ok := s["required"].([]any); ok {
+		v.validateRequired(required, obj, ptr)
+	}
+
+	props, _ := s["properties"].(map[string]any)
+	patternProps, _ := s["patternProperties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	names, hasNames := s["propertyNames"]
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		keyPtr := ptr + "/" + escapePointer(key)
+		if hasNames {
+			sub := &schemaValidator{root: v.root, patterns: v.patterns, depth: v.depth}
+			sub.validate(names, key, keyPtr)
+			for _, violation := range sub.violations {
+				v.fail(keyPtr, "property name: %s", violation.message)
+			}
+		}
+		matched := false
+		if schema, ok := props[key]; ok {
+			matched = true
+			v.validate(schema, obj[key], keyPtr)
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(patternProps)) {
+			if re, err := v.pattern(pattern); err == nil && re.MatchString(key) {
+				matched = true
+				v.validate(patternProps[pattern], obj[key], keyPtr)
+			}
+		}
+		if !matched && hasAdditional {
+			if additional == false {
+				v.fail(keyPtr, "unexpected property")
+			} else {
+				v.validate(additional, obj[key], keyPtr)
+			}
+		}
+	}
+	v.validateDependencies(s, obj, ptr)
+}
+
+func (v *schemaValidator) validateRequired(required []any, obj map[string]any, ptr string) {
+	for _, r := range required {
+		if name, ok := r.(string); ok {
+			if _, present := obj[name]; !present {
+				v.fail(ptr+"/"+escapePointer(name), "missing required property")
+			}
+		}
+	}
+}
+
+// validateDependencies checks dependentRequired, dependentSchemas and their
+// draft 7 spelling, dependencies.
+func (v *schemaValidator) validateDependencies(s map[string]any, obj map[string]any, ptr string) {
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas", "dependencies"} {
+		deps, _ := s[keyword].(map[string]any)
+		for _, key := range slices.Sorted(maps.Keys(deps)) {
+			if _, present := obj[key]; !present {
+				continue
+			}
+			if required, ok := deps[key].([]any); ok {
+				v.validateRequired(required, obj, ptr)
+			} else {
+				v.validate(deps[key], obj, ptr)
+			}
+		}
+	}
+}
+
+// validateApplicators checks allOf, anyOf, oneOf, not and if/then/else.
+func (v *schemaValidator) validateApplicators(s map[string]any, inst any, ptr string) {
+	if all, ok := s["allOf"].([]any); ok {
+		for _, schema := range all {
+			v.validate(schema, inst, ptr)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		if !slices.ContainsFunc(anyOf, func(schema any) bool { return v.valid(schema, inst, ptr) }) {
+			v.fail(ptr, "does not match any of the anyOf schemas")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		n := 0
+		for _, schema := range oneOf {
+			if v.valid(schema, inst, ptr) {
+				n++
+			}
+		}
+		switch {
+		case n == 0:
+			v.fail(ptr, "does not match any of the oneOf schemas")
+		case n > 1:
+			v.fail(ptr, "matches %d of the oneOf schemas, expected exactly one", n)
+		}
+	}
+	if not, ok := s["not"]; ok && v.valid(not, inst, ptr) {
+		v.fail(ptr, "matches the schema in not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.valid(cond, inst, ptr) {
+			if then, ok := s["then"]; ok {
+				v.validate(then, inst, ptr)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.validate(els, inst, ptr)
+		}
+	}
+}
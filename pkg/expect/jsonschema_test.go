@@ -0,0 +1,339 @@
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["id", "name", "email"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1},
+		"email": {"type": "string", "format": "email"},
+		"role": {"enum": ["admin", "member"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true}
+	},
+	"additionalProperties": false
+}`
+
+func TestJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		actual string
+		want   []string // violations, in order; none means the value conforms
+	}{
+		{name: "conforms", schema: userSchema, actual: `{"id":1,"name":"a","email":"a@b.co","tags":["x"]}`},
+		{
+			name:   "every violation",
+			schema: userSchema,
+			actual: `{"id":1.5,"name":"","role":"owner","tags":["x","x"],"extra":true}`,
+			want: []string{
+				"#/email: missing required property",
+				"#/extra: unexpected property",
+				"#/id: expected integer, got number",
+				"#/name: expected at least 1 characters, got 0",
+				`#/role: expected one of ["admin","member"], got "owner"`,
+				"#/tags: expected unique elements, [0] and [1] are equal",
+			},
+		},
+		{name: "type", schema: `{"type":["string","null"]}`, actual: `3`, want: []string{"#: expected string or null, got number"}},
+		{name: "const", schema: `{"const":{"a":1}}`, actual: `{"a":1}`},
+		{
+			name:   "numbers",
+			schema: `{"items":{"exclusiveMinimum":0,"maximum":10,"multipleOf":0.5}}`,
+			actual: `[0,11,1.25,2.5]`,
+			want:   []string{"#/0: expected > 0, got 0", "#/1: expected <= 10, got 11", "#/2: expected a multiple of 0.5, got 1.25"},
+		},
+		{
+			name:   "strings",
+			schema: `{"items":[{"pattern":"^a"},{"maxLength":2},{"format":"date-time"},{"format":"uuid"}]}`,
+			actual: `["ba","abc","yesterday","x"]`,
+			want: []string{
+				`#/0: "ba" does not match pattern "^a"`,
+				"#/1: expected at most 2 characters, got 3",
+				`#/2: "yesterday" is not a time in layout RFC3339`,
+				`#/3: "x" is not a UUID`,
+			},
+		},
+		{
+			name:   "arrays",
+			schema: `{"prefixItems":[{"type":"string"}],"items":{"type":"number"},"contains":{"const":3},"maxItems":2}`,
+			actual: `["a",1,2]`,
+			want: []string{
+				"#: expected at most 2 elements, got 3",
+				"#: expected at least 1 elements matching contains, got 0",
+			},
+		},
+		{
+			name:   "objects",
+			schema: `{"patternProperties":{"^x-":{"type":"string"}},"propertyNames":{"maxLength":3},"dependentRequired":{"a":["b"]}}`,
+			actual: `{"x-1":1,"a":true,"long":1}`,
+			want: []string{
+				"#/long: property name: expected at most 3 characters, got 4",
+				"#/x-1: expected string, got number",
+				"#/b: missing required property",
+			},
+		},
+		{
+			name:   "applicators",
+			schema: `{"anyOf":[{"type":"string"},{"type":"null"}],"oneOf":[{"minimum":0},{"maximum":10}],"not":{"const":5}}`,
+			actual: `5`,
+			want: []string{
+				"#: does not match any of the anyOf schemas",
+				"#: matches 2 of the oneOf schemas, expected exactly one",
+				"#: matches the schema in not",
+			},
+		},
+		{
+			name:   "if then else",
+			schema: `{"if":{"properties":{"kind":{"const":"card"}}},"then":{"required":["last4"]},"else":{"required":["iban"]}}`,
+			actual: `{"kind":"card"}`,
+			want:   []string{"#/last4: missing required property"},
+		},
+		{
+			name: "refs",
+			schema: `{
+				"$defs": {"node": {"type": "object", "properties": {"next": {"$ref": "#/$defs/node"}, "v": {"type": "integer"}}}},
+				"$ref": "#/$defs/node"
+			}`,
+			actual: `{"v":1,"next":{"v":2,"next":{"v":"x"}}}`,
+			want:   []string{"#/next/next/v: expected integer, got string"},
+		},
+		{name: "false", schema: `{"properties":{"a":false}}`, actual: `{"a":1}`, want: []string{"#/a: not allowed by schema"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actual any
+			if err := json.Unmarshal([]byte(tt.actual), &actual); err != nil {
+				t.Fatal(err)
+			}
+			err := JSONSchema([]byte(tt.schema)).Match(actual)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected violations %q", tt.want)
+			}
+			lines := strings.Split(err.Error(), "\n")[1:]
+			for i := range lines {
+				lines[i] = strings.TrimSpace(lines[i])
+			}
+			if strings.Join(lines, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("unexpected violations:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestJSONSchema_invalid(t *testing.T) {
+	for _, schema := range []any{
+		[]byte(`{"type":`),
+		[]byte(`{"$ref":"#/$defs/missing"}`),
+		[]byte(`{"$ref":"https://example.com/user.json"}`),
+		[]byte(`{"$ref":"#anchor"}`),
+		filepath.Join(t.TempDir(), "missing.json"),
+		42,
+	} {
+		if err := JSONSchema(schema).Match(map[string]any{}); err == nil {
+			t.Errorf("expected error for schema %v", schema)
+		}
+	}
+}
+
+// writeSchemas writes a user schema that refers to an address schema in another directory.
+func writeSchemas(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"schemas/user.json": `{
+			"type": "object",
+			"required": ["id"],
+			"properties": {
+				"id": {"type": "integer"},
+				"address": {"$ref": "common/address.yaml#/$defs/address"}
+			}
+		}`,
+		"schemas/common/address.yaml": strings.Join([]string{
+			"$defs:",
+			"  address:",
+			"    type: object",
+			"    required: [city]",
+			"    properties:",
+			"      city: {type: string}",
+			"      zip: {$ref: '#/$defs/zip'}",
+			"  zip:",
+			"    type: string",
+			"    pattern: '^[0-9]{5}$'",
+		}, "\n"),
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestJSONSchema_fileRefs(t *testing.T) {
+	dir := t.TempDir()
+	writeSchemas(t, dir)
+
+	m := JSONSchema(filepath.Join(dir, "schemas", "user.json"))
+	ok := map[string]any{"id": float64(1), "address": map[string]any{"city": "Paris", "zip": "75001"}}
+	if err := m.Match(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bad := map[string]any{"id": float64(1), "address": map[string]any{"zip": "7500"}}
+	err := m.Match(bad)
+	if err == nil || !strings.Contains(err.Error(), "#/address/city: missing required property") ||
+		!strings.Contains(err.Error(), `#/address/zip: "7500" does not match pattern`) {
+		t.Fatalf("expected address violations, got %v", err)
+	}
+
+	// The bundled schema survives marshalling, as in ExpectBody.
+	body := toExpectBody("test", map[string]any{"user": m})
+	if err := body.Validate([]byte(`{"user":{"id":1,"address":{"city":"Paris"}}}`)); err != nil {
+		t.Errorf("unexpected error after marshalling: %v", err)
+	}
+	err = body.Validate([]byte(`{"user":{"id":"1"}}`))
+	want := "$.user: does not conform to JSON schema (1 violation):\n  #/id: expected integer"
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("expected sub-field violation, got %v", err)
+	}
+}
+
+func TestExpectJSONSchema(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["id"],"properties":{"id":{"type":"integer"}}}`)
+
+	t.Run("http", func(t *testing.T) {
+		exp := GET("/").ExpectBody(map[string]any{"id": Gt(0)}).ExpectJSONSchema(schema).Build().Expect.(*HTTPExpect)
+		resp := func(body string) *http.Response {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+		}
+		if err := exp.Validate(resp(`{"id":2}`), nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		err := exp.Validate(resp(`{"id":2.5}`), nil)
+		var mismatch *MismatchError
+		if !errors.As(err, &mismatch) || mismatch.Path != "$" || mismatch.Diff() != "" {
+			t.Errorf("expected a mismatch at $ without a diff, got %v", err)
+		}
+		if err := exp.Validate(resp(`not json`), nil); err == nil {
+			t.Error("expected error for a non-JSON body")
+		}
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		exp := GRPCRawCall("api", "/svc/Method", nil).ExpectJSONSchema(schema).Build().Expect.(*GRPCExpect)
+		if err := exp.Validate([]byte(`{"id":1}`), nil, nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		err := exp.ValidateStream([][]byte{[]byte(`{"id":1}`), []byte(`{}`)}, nil, nil)
+		if err == nil || !strings.HasPrefix(err.Error(), "message [1]: $: does not conform") {
+			t.Errorf("expected message [1] violation, got %v", err)
+		}
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		exp := SQLStep("db", "SELECT id FROM users").ExpectJSONSchema(schema).Build().Expect.(*SQLExpect)
+		result := &SQLResult{Rows: []map[string]any{{"id": int64(1)}, {"id": "2"}}}
+		err := exp.Validate(result, nil)
+		if err == nil || !strings.HasPrefix(err.Error(), "row [1]: $: does not conform") {
+			t.Errorf("expected row [1] violation, got %v", err)
+		}
+	})
+}
+
+const schemaSuite = `
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: users
+    steps:
+      - request:
+          method: GET
+          endpoint: /users/1
+        expect:
+          schema: ./schemas/user.json
+          body:
+            address: {$json_schema: schemas/common/address.yaml#/$defs/address}
+            owner: {$json_schema: {properties: {address: {$ref: schemas/common/address.yaml#/$defs/address}}}}
+      - request:
+          method: GET
+          endpoint: /health
+        expect:
+          schema:
+            type: object
+            required: [ok]
+`
+
+func TestBuildScenarios_schema(t *testing.T) {
+	check := func(t *testing.T, suite *Suite) {
+		t.Helper()
+		exp := suite.scenarios[0].steps[0].Expect.(*HTTPExpect)
+		if err := exp.Schema.Match(map[string]any{"id": "x"}); err == nil {
+			t.Error("expected schema violation")
+		}
+		if err := exp.Body.Validate([]byte(`{"address":{"zip":"1"}}`)); err == nil {
+			t.Error("expected body schema violation")
+		}
+		// The inline schema's $ref resolves relative to the suite file too.
+		if err := exp.Body.Validate([]byte(`{"address":{"city":"a"},"owner":{"address":{"city":"b"}}}`)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := exp.Body.Validate([]byte(`{"address":{"city":"a"},"owner":{"address":{"zip":"1"}}}`)); err == nil {
+			t.Error("expected inline body schema violation")
+		}
+		health := suite.scenarios[0].steps[1].Expect.(*HTTPExpect)
+		if err := health.Schema.Match(map[string]any{}); err == nil {
+			t.Error("expected inline schema violation")
+		}
+	}
+
+	dir := t.TempDir()
+	writeSchemas(t, filepath.Join(dir, "tests"))
+	path := filepath.Join(dir, "tests", "users.yaml")
+	if err := os.WriteFile(path, []byte(schemaSuite), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		suite, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile error: %v", err)
+		}
+		check(t, suite)
+	})
+
+	t.Run("fs", func(t *testing.T) {
+		suite, err := LoadFS(os.DirFS(dir))
+		if err != nil {
+			t.Fatalf("LoadFS error: %v", err)
+		}
+		check(t, suite)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		fsys := fstest.MapFS{"users.yaml": {Data: []byte(schemaSuite)}}
+		if _, err := LoadFS(fsys); err == nil || !strings.Contains(err.Error(), "schemas/") {
+			t.Errorf("expected missing schema error, got %v", err)
+		}
+	})
+}
@@ -11,16 +11,88 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-func unmarshalYAML(data []byte) (expectFile, error) {
-	var f expectFile
-	if err := yaml.Unmarshal(data, &f); err != nil {
+// fileSource locates the files an expectation file refers to, such as JSON
+// schemas. Relative names resolve against the directory of the file.
+type fileSource struct {
+	fsys fs.FS  // nil for the OS filesystem
+	dir  string // directory of the expectation file; "" for the working directory
+}
+
+// join resolves name relative to the file from, or to the source's directory
+// when from is "".
+func (s fileSource) join(from, name string) string {
+	if s.fsys != nil {
+		if from == "" {
+			return path.Join(s.dir, name)
+		}
+		return path.Join(path.Dir(from), name)
+	}
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	if from == "" {
+		return filepath.Join(s.dir, name)
+	}
+	return filepath.Join(filepath.Dir(from), name)
+}
+
+func (s fileSource) readFile(name string) ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, name)
+	}
+	return os.ReadFile(name)
+}
+
+// isExpectFile reports whether the extension is that of an expectation file.
+func isExpectFile(ext string) bool {
+	return ext == ".yaml" || ext == ".yml" || ext == ".json" || ext == ".http"
+}
+
+// parseFile parses a YAML, JSON or .http expectation file, detected by the
+// extension of its name, expanding ${VAR} in YAML and JSON files.
+func parseFile(data []byte, name string, src fileSource, cfg *loadConfig) (expectFile, error) {
+	var (
+		f   expectFile
+		err error
+	)
+	switch path.Ext(name) {
+	case ".http":
+		return parseHTTPFile(data, name, src)
+	case ".json":
+		f, err = unmarshalJSON(data, cfg)
+	default:
+		f, err = unmarshalYAML(data, cfg)
+	}
+	f.src = src
+	return f, err
+}
+
+func unmarshalYAML(data []byte, cfg *loadConfig) (expectFile, error) {
+	var (
+		doc yaml.Node
+		f   expectFile
+	)
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return expectFile{}, fmt.Errorf("go-expect: parse yaml: %w", err)
+	}
+	if err := cfg.expandYAMLEnv(&doc); err != nil {
+		return expectFile{}, fmt.Errorf("go-expect: expand yaml: %w", err)
+	}
+	if doc.Kind == 0 {
+		return f, nil
+	}
+	if err := doc.Decode(&f); err != nil {
 		return expectFile{}, fmt.Errorf("go-expect: parse yaml: %w", err)
 	}
 	return f, nil
 }
 
-func unmarshalJSON(data []byte) (expectFile, error) {
+func unmarshalJSON(data []byte, cfg *loadConfig) (expectFile, error) {
 	var f expectFile
+	data, err := cfg.expandJSONEnv(data)
+	if err != nil {
+		return expectFile{}, fmt.Errorf("go-expect: expand json: %w", err)
+	}
 	if err := json.Unmarshal(data, &f); err != nil {
 		return expectFile{}, fmt.Errorf("go-expect: parse json: %w", err)
 	}
@@ -28,37 +100,57 @@ func unmarshalJSON(data []byte) (expectFile, error) {
 }
 
 // LoadYAML parses YAML bytes and returns a Suite ready to run.
-func LoadYAML(data []byte) (*Suite, error) {
-	f, err := unmarshalYAML(data)
-	if err != nil {
-		return nil, err
-	}
-	return buildSuite([]expectFile{f})
+// ${VAR} expands from the environment and any WithEnvFile files.
+func LoadYAML(data []byte, opts ...LoadOption) (*Suite, error) {
+	return loadBytes(data, unmarshalYAML, opts)
 }
 
 // LoadJSON parses JSON bytes and returns a Suite ready to run.
-func LoadJSON(data []byte) (*Suite, error) {
-	f, err := unmarshalJSON(data)
+// ${VAR} expands from the environment and any WithEnvFile files.
+func LoadJSON(data []byte, opts ...LoadOption) (*Suite, error) {
+	return loadBytes(data, unmarshalJSON, opts)
+}
+
+func loadBytes(
+	data []byte, unmarshal func([]byte, *loadConfig) (expectFile, error), opts []LoadOption,
+) (*Suite, error) {
+	cfg := newLoadConfig(opts)
+	if err := cfg.loadEnvFiles(); err != nil {
+		return nil, err
+	}
+	f, err := unmarshal(data, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return buildSuite([]expectFile{f})
+	return buildSuite([]expectFile{f}, cfg)
 }
 
-// LoadFile parses a YAML or JSON file from the OS filesystem, detected by extension.
-func LoadFile(fpath string) (*Suite, error) {
+// LoadFile parses a YAML, JSON or .http file from the OS filesystem, detected by extension.
+// ${VAR} expands from the environment and the .env files next to it; see WithEnvironment.
+func LoadFile(fpath string, opts ...LoadOption) (*Suite, error) {
+	cfg := newLoadConfig(opts)
+	src := fileSource{dir: filepath.Dir(fpath)}
+	if err := cfg.loadDotEnv(src); err != nil {
+		return nil, err
+	}
 	data, err := os.ReadFile(fpath)
 	if err != nil {
 		return nil, fmt.Errorf("go-expect: read file %q: %w", fpath, err)
 	}
-	if filepath.Ext(fpath) == ".json" {
-		return LoadJSON(data)
+	f, err := parseFile(data, filepath.ToSlash(fpath), src, cfg)
+	if err != nil {
+		return nil, err
 	}
-	return LoadYAML(data)
+	return buildSuite([]expectFile{f}, cfg)
 }
 
-// LoadDir loads all *.yaml, *.yml, and *.json files in dir from the OS filesystem.
-func LoadDir(dir string) (*Suite, error) {
+// LoadDir loads all *.yaml, *.yml, *.json and *.http files in dir from the OS filesystem.
+// ${VAR} expands from the environment and the .env files in dir; see WithEnvironment.
+func LoadDir(dir string, opts ...LoadOption) (*Suite, error) {
+	cfg := newLoadConfig(opts)
+	if err := cfg.loadDotEnv(fileSource{dir: dir}); err != nil {
+		return nil, err
+	}
 	var files []expectFile
 	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -67,20 +159,14 @@ func LoadDir(dir string) (*Suite, error) {
 		if d.IsDir() {
 			return nil
 		}
-		ext := filepath.Ext(p)
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		if !isExpectFile(filepath.Ext(p)) {
 			return nil
 		}
 		data, err := os.ReadFile(p)
 		if err != nil {
 			return fmt.Errorf("go-expect: read %q: %w", p, err)
 		}
-		var f expectFile
-		if ext == ".json" {
-			f, err = unmarshalJSON(data)
-		} else {
-			f, err = unmarshalYAML(data)
-		}
+		f, err := parseFile(data, filepath.ToSlash(p), fileSource{dir: filepath.Dir(p)}, cfg)
 		if err != nil {
 			return err
 		}
@@ -90,12 +176,17 @@ func LoadDir(dir string) (*Suite, error) {
 	if err != nil {
 		return nil, err
 	}
-	return buildSuite(files)
+	return buildSuite(files, cfg)
 }
 
-// LoadFS loads all *.yaml, *.yml, and *.json files from fsys and returns a Suite ready to run.
+// LoadFS loads all *.yaml, *.yml, *.json and *.http files from fsys and returns a Suite ready to run.
 // Useful with //go:embed directories. Use fs.Sub to scope to a subdirectory if needed.
-func LoadFS(fsys fs.FS) (*Suite, error) {
+// ${VAR} expands from the environment and the .env files at the root of fsys; see WithEnvironment.
+func LoadFS(fsys fs.FS, opts ...LoadOption) (*Suite, error) {
+	cfg := newLoadConfig(opts)
+	if err := cfg.loadDotEnv(fileSource{fsys: fsys, dir: "."}); err != nil {
+		return nil, err
+	}
 	var files []expectFile
 	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -104,20 +195,14 @@ func LoadFS(fsys fs.FS) (*Suite, error) {
 		if d.IsDir() {
 			return nil
 		}
-		ext := path.Ext(p)
-		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		if !isExpectFile(path.Ext(p)) {
 			return nil
 		}
 		data, err := fs.ReadFile(fsys, p)
 		if err != nil {
 			return fmt.Errorf("go-expect: read %q: %w", p, err)
 		}
-		var f expectFile
-		if ext == ".json" {
-			f, err = unmarshalJSON(data)
-		} else {
-			f, err = unmarshalYAML(data)
-		}
+		f, err := parseFile(data, p, fileSource{fsys: fsys, dir: path.Dir(p)}, cfg)
 		if err != nil {
 			return err
 		}
@@ -127,5 +212,5 @@ func LoadFS(fsys fs.FS) (*Suite, error) {
 	if err != nil {
 		return nil, err
 	}
-	return buildSuite(files)
+	return buildSuite(files, cfg)
 }
@@ -2,6 +2,7 @@ package expect
 
 import (
 	"testing"
+	"time"
 )
 
 func TestUnmarshalYAML_connections(t *testing.T) {
@@ -12,7 +13,7 @@ connections:
     url: http://localhost:8080
 scenarios: []
 `)
-	_, err := unmarshalYAML(data)
+	_, err := unmarshalYAML(data, &loadConfig{})
 	if err != nil {
 		t.Fatalf("unmarshalYAML error: %v", err)
 	}
@@ -61,7 +62,7 @@ scenarios:
           body:
             count: 2
 `)
-	f, err := unmarshalYAML(data)
+	f, err := unmarshalYAML(data, &loadConfig{})
 	if err != nil {
 		t.Fatalf("unmarshalYAML error: %v", err)
 	}
@@ -99,7 +100,7 @@ scenarios:
             - field: id
               as: user_id
 `)
-	f, err := unmarshalYAML(data)
+	f, err := unmarshalYAML(data, &loadConfig{})
 	if err != nil {
 		t.Fatalf("unmarshalYAML error: %v", err)
 	}
@@ -125,7 +126,7 @@ connections:
     url: root@tcp(localhost)/testdb
 scenarios: []
 `)
-	f, err := unmarshalYAML(data)
+	f, err := unmarshalYAML(data, &loadConfig{})
 	if err != nil {
 		t.Fatalf("unmarshalYAML error: %v", err)
 	}
@@ -160,7 +161,7 @@ connections:
     url: ws://localhost:9090
 scenarios: []
 `)
-	f, err := unmarshalYAML(data)
+	f, err := unmarshalYAML(data, &loadConfig{})
 	if err != nil {
 		t.Fatalf("unmarshalYAML error: %v", err)
 	}
@@ -169,3 +170,246 @@ scenarios: []
 		t.Fatal("expected error for unknown connection type, got nil")
 	}
 }
+
+func TestBuildScenarios_parallel(t *testing.T) {
+	data := []byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+
+scenarios:
+  - name: serial
+    steps: []
+  - name: concurrent
+    parallel: true
+    steps: []
+`)
+	suite, err := LoadYAML(data)
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	if len(suite.scenarios) != 2 {
+		t.Fatalf("expected 2 scenarios, got %d", len(suite.scenarios))
+	}
+	if suite.scenarios[0].parallel {
+		t.Fatal("expected first scenario to be serial")
+	}
+	if !suite.scenarios[1].parallel {
+		t.Fatal("expected second scenario to be parallel")
+	}
+}
+
+func TestBuildScenarios_retry(t *testing.T) {
+	data := []byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+
+scenarios:
+  - name: retry test
+    steps:
+      - request:
+          method: GET
+          endpoint: /users/1
+        expect:
+          status: 200
+        retry:
+          attempts: 10
+          interval: 200ms
+          backoff: exponential
+          until_timeout: 5s
+`)
+	suite, err := LoadYAML(data)
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	retry := suite.scenarios[0].steps[0].Retry
+	if retry == nil {
+		t.Fatal("expected retry policy")
+	}
+	want := RetryPolicy{
+		Attempts:     10,
+		Interval:     200 * time.Millisecond,
+		Backoff:      BackoffExponential,
+		UntilTimeout: 5 * time.Second,
+	}
+	if *retry != want {
+		t.Fatalf("expected %+v, got %+v", want, *retry)
+	}
+}
+
+func TestBuildScenarios_retryInvalid(t *testing.T) {
+	data := []byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+
+scenarios:
+  - name: retry test
+    steps:
+      - request:
+          method: GET
+          endpoint: /users/1
+        retry:
+          interval: soon
+`)
+	if _, err := LoadYAML(data); err == nil {
+		t.Fatal("expected error for invalid retry interval")
+	}
+}
+
+func TestBuildScenarios_timeout(t *testing.T) {
+	data := []byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+
+scenarios:
+  - name: bounded
+    timeout: 30s
+    steps: []
+`)
+	suite, err := LoadYAML(data)
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	if got := suite.scenarios[0].timeout; got != 30*time.Second {
+		t.Fatalf("expected timeout 30s, got %v", got)
+	}
+}
+
+func TestBuildScenarios_grpcStream(t *testing.T) {
+	data := []byte(`
+connections:
+  - name: svc
+    type: grpc
+    url: localhost:50051
+
+scenarios:
+  - name: stream test
+    steps:
+      - request:
+          endpoint: /numbers.Numbers/Sum
+          messages:
+            - n: 1
+            - n: 2
+        expect:
+          code: OK
+          message_count: 1
+          unordered: true
+          messages:
+            - n: 3
+          save:
+            - message: 0
+              field: n
+              as: total
+`)
+	suite, err := LoadYAML(data)
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	step := suite.scenarios[0].steps[0]
+	req := step.Request.(*GRPCRequest)
+	if len(req.Messages) != 2 || string(req.Messages[1]) != `{"n":2}` {
+		t.Fatalf("unexpected request messages: %q", req.Messages)
+	}
+	exp := step.Expect.(*GRPCExpect)
+	if exp.MessageCount == nil || *exp.MessageCount != 1 || !exp.Unordered || len(exp.Messages) != 1 {
+		t.Fatalf("unexpected stream expectations: %+v", exp)
+	}
+	if len(exp.Save) != 1 || exp.Save[0].Field != "0.n" {
+		t.Fatalf("unexpected save entries: %+v", exp.Save)
+	}
+}
+
+func TestBuildScenarios_match(t *testing.T) {
+	tests := []struct {
+		name    string
+		expect  string
+		actual  string
+		wantErr bool
+	}{
+		{
+			name:   "partial",
+			expect: "body:\n            id: 1",
+			actual: `{"id":1,"extra":true}`,
+		},
+		{
+			name:    "strict",
+			expect:  "match: strict\n          body:\n            id: 1",
+			actual:  `{"id":1,"extra":true}`,
+			wantErr: true,
+		},
+		{
+			name:   "ordered and exact-length list",
+			expect: "match: [ordered, exact-length]\n          body:\n            ids: [1, 2]",
+			actual: `{"ids":[1,2]}`,
+		},
+		{
+			name:    "ordered list out of order",
+			expect:  "match: [ordered, exact-length]\n          body:\n            ids: [1, 2]",
+			actual:  `{"ids":[2,1]}`,
+			wantErr: true,
+		},
+		{
+			name:    "operator sub-tree",
+			expect:  "body:\n            user:\n              $strict:\n                id: 1",
+			actual:  `{"user":{"id":1,"password":"x"}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: match
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          ` + tt.expect + `
+`))
+			if err != nil {
+				t.Fatalf("LoadYAML error: %v", err)
+			}
+			exp := suite.scenarios[0].steps[0].Expect.(*HTTPExpect)
+			if err := exp.Body.Validate([]byte(tt.actual)); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildScenarios_matchInvalid(t *testing.T) {
+	for _, expect := range []string{
+		"match: sorted\n          body:\n            id: 1",
+		"body:\n            ids:\n              $ordered: 1",
+	} {
+		_, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: match
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          ` + expect + `
+`))
+		if err == nil {
+			t.Errorf("expected error for %q", expect)
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package expect
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
 	"reflect"
 	"regexp"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Matcher is implemented by any value that can assert itself against an actual value.
@@ -31,6 +40,11 @@ func (m Contains) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$contains": ...}.
+func (m Contains) MarshalJSON() ([]byte, error) {
+	return operatorJSON("contains", string(m))
+}
+
 // Matches asserts the actual string matches the given regular expression.
 type Matches string
 
@@ -49,6 +63,11 @@ func (m Matches) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$matches": ...}.
+func (m Matches) MarshalJSON() ([]byte, error) {
+	return operatorJSON("matches", string(m))
+}
+
 // NotEmpty asserts the actual value is non-nil and non-zero.
 type NotEmpty struct{}
 
@@ -62,6 +81,11 @@ func (NotEmpty) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$not_empty": true}.
+func (NotEmpty) MarshalJSON() ([]byte, error) {
+	return operatorJSON("not_empty", true)
+}
+
 // ---- Numeric matchers ----
 
 func toFloat(v any) (float64, bool) {
@@ -92,6 +116,11 @@ func (m Gt) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$gt": ...}.
+func (m Gt) MarshalJSON() ([]byte, error) {
+	return operatorJSON("gt", float64(m))
+}
+
 // Gte asserts actual >= n.
 type Gte float64
 
@@ -106,6 +135,11 @@ func (m Gte) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$gte": ...}.
+func (m Gte) MarshalJSON() ([]byte, error) {
+	return operatorJSON("gte", float64(m))
+}
+
 // Lt asserts actual < n.
 type Lt float64
 
@@ -120,6 +154,11 @@ func (m Lt) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$lt": ...}.
+func (m Lt) MarshalJSON() ([]byte, error) {
+	return operatorJSON("lt", float64(m))
+}
+
 // Lte asserts actual <= n.
 type Lte float64
 
@@ -134,6 +173,11 @@ func (m Lte) Match(actual any) error {
 	return nil
 }
 
+// MarshalJSON encodes the matcher as {"$lte": ...}.
+func (m Lte) MarshalJSON() ([]byte, error) {
+	return operatorJSON("lte", float64(m))
+}
+
 // ---- Array matchers ----
 
 // Length asserts the actual array or string has exactly n elements.
@@ -151,6 +195,314 @@ func (m Length) Match(actual any) error {
 	return fmt.Errorf("expected slice/string/map, got %T", actual)
 }
 
+// MarshalJSON encodes the matcher as {"$length": ...}.
+func (m Length) MarshalJSON() ([]byte, error) {
+	return operatorJSON("length", int(m))
+}
+
+// ---- Type matchers ----
+
+// matchType asserts actual has the named JSON type.
+func matchType(want string, actual any) error {
+	if got := jsonType(actual); got != want {
+		return fmt.Errorf("expected %s, got %s", want, got)
+	}
+	return nil
+}
+
+// IsString asserts the actual value is a string.
+type IsString struct{}
+
+func (IsString) Match(actual any) error { return matchType("string", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "string"}.
+func (IsString) MarshalJSON() ([]byte, error) { return operatorJSON("type", "string") }
+
+// IsNumber asserts the actual value is a number.
+type IsNumber struct{}
+
+func (IsNumber) Match(actual any) error { return matchType("number", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "number"}.
+func (IsNumber) MarshalJSON() ([]byte, error) { return operatorJSON("type", "number") }
+
+// IsInteger asserts the actual value is a number with no fractional part.
+type IsInteger struct{}
+
+func (IsInteger) Match(actual any) error {
+	f, ok := toFloat(actual)
+	if !ok {
+		return matchType("number", actual)
+	}
+	if f != math.Trunc(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("expected integer, got %v", f)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$type": "integer"}.
+func (IsInteger) MarshalJSON() ([]byte, error) { return operatorJSON("type", "integer") }
+
+// IsBool asserts the actual value is a boolean.
+type IsBool struct{}
+
+func (IsBool) Match(actual any) error { return matchType("boolean", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "boolean"}.
+func (IsBool) MarshalJSON() ([]byte, error) { return operatorJSON("type", "boolean") }
+
+// IsArray asserts the actual value is an array.
+type IsArray struct{}
+
+func (IsArray) Match(actual any) error { return matchType("array", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "array"}.
+func (IsArray) MarshalJSON() ([]byte, error) { return operatorJSON("type", "array") }
+
+// IsObject asserts the actual value is an object.
+type IsObject struct{}
+
+func (IsObject) Match(actual any) error { return matchType("object", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "object"}.
+func (IsObject) MarshalJSON() ([]byte, error) { return operatorJSON("type", "object") }
+
+// IsNull asserts the actual value is null.
+type IsNull struct{}
+
+func (IsNull) Match(actual any) error { return matchType("null", actual) }
+
+// MarshalJSON encodes the matcher as {"$type": "null"}.
+func (IsNull) MarshalJSON() ([]byte, error) { return operatorJSON("type", "null") }
+
+// ---- Format matchers ----
+
+//nolint:gochecknoglobals // compiled once
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// UUID asserts the actual string is a UUID in canonical 8-4-4-4-12 form.
+// A non-zero UUID also requires that version, e.g. UUID(4); UUID(0) accepts any.
+type UUID int
+
+func (m UUID) Match(actual any) error {
+	s, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected string, got %s", jsonType(actual))
+	}
+	if !uuidRe.MatchString(s) {
+		return fmt.Errorf("%q is not a UUID", s)
+	}
+	if m != 0 {
+		if v, _ := strconv.ParseInt(s[14:15], 16, 8); int(v) != int(m) {
+			return fmt.Errorf("expected UUID version %d, got version %d", int(m), v)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$uuid": version}, or {"$uuid": true} for any version.
+func (m UUID) MarshalJSON() ([]byte, error) {
+	if m == 0 {
+		return operatorJSON("uuid", true)
+	}
+	return operatorJSON("uuid", int(m))
+}
+
+// TimeFormat asserts the actual string is a time in the given layout (see time.Parse).
+type TimeFormat string
+
+// RFC3339 asserts the actual string is an RFC 3339 timestamp, with or without
+// fractional seconds.
+const RFC3339 = TimeFormat(time.RFC3339)
+
+// timeLayouts names the time package layouts usable in $time_format.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+func (m TimeFormat) Match(actual any) error {
+	s, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected string, got %s", jsonType(actual))
+	}
+	if _, err := time.Parse(string(m), s); err != nil {
+		if name, ok := m.layoutName(); ok {
+			return fmt.Errorf("%q is not a time in layout %s", s, name)
+		}
+		return fmt.Errorf("%q is not a time in layout %q", s, string(m))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$time_format": layout}, naming the
+// layout when it is one of timeLayouts.
+func (m TimeFormat) MarshalJSON() ([]byte, error) {
+	if name, ok := m.layoutName(); ok {
+		return operatorJSON("time_format", name)
+	}
+	return operatorJSON("time_format", string(m))
+}
+
+func (m TimeFormat) layoutName() (string, bool) {
+	for name, layout := range timeLayouts {
+		if layout == string(m) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Email asserts the actual string is a bare email address, e.g. "a@example.com".
+type Email struct{}
+
+func (Email) Match(actual any) error {
+	s, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected string, got %s", jsonType(actual))
+	}
+	if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+		return fmt.Errorf("%q is not an email address", s)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$email": true}.
+func (Email) MarshalJSON() ([]byte, error) { return operatorJSON("email", true) }
+
+// URL asserts the actual string is an absolute URL with a scheme and host.
+type URL struct{}
+
+func (URL) Match(actual any) error {
+	s, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected string, got %s", jsonType(actual))
+	}
+	if u, err := url.Parse(s); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute URL", s)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$url": true}.
+func (URL) MarshalJSON() ([]byte, error) { return operatorJSON("url", true) }
+
+// Base64 asserts the actual string is standard, padded base64.
+type Base64 struct{}
+
+func (Base64) Match(actual any) error {
+	s, ok := actual.(string)
+	if !ok {
+		return fmt.Errorf("expected string, got %s", jsonType(actual))
+	}
+	if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+		return fmt.Errorf("%q is not base64", s)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$base64": true}.
+func (Base64) MarshalJSON() ([]byte, error) { return operatorJSON("base64", true) }
+
+// ---- Time matchers ----
+//
+// Time matchers accept RFC 3339 timestamps, with or without fractional seconds.
+
+func toTime(v any) (time.Time, error) {
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected RFC3339 timestamp, got %s", jsonType(v))
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// Within asserts the actual timestamp is within d of now, in either direction.
+type Within time.Duration
+
+func (m Within) Match(actual any) error {
+	t, err := toTime(actual)
+	if err != nil {
+		return err
+	}
+	if d := time.Since(t).Abs(); d > time.Duration(m) {
+		return fmt.Errorf("expected within %s of now, got %s (%s away)", time.Duration(m), actual, d.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the matcher as {"$within": "5s"}.
+func (m Within) MarshalJSON() ([]byte, error) {
+	return operatorJSON("within", time.Duration(m).String())
+}
+
+// After asserts the actual timestamp is strictly after ref: the name of a
+// saved variable holding a timestamp, or a timestamp itself.
+type After string
+
+func (m After) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m After) matchWith(path string, actual any, o matchOpts) error {
+	return matchTimeOrder(path, actual, string(m), o.vars, true)
+}
+
+// MarshalJSON encodes the matcher as {"$after": ref}.
+func (m After) MarshalJSON() ([]byte, error) { return operatorJSON("after", string(m)) }
+
+// Before asserts the actual timestamp is strictly before ref: the name of a
+// saved variable holding a timestamp, or a timestamp itself.
+type Before string
+
+func (m Before) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m Before) matchWith(path string, actual any, o matchOpts) error {
+	return matchTimeOrder(path, actual, string(m), o.vars, false)
+}
+
+// MarshalJSON encodes the matcher as {"$before": ref}.
+func (m Before) MarshalJSON() ([]byte, error) { return operatorJSON("before", string(m)) }
+
+// matchTimeOrder checks that actual is after (or before) ref, resolving ref as
+// a variable first.
+func matchTimeOrder(path string, actual any, ref string, vars VarStore, after bool) error {
+	rel := "before"
+	if after {
+		rel = "after"
+	}
+	fail := func(err error) error {
+		return &MismatchError{Path: path, Reason: err.Error(), Expected: ref, Actual: actual}
+	}
+	t, err := toTime(actual)
+	if err != nil {
+		return fail(err)
+	}
+	refValue, name := any(ref), ""
+	if v, found := vars[ref]; found {
+		refValue, name = v, ref+" = "
+	}
+	refTime, err := toTime(refValue)
+	switch {
+	case err != nil && name != "":
+		return fail(fmt.Errorf("%s: variable %s: %w", rel, ref, err))
+	case err != nil:
+		return fail(fmt.Errorf("%s: %s is not a saved variable or timestamp", rel, formatValue(ref)))
+	}
+	if (after && !t.After(refTime)) || (!after && !t.Before(refTime)) {
+		return fail(fmt.Errorf("expected %s %s%s, got %s", rel, name, refTime.Format(time.RFC3339Nano), actual))
+	}
+	return nil
+}
+
 // ---- Status code matchers ----
 
 // AnyOf asserts the actual HTTP status code is one of the given codes.
@@ -162,3 +514,296 @@ func (m AnyOf) MatchStatus(actual int) error {
 	}
 	return fmt.Errorf("expected status one of %v, got %d", []int(m), actual)
 }
+
+// ---- Match modes ----
+
+// matchMode tightens how an expected sub-tree is compared; modes nest.
+type matchMode uint8
+
+const (
+	modeStrict      matchMode = 1 << iota // objects may not have extra keys
+	modeOrdered                           // arrays match position by position
+	modeExactLength                       // arrays have exactly the expected number of elements
+)
+
+// modeMatcher applies a match mode to an expected value and everything below it.
+type modeMatcher struct {
+	mode  matchMode
+	value any
+}
+
+// Strict matches v with no extra keys allowed in any object within it.
+func Strict(v any) Matcher {
+	if m, ok := v.(modeMatcher); ok {
+		return modeMatcher{mode: m.mode | modeStrict, value: m.value}
+	}
+	return modeMatcher{mode: modeStrict, value: v}
+}
+
+// Ordered matches an array position by position: the ith actual element must
+// match the ith expected element. Arrays nested in the elements are ordered too.
+// Ordered(Exactly(...)) combines both modes on the same array.
+func Ordered(elems ...any) Matcher {
+	return arrayMode(modeOrdered, elems)
+}
+
+// Exactly matches an array of exactly len(elems) elements, each expected
+// element matching a distinct actual element in any order.
+// Exactly(Ordered(...)) combines both modes on the same array.
+func Exactly(elems ...any) Matcher {
+	return arrayMode(modeExactLength, elems)
+}
+
+func arrayMode(mode matchMode, elems []any) Matcher {
+	if len(elems) == 1 {
+		if m, ok := elems[0].(modeMatcher); ok {
+			return modeMatcher{mode: m.mode | mode, value: m.value}
+		}
+	}
+	return modeMatcher{mode: mode, value: nonNil(elems)}
+}
+
+func (m modeMatcher) Match(actual any) error {
+	return m.matchWith("$", actual, matchOpts{})
+}
+
+func (m modeMatcher) matchWith(path string, actual any, o matchOpts) error {
+	o.mode |= m.mode
+	return matchPath(path, actual, m.value, o)
+}
+
+func (m modeMatcher) MatchAbsent() error {
+	return matchAbsent(m.value)
+}
+
+// MarshalJSON encodes each mode as an operator object, e.g. {"$strict": {...}},
+// so it survives ExpectBody and can be written in YAML and JSON files.
+func (m modeMatcher) MarshalJSON() ([]byte, error) {
+	v := m.value
+	for _, mode := range []matchMode{modeOrdered, modeExactLength, modeStrict} {
+		if m.mode&mode != 0 {
+			v = map[string]any{mode.operator(): v}
+		}
+	}
+	return json.Marshal(v)
+}
+
+// ---- Combinators ----
+//
+// Combinator arguments may be matchers or plain values, which are compared as
+// in any expected body.
+
+// absenceMatcher is implemented by matchers with an opinion on a missing field.
+// MatchAbsent returns nil if the field may be absent.
+type absenceMatcher interface {
+	MatchAbsent() error
+}
+
+// matchAbsent reports whether expected accepts a missing field.
+func matchAbsent(expected any) error {
+	if m, ok := expected.(absenceMatcher); ok {
+		return m.MatchAbsent()
+	}
+	return errors.New("missing field")
+}
+
+// Absent asserts the field is not present in the actual object.
+type Absent struct{}
+
+func (Absent) Match(actual any) error {
+	return fmt.Errorf("expected field to be absent, got %s", formatValue(actual))
+}
+
+func (Absent) MatchAbsent() error { return nil }
+
+// MarshalJSON encodes the matcher as {"$absent": true}.
+func (Absent) MarshalJSON() ([]byte, error) {
+	return operatorJSON("absent", true)
+}
+
+type optionalMatcher struct{ v any }
+
+// Optional asserts the field is either absent or matches v.
+func Optional(v any) Matcher { return optionalMatcher{v} }
+
+func (m optionalMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m optionalMatcher) matchWith(path string, actual any, o matchOpts) error {
+	return matchPath(path, actual, m.v, o)
+}
+
+func (optionalMatcher) MatchAbsent() error { return nil }
+
+func (m optionalMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("optional", m.v) }
+
+type nullableMatcher struct{ v any }
+
+// Nullable asserts the value is null or matches v.
+func Nullable(v any) Matcher { return nullableMatcher{v} }
+
+func (m nullableMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m nullableMatcher) matchWith(path string, actual any, o matchOpts) error {
+	if actual == nil {
+		return nil
+	}
+	return matchPath(path, actual, m.v, o)
+}
+
+func (m nullableMatcher) MatchAbsent() error { return matchAbsent(m.v) }
+
+func (m nullableMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("nullable", m.v) }
+
+type notMatcher struct{ v any }
+
+// Not asserts the value does not match v. Not(Absent{}) asserts the field is present.
+func Not(v any) Matcher { return notMatcher{v} }
+
+func (m notMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m notMatcher) matchWith(path string, actual any, o matchOpts) error {
+	if matchPath(path, actual, m.v, o) == nil {
+		return &MismatchError{
+			Path:     path,
+			Reason:   fmt.Sprintf("expected not %s, got %s", formatValue(m.v), formatValue(actual)),
+			Expected: m,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
+
+func (m notMatcher) MatchAbsent() error {
+	if matchAbsent(m.v) == nil {
+		return errors.New("missing field")
+	}
+	return nil
+}
+
+func (m notMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("not", m.v) }
+
+type allMatcher []any
+
+// All asserts the value matches every one of vs.
+func All(vs ...any) Matcher { return allMatcher(vs) }
+
+func (m allMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m allMatcher) matchWith(path string, actual any, o matchOpts) error {
+	for _, v := range m {
+		if err := matchPath(path, actual, v, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m allMatcher) MatchAbsent() error {
+	for _, v := range m {
+		if err := matchAbsent(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m allMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("all", []any(nonNil(m))) }
+
+type anyMatcher []any
+
+// Any asserts the value matches at least one of vs.
+func Any(vs ...any) Matcher { return anyMatcher(vs) }
+
+func (m anyMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m anyMatcher) matchWith(path string, actual any, o matchOpts) error {
+	reasons := make([]string, 0, len(m))
+	for _, v := range m {
+		err := matchPath(path, actual, v, o)
+		if err == nil {
+			return nil
+		}
+		reasons = append(reasons, mismatchReason(path, err))
+	}
+	return &MismatchError{
+		Path:     path,
+		Reason:   "matched none of: " + strings.Join(reasons, "; "),
+		Expected: m,
+		Actual:   actual,
+	}
+}
+
+func (m anyMatcher) MatchAbsent() error {
+	for _, v := range m {
+		if matchAbsent(v) == nil {
+			return nil
+		}
+	}
+	return errors.New("missing field")
+}
+
+func (m anyMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("any", []any(nonNil(m))) }
+
+type oneOfMatcher []any
+
+// OneOf asserts the value matches exactly one of vs.
+func OneOf(vs ...any) Matcher { return oneOfMatcher(vs) }
+
+func (m oneOfMatcher) Match(actual any) error { return m.matchWith("$", actual, matchOpts{}) }
+
+func (m oneOfMatcher) matchWith(path string, actual any, o matchOpts) error {
+	var matched []string
+	reasons := make([]string, 0, len(m))
+	for _, v := range m {
+		if err := matchPath(path, actual, v, o); err != nil {
+			reasons = append(reasons, mismatchReason(path, err))
+		} else {
+			matched = append(matched, formatValue(v))
+		}
+	}
+	mismatch := &MismatchError{Path: path, Expected: m, Actual: actual}
+	switch len(matched) {
+	case 1:
+		return nil
+	case 0:
+		mismatch.Reason = "matched none of: " + strings.Join(reasons, "; ")
+	default:
+		mismatch.Reason = "matched more than one of: " + strings.Join(matched, ", ")
+	}
+	return mismatch
+}
+
+func (m oneOfMatcher) MatchAbsent() error {
+	n := 0
+	for _, v := range m {
+		if matchAbsent(v) == nil {
+			n++
+		}
+	}
+	if n != 1 {
+		return errors.New("missing field")
+	}
+	return nil
+}
+
+func (m oneOfMatcher) MarshalJSON() ([]byte, error) { return operatorJSON("one_of", []any(nonNil(m))) }
+
+// mismatchReason is err's message without the diff of a *MismatchError, and
+// without its path when that is path itself.
+func mismatchReason(path string, err error) string {
+	var mismatch *MismatchError
+	if errors.As(err, &mismatch) {
+		if mismatch.Path == path {
+			return mismatch.Reason
+		}
+		return mismatch.Path + ": " + mismatch.Reason
+	}
+	return err.Error()
+}
+
+func nonNil(vs []any) []any {
+	if vs == nil {
+		return []any{}
+	}
+	return vs
+}
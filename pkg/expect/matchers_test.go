@@ -1,6 +1,13 @@
 package expect
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestContains(t *testing.T) {
 	if err := Contains("ello").Match("hello world"); err != nil {
@@ -95,3 +102,512 @@ func TestPartialMatch_withMatcherFail(t *testing.T) {
 		t.Error("expected error: 1 not > 10")
 	}
 }
+
+func TestMatchModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected any
+		actual   string
+		wantErr  string
+	}{
+		{
+			name:     "partial ignores extra keys",
+			expected: map[string]any{"id": 1},
+			actual:   `{"id":1,"secret":"x"}`,
+		},
+		{
+			name:     "strict rejects extra keys",
+			expected: Strict(map[string]any{"id": 1}),
+			actual:   `{"id":1,"secret":"x"}`,
+			wantErr:  "$.secret: unexpected field",
+		},
+		{
+			name:     "strict applies to nested objects",
+			expected: Strict(map[string]any{"user": map[string]any{"id": 1}}),
+			actual:   `{"user":{"id":1,"password":"x"}}`,
+			wantErr:  "$.user.password: unexpected field",
+		},
+		{
+			name:     "strict sub-tree only",
+			expected: map[string]any{"user": Strict(map[string]any{"id": 1})},
+			actual:   `{"user":{"id":1},"extra":true}`,
+		},
+		{
+			name:     "unordered by default",
+			expected: map[string]any{"ids": []any{3, 1}},
+			actual:   `{"ids":[1,2,3]}`,
+		},
+		{
+			name:     "ordered",
+			expected: map[string]any{"ids": Ordered(1, 2, 3)},
+			actual:   `{"ids":[1,2,3,4]}`,
+		},
+		{
+			name:     "ordered out of order",
+			expected: map[string]any{"ids": Ordered(1, 3, 2)},
+			actual:   `{"ids":[1,2,3]}`,
+			wantErr:  "$.ids[1]: expected 3, got 2",
+		},
+		{
+			name:     "ordered too short",
+			expected: map[string]any{"ids": Ordered(1, 2)},
+			actual:   `{"ids":[1]}`,
+			wantErr:  "$.ids[1]: missing element",
+		},
+		{
+			name:     "exactly",
+			expected: map[string]any{"ids": Exactly(3, 2, 1)},
+			actual:   `{"ids":[1,2,3]}`,
+		},
+		{
+			name:     "exactly wrong length",
+			expected: map[string]any{"ids": Exactly(1, 2)},
+			actual:   `{"ids":[1,2,3]}`,
+			wantErr:  "$.ids: expected 2 elements, got 3",
+		},
+		{
+			name:     "exactly matches distinct elements",
+			expected: map[string]any{"ids": Exactly(1, 1)},
+			actual:   `{"ids":[1,2]}`,
+			wantErr:  "$.ids[1]: element not found",
+		},
+		{
+			name:     "exactly revisits earlier matches",
+			expected: Exactly(map[string]any{}, map[string]any{"id": 1}),
+			actual:   `[{"id":1},{"id":2}]`,
+		},
+		{
+			name:     "strict, ordered and exact top-level array",
+			expected: Strict(Exactly(Ordered(map[string]any{"id": 1}, map[string]any{"id": 2}))),
+			actual:   `[{"id":1},{"id":2}]`,
+		},
+		{
+			name:     "ordered and exact rejects extra elements",
+			expected: Exactly(Ordered(1, 2)),
+			actual:   `[1,2,3]`,
+			wantErr:  "$: expected 2 elements, got 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toExpectBody("test", tt.expected).Validate([]byte(tt.actual))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("expected error %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.HasPrefix(err.Error(), tt.wantErr):
+				t.Errorf("expected error %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodeOperators_invalid(t *testing.T) {
+	for _, body := range []string{`{"ids":{"$ordered":1}}`, `{"ids":{"$unknown":[1]}}`} {
+		if err := ExpectBody(body).Validate([]byte(`{"ids":[1]}`)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestDecodeOperators_literalKeys(t *testing.T) {
+	tests := []struct {
+		expected, actual string
+		wantErr          bool
+	}{
+		{`{"schema":{"$ref":"#/defs/pet"}}`, `{"schema":{"$ref":"#/defs/pet"}}`, false},
+		{`{"_id":{"$oid":"5f1d"}}`, `{"_id":{"$oid":"5f1d"}}`, false},
+		{`{"_id":{"$oid":"5f1d"}}`, `{"_id":{"$oid":"beef"}}`, true},
+		{`{"filter":{"$$gt":0}}`, `{"filter":{"$gt":0}}`, false},
+		{`{"filter":{"$gt":0}}`, `{"filter":{"$gt":0}}`, true},
+	}
+	for _, tt := range tests {
+		err := ExpectBody(tt.expected).Validate([]byte(tt.actual))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s against %s: unexpected error %v", tt.expected, tt.actual, err)
+		}
+	}
+}
+
+func TestMatchers_builderBody(t *testing.T) {
+	body := GET("/").ExpectBody(map[string]any{
+		"results": Length(2),
+		"cursor":  NotEmpty{},
+		"query":   Contains("ali"),
+		"id":      Matches(`^[0-9a-f]{4}$`),
+		"score":   Gt(0.5),
+	}).Build().Expect.(*HTTPExpect).Body
+
+	if err := body.Validate([]byte(`{"results":[1,2],"cursor":"c","query":"alice","id":"beef","score":0.7}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := body.Validate([]byte(`{"results":[1],"cursor":"c","query":"alice","id":"beef","score":0.7}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "$.results: expected length 2, got 1") {
+		t.Errorf("expected length mismatch, got %v", err)
+	}
+}
+
+func TestMatchers_fileSyntax(t *testing.T) {
+	suite, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: matchers
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          body:
+            id: {$matches: "^[0-9a-f-]{36}$"}
+            count: {$gt: 0}
+            items: {$length: 3}
+            name: {$contains: ali}
+            token: {$not_empty: true}
+            price: {$lt: 2}
+`))
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	body := suite.scenarios[0].steps[0].Expect.(*HTTPExpect).Body
+
+	ok := `{"id":"3f1c2a34-5b6d-4e7f-8a9b-0c1d2e3f4a5b","count":2,"items":[1,2,3],"name":"alice","token":"t","price":1.5}`
+	if err := body.Validate([]byte(ok)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	bad := `{"id":"nope","count":2,"items":[1,2,3],"name":"alice","token":"t","price":1.5}`
+	if err := body.Validate([]byte(bad)); err == nil || !strings.HasPrefix(err.Error(), "$.id:") {
+		t.Errorf("expected $.id mismatch, got %v", err)
+	}
+}
+
+func TestMatchers_fileSyntaxInvalid(t *testing.T) {
+	for _, body := range []string{
+		`{$gt: "five"}`,
+		`{$length: 1.5}`,
+		`{$matches: "("}`,
+		`{$not_empty: false}`,
+	} {
+		_, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:8080
+scenarios:
+  - name: matchers
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          body:
+            value: ` + body + `
+`))
+		if err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+type divisibleBy float64
+
+func (m divisibleBy) Match(actual any) error {
+	f, ok := toFloat(actual)
+	if !ok || math.Mod(f, float64(m)) != 0 {
+		return fmt.Errorf("expected a multiple of %v, got %v", float64(m), actual)
+	}
+	return nil
+}
+
+func (m divisibleBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"$divisible_by": float64(m)})
+}
+
+func TestRegisterMatcher(t *testing.T) {
+	RegisterMatcher("divisible_by", func(arg any) (Matcher, error) {
+		n, ok := arg.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected number, got %T", arg)
+		}
+		return divisibleBy(n), nil
+	})
+
+	body := toExpectBody("test", map[string]any{"n": divisibleBy(3)})
+	if err := body.Validate([]byte(`{"n":9}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ExpectBody(`{"n":{"$divisible_by":3}}`).Validate([]byte(`{"n":10}`)); err == nil {
+		t.Error("expected error: 10 is not a multiple of 3")
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]any
+		actual   string
+		wantErr  string
+	}{
+		{name: "not", expected: map[string]any{"status": Not("deleted")}, actual: `{"status":"active"}`},
+		{
+			name:     "not fails",
+			expected: map[string]any{"status": Not("deleted")},
+			actual:   `{"status":"deleted"}`,
+			wantErr:  `$.status: expected not "deleted", got "deleted"`,
+		},
+		{name: "all", expected: map[string]any{"n": All(Gt(1), Lt(5))}, actual: `{"n":3}`},
+		{
+			name:     "all fails",
+			expected: map[string]any{"n": All(Gt(1), Lt(5))},
+			actual:   `{"n":7}`,
+			wantErr:  "$.n: expected < 5, got 7",
+		},
+		{name: "any", expected: map[string]any{"n": Any(nil, Gt(0))}, actual: `{"n":3}`},
+		{
+			name:     "any fails",
+			expected: map[string]any{"n": Any(nil, Gt(0))},
+			actual:   `{"n":-1}`,
+			wantErr:  "$.n: matched none of: expected null, got -1; expected > 0, got -1",
+		},
+		{name: "one of", expected: map[string]any{"n": OneOf(1, 2)}, actual: `{"n":2}`},
+		{
+			name:     "one of matches both",
+			expected: map[string]any{"n": OneOf(Gt(0), Lt(5))},
+			actual:   `{"n":2}`,
+			wantErr:  "$.n: matched more than one of",
+		},
+		{name: "nullable null", expected: map[string]any{"n": Nullable(Gt(0))}, actual: `{"n":null}`},
+		{name: "nullable value", expected: map[string]any{"n": Nullable(Gt(0))}, actual: `{"n":4}`},
+		{
+			name:     "nullable fails",
+			expected: map[string]any{"n": Nullable(Gt(0))},
+			actual:   `{"n":-4}`,
+			wantErr:  "$.n: expected > 0, got -4",
+		},
+		{name: "absent", expected: map[string]any{"password": Absent{}}, actual: `{"id":1}`},
+		{
+			name:     "absent present",
+			expected: map[string]any{"password": Absent{}},
+			actual:   `{"password":"x"}`,
+			wantErr:  `$.password: expected field to be absent, got "x"`,
+		},
+		{name: "optional absent", expected: map[string]any{"nick": Optional(Matches(".+"))}, actual: `{}`},
+		{
+			name:     "optional present",
+			expected: map[string]any{"nick": Optional(Matches(".+"))},
+			actual:   `{"nick":""}`,
+			wantErr:  "$.nick:",
+		},
+		{
+			name:     "not absent requires presence",
+			expected: map[string]any{"id": Not(Absent{})},
+			actual:   `{}`,
+			wantErr:  "$.id: missing field",
+		},
+		{name: "any absent", expected: map[string]any{"id": Any(Absent{}, Gt(0))}, actual: `{}`},
+		{
+			name:     "nested object",
+			expected: map[string]any{"user": Not(map[string]any{"role": "admin"})},
+			actual:   `{"user":{"role":"admin","id":1}}`,
+			wantErr:  `$.user: expected not {"role":"admin"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toExpectBody("test", tt.expected).Validate([]byte(tt.actual))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("expected error %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.HasPrefix(err.Error(), tt.wantErr):
+				t.Errorf("expected error %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCombinators_fileSyntax(t *testing.T) {
+	body := ExpectBody(`{
+		"status": {"$not": "deleted"},
+		"score": {"$nullable": {"$all": [{"$gte": 0}, {"$lte": 1}]}},
+		"kind": {"$any": ["a", "b"]},
+		"id": {"$one_of": [{"$gt": 0}, {"$lt": -100}]},
+		"password": {"$absent": true},
+		"nick": {"$optional": {"$matches": "^[a-z]+$"}}
+	}`)
+	if err := body.Validate([]byte(`{"status":"active","score":null,"kind":"b","id":3}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := body.Validate([]byte(`{"status":"active","score":0.5,"kind":"b","id":3,"password":"x"}`)); err == nil {
+		t.Error("expected error for present password")
+	}
+	if err := ExpectBody(`{"kind":{"$any":"a"}}`).Validate([]byte(`{"kind":"a"}`)); err == nil {
+		t.Error("expected error for non-array $any argument")
+	}
+}
+
+func TestTypeAndFormatMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher Matcher
+		actual  any
+		wantErr bool
+	}{
+		{name: "string", matcher: IsString{}, actual: "a"},
+		{name: "string fails", matcher: IsString{}, actual: 1.0, wantErr: true},
+		{name: "number", matcher: IsNumber{}, actual: 1.5},
+		{name: "number fails", matcher: IsNumber{}, actual: "1", wantErr: true},
+		{name: "integer", matcher: IsInteger{}, actual: 3.0},
+		{name: "integer fraction", matcher: IsInteger{}, actual: 3.5, wantErr: true},
+		{name: "bool", matcher: IsBool{}, actual: false},
+		{name: "array", matcher: IsArray{}, actual: []any{}},
+		{name: "object", matcher: IsObject{}, actual: map[string]any{}},
+		{name: "object fails", matcher: IsObject{}, actual: []any{}, wantErr: true},
+		{name: "null", matcher: IsNull{}, actual: nil},
+		{name: "null fails", matcher: IsNull{}, actual: "", wantErr: true},
+		{name: "uuid", matcher: UUID(0), actual: "0b8e5a4e-2f4b-11ee-be56-0242ac120002"},
+		{name: "uuid v4", matcher: UUID(4), actual: "f47ac10b-58cc-4372-a567-0e02b2c3d479"},
+		{name: "uuid wrong version", matcher: UUID(4), actual: "0b8e5a4e-2f4b-11ee-be56-0242ac120002", wantErr: true},
+		{name: "uuid malformed", matcher: UUID(0), actual: "f47ac10b58cc4372a5670e02b2c3d479", wantErr: true},
+		{name: "rfc3339", matcher: RFC3339, actual: "2024-05-01T12:00:00Z"},
+		{name: "rfc3339 fractional", matcher: RFC3339, actual: "2024-05-01T12:00:00.123+02:00"},
+		{name: "rfc3339 fails", matcher: RFC3339, actual: "2024-05-01 12:00:00", wantErr: true},
+		{name: "time format", matcher: TimeFormat(time.DateOnly), actual: "2024-05-01"},
+		{name: "email", matcher: Email{}, actual: "alice@example.com"},
+		{name: "email display name", matcher: Email{}, actual: "Alice <alice@example.com>", wantErr: true},
+		{name: "email fails", matcher: Email{}, actual: "alice", wantErr: true},
+		{name: "url", matcher: URL{}, actual: "https://example.com/a?b=c"},
+		{name: "url relative", matcher: URL{}, actual: "/a/b", wantErr: true},
+		{name: "base64", matcher: Base64{}, actual: "aGVsbG8="},
+		{name: "base64 fails", matcher: Base64{}, actual: "hello!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.matcher.Match(tt.actual)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Match(%v) error = %v, wantErr %v", tt.actual, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeMatchers(t *testing.T) {
+	now := time.Now().UTC()
+	vars := VarStore{"created": now.Add(-time.Minute).Format(time.RFC3339Nano), "count": 3}
+
+	tests := []struct {
+		name    string
+		body    string
+		actual  string
+		wantErr string
+	}{
+		{name: "within", body: `{"at":{"$within":"5s"}}`, actual: now.Format(time.RFC3339Nano)},
+		{
+			name:    "within fails",
+			body:    `{"at":{"$within":"5s"}}`,
+			actual:  now.Add(-time.Hour).Format(time.RFC3339),
+			wantErr: "$.at: expected within 5s of now",
+		},
+		{name: "after variable", body: `{"at":{"$after":"created"}}`, actual: now.Format(time.RFC3339Nano)},
+		{
+			name:    "after variable fails",
+			body:    `{"at":{"$after":"created"}}`,
+			actual:  now.Add(-time.Hour).Format(time.RFC3339),
+			wantErr: "$.at: expected after created = ",
+		},
+		{name: "before timestamp", body: `{"at":{"$before":"2100-01-01T00:00:00Z"}}`, actual: now.Format(time.RFC3339)},
+		{
+			name:   "nested in combinator",
+			body:   `{"at":{"$all":[{"$after":"created"},{"$within":"1m"}]}}`,
+			actual: now.Format(time.RFC3339Nano),
+		},
+		{
+			name:    "unknown ref",
+			body:    `{"at":{"$after":"missing"}}`,
+			actual:  now.Format(time.RFC3339),
+			wantErr: `$.at: after: "missing" is not a saved variable or timestamp`,
+		},
+		{
+			name:    "bad variable",
+			body:    `{"at":{"$after":"count"}}`,
+			actual:  now.Format(time.RFC3339),
+			wantErr: "$.at: after: variable count: expected RFC3339 timestamp, got number",
+		},
+		{
+			name:    "not a time",
+			body:    `{"at":{"$within":"1h"}}`,
+			actual:  "yesterday",
+			wantErr: `$.at: "yesterday" is not an RFC3339 timestamp`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExpectBody(tt.body).validate([]byte(`{"at":"`+tt.actual+`"}`), vars)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("expected error %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.HasPrefix(err.Error(), tt.wantErr):
+				t.Errorf("expected error %q, got %q", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTypeAndFormatMatchers_fileSyntax(t *testing.T) {
+	body := ExpectBody(`{
+		"id": {"$uuid": 4},
+		"ref": {"$uuid": true},
+		"name": {"$type": "string"},
+		"count": {"$type": "integer"},
+		"tags": {"$type": "array"},
+		"created_at": {"$time_format": "RFC3339"},
+		"day": {"$time_format": "2006-01-02"},
+		"email": {"$email": true},
+		"homepage": {"$url": true},
+		"avatar": {"$base64": true}
+	}`)
+	actual := `{
+		"id": "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		"ref": "0b8e5a4e-2f4b-11ee-be56-0242ac120002",
+		"name": "alice",
+		"count": 2,
+		"tags": [],
+		"created_at": "2024-05-01T12:00:00Z",
+		"day": "2024-05-01",
+		"email": "alice@example.com",
+		"homepage": "https://example.com",
+		"avatar": "aGVsbG8="
+	}`
+	if err := body.Validate([]byte(actual)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Builder matchers marshal to the same operators.
+	built := toExpectBody("test", map[string]any{
+		"id": UUID(4), "count": IsInteger{}, "created_at": RFC3339, "at": Within(time.Minute), "after": After("created"),
+	})
+	want := `{"after":{"$after":"created"},"at":{"$within":"1m0s"},"count":{"$type":"integer"},` +
+		`"created_at":{"$time_format":"RFC3339"},"id":{"$uuid":4}}`
+	if string(built) != want {
+		t.Errorf("expected %s, got %s", want, built)
+	}
+
+	for _, invalid := range []string{
+		`{"a":{"$type":"date"}}`,
+		`{"a":{"$uuid":9}}`,
+		`{"a":{"$uuid":"v4"}}`,
+		`{"a":{"$email":"yes"}}`,
+		`{"a":{"$within":"soon"}}`,
+		`{"a":{"$after":1}}`,
+	} {
+		if err := ExpectBody(invalid).Validate([]byte(`{"a":"x"}`)); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MismatchError describes where an actual body first diverged from its expectation.
+// Error includes a unified diff of the whole expected and actual bodies; reporters
+// can use Diff or ColorDiff to render it themselves.
+type MismatchError struct {
+	// Path is the JSON path of the mismatch, e.g. "$.items[0].price".
+	Path string
+	// Reason describes the mismatch, e.g. "expected 3, got 4".
+	Reason string
+	// Expected is the expected value at Path.
+	Expected any
+	// Actual is the actual value at Path, or the closest candidate element when
+	// an expected array element was not found.
+	Actual any
+	// Candidate is the JSON path of the closest actual array element when an
+	// expected element was not found; empty otherwise.
+	Candidate string
+
+	expectedBody any
+	actualBody   any
+}
+
+func (e *MismatchError) Error() string {
+	msg := e.Path + ": " + e.Reason
+	if diff := e.Diff(); diff != "" {
+		msg += "\n" + diff
+	}
+	return msg
+}
+
+// Diff returns a unified diff of the expected and actual bodies, or "" when
+// the bodies are not known.
+func (e *MismatchError) Diff() string {
+	if e.expectedBody == nil && e.actualBody == nil {
+		return ""
+	}
+	return unifiedDiff(diffLines(e.expectedBody), diffLines(e.actualBody))
+}
+
+// ColorDiff is Diff with ANSI colours for terminals.
+func (e *MismatchError) ColorDiff() string {
+	return colorDiff(e.Diff())
+}
+
+// withBodies records the full bodies the mismatch was found in, for Diff.
+func (e *MismatchError) withBodies(expected, actual any) *MismatchError {
+	e.expectedBody, e.actualBody = expected, actual
+	return e
+}
+
+// diffLines renders v as indented JSON lines; strings are split as raw text.
+func diffLines(v any) []string {
+	if s, ok := v.(string); ok {
+		return strings.Split(s, "\n")
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return strings.Split(fmt.Sprint(v), "\n")
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// ---- JSON paths ----
+
+//nolint:gochecknoglobals // compiled once
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func keyPath(path, key string) string {
+	if identRe.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// formatValue renders v as compact JSON for mismatch reasons.
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, json.Number:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// ---- closest candidate ----
+
+// closestElement returns the index of the element of actual that misses the
+// fewest expected leaves, or -1 if actual is empty.
+func closestElement(actual []any, expected any, o matchOpts) int {
+	best, bestScore := -1, 0
+	for i, elem := range actual {
+		score := mismatchScore(elem, expected, o)
+		if best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// mismatchScore counts the expected leaves that actual does not satisfy.
+func mismatchScore(actual, expected any, o matchOpts) int {
+	if _, ok := expected.(Matcher); ok {
+		if matchPath("$", actual, expected, o) != nil {
+			return 1
+		}
+		return 0
+	}
+	switch exp := expected.(type) {
+	case map[string]any:
+		act, ok := actual.(map[string]any)
+		if !ok {
+			return leafCount(exp)
+		}
+		n := 0
+		for k, v := range exp {
+			if a, ok := act[k]; ok {
+				n += mismatchScore(a, v, o)
+			} else if matchAbsent(v) != nil {
+				n += leafCount(v)
+			}
+		}
+		return n
+	case []any:
+		act, ok := actual.([]any)
+		if !ok {
+			return leafCount(exp)
+		}
+		n := 0
+		for _, e := range exp {
+			if i := closestElement(act, e, o); i >= 0 {
+				n += mismatchScore(act[i], e, o)
+			} else {
+				n += leafCount(e)
+			}
+		}
+		return n
+	default:
+		if matchPath("$", actual, expected, o) != nil {
+			return 1
+		}
+		return 0
+	}
+}
+
+func leafCount(v any) int {
+	switch val := v.(type) {
+	case map[string]any:
+		n := 0
+		for _, e := range val {
+			n += leafCount(e)
+		}
+		return max(n, 1)
+	case []any:
+		n := 0
+		for _, e := range val {
+			n += leafCount(e)
+		}
+		return max(n, 1)
+	default:
+		return 1
+	}
+}
+
+// ---- unified diff ----
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff from a to b, or "" if they are equal.
+func unifiedDiff(a, b []string) string {
+	ops := diffOps(a, b)
+
+	var out strings.Builder
+	out.WriteString("--- expected\n+++ actual\n")
+	changed := false
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of its hunk.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		changed = true
+		lo := max(first-diffContext, start)
+		hi := first
+		for hi < len(ops) {
+			if ops[hi].kind != ' ' {
+				hi++
+				continue
+			}
+			run := hi
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-hi > 2*diffContext {
+				hi = min(hi+diffContext, len(ops))
+				break
+			}
+			hi = run
+		}
+		writeHunk(&out, ops, lo, hi)
+		start = hi
+	}
+	if !changed {
+		return ""
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp, lo, hi int) {
+	aStart, bStart := 1, 1
+	for _, op := range ops[:lo] {
+		if op.kind != '+' {
+			aStart++
+		}
+		if op.kind != '-' {
+			bStart++
+		}
+	}
+	aLen, bLen := 0, 0
+	for _, op := range ops[lo:hi] {
+		if op.kind != '+' {
+			aLen++
+		}
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, op := range ops[lo:hi] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		out.WriteByte('\n')
+	}
+}
+
+// maxDiffCells bounds the LCS table; larger inputs are diffed as a whole replacement.
+const maxDiffCells = 1 << 22
+
+// diffOps computes a line edit script from a to b via longest common subsequence.
+func diffOps(a, b []string) []diffOp {
+	// Common prefix and suffix need no table.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:pre] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, lcsOps(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func lcsOps(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiReset = "\x1b[0m"
+)
+
+// colorDiff adds ANSI colours to a unified diff.
+func colorDiff(diff string) string {
+	if diff == "" {
+		return ""
+	}
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = ansiCyan + line + ansiReset
+		case strings.HasPrefix(line, "-"):
+			lines[i] = ansiRed + line + ansiReset
+		case strings.HasPrefix(line, "+"):
+			lines[i] = ansiGreen + line + ansiReset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
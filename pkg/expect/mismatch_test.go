@@ -0,0 +1,154 @@
+package expect
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpectBody_ValidateMismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		expected      string
+		actual        string
+		wantPath      string
+		wantReason    string
+		wantCandidate string
+	}{
+		{
+			name:          "nested value",
+			expected:      `{"items":[{"id":1,"price":3}]}`,
+			actual:        `{"items":[{"id":1,"price":4}]}`,
+			wantPath:      "$.items[0]",
+			wantReason:    "closest is $.items[0] ($.items[0].price: expected 3, got 4)",
+			wantCandidate: "$.items[0]",
+		},
+		{
+			name:          "closest candidate",
+			expected:      `{"items":[{"id":2,"name":"b","price":3}]}`,
+			actual:        `{"items":[{"id":1,"name":"a","price":1},{"id":2,"name":"b","price":4}]}`,
+			wantPath:      "$.items[0]",
+			wantReason:    "closest is $.items[1] ($.items[1].price: expected 3, got 4)",
+			wantCandidate: "$.items[1]",
+		},
+		{
+			name:       "scalar",
+			expected:   `{"user":{"name":"alice"}}`,
+			actual:     `{"user":{"name":"bob"}}`,
+			wantPath:   "$.user.name",
+			wantReason: `expected "alice", got "bob"`,
+		},
+		{
+			name:       "missing field",
+			expected:   `{"user":{"email":"a@b.c"}}`,
+			actual:     `{"user":{"name":"bob"}}`,
+			wantPath:   "$.user.email",
+			wantReason: "missing field",
+		},
+		{
+			name:       "type",
+			expected:   `{"tags":["a"]}`,
+			actual:     `{"tags":"a"}`,
+			wantPath:   "$.tags",
+			wantReason: "expected array, got string",
+		},
+		{
+			name:       "quoted key",
+			expected:   `{"content-type":"json"}`,
+			actual:     `{"content-type":"xml"}`,
+			wantPath:   `$["content-type"]`,
+			wantReason: `expected "json", got "xml"`,
+		},
+		{
+			name:       "raw body",
+			expected:   `hello`,
+			actual:     `goodbye`,
+			wantPath:   "$",
+			wantReason: "unexpected body: goodbye",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExpectBody(tt.expected).Validate([]byte(tt.actual))
+			var mismatch *MismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("expected *MismatchError, got %v", err)
+			}
+			if mismatch.Path != tt.wantPath {
+				t.Errorf("expected path %q, got %q", tt.wantPath, mismatch.Path)
+			}
+			if !strings.Contains(mismatch.Reason, tt.wantReason) {
+				t.Errorf("expected reason to contain %q, got %q", tt.wantReason, mismatch.Reason)
+			}
+			if mismatch.Candidate != tt.wantCandidate {
+				t.Errorf("expected candidate %q, got %q", tt.wantCandidate, mismatch.Candidate)
+			}
+			if mismatch.Diff() == "" {
+				t.Error("expected a diff")
+			}
+		})
+	}
+}
+
+func TestMismatchError_Diff(t *testing.T) {
+	err := ExpectBody(`{"count":3,"name":"alice"}`).Validate([]byte(`{"count":4,"name":"alice","id":7}`))
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected *MismatchError, got %v", err)
+	}
+
+	want := strings.Join([]string{
+		"--- expected",
+		"+++ actual",
+		"@@ -1,4 +1,5 @@",
+		" {",
+		`-  "count": 3,`,
+		`+  "count": 4,`,
+		`+  "id": 7,`,
+		`   "name": "alice"`,
+		" }",
+	}, "\n")
+	if mismatch.Diff() != want {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", mismatch.Diff(), want)
+	}
+	if !strings.HasPrefix(err.Error(), "$.count: expected 3, got 4\n--- expected") {
+		t.Errorf("expected error to lead with path and include diff, got:\n%s", err)
+	}
+	if !strings.Contains(mismatch.ColorDiff(), ansiRed+`-  "count": 3,`+ansiReset) {
+		t.Errorf("expected coloured removal, got %q", mismatch.ColorDiff())
+	}
+}
+
+func TestUnifiedDiff_hunks(t *testing.T) {
+	var a, b []string
+	for i := range 20 {
+		line := strings.Repeat("x", i)
+		a = append(a, line)
+		b = append(b, line)
+	}
+	b[2], b[17] = "changed", "changed"
+
+	diff := unifiedDiff(a, b)
+	if got := strings.Count(diff, "@@ -"); got != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", got, diff)
+	}
+	if !strings.Contains(diff, "@@ -1,6 +1,6 @@") || !strings.Contains(diff, "@@ -15,6 +15,6 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", diff)
+	}
+	if unifiedDiff(a, a) != "" {
+		t.Error("expected no diff for equal input")
+	}
+}
+
+func TestPartialMatch_matcherPath(t *testing.T) {
+	err := partialMatch(map[string]any{"user": map[string]any{"age": float64(3)}}, map[string]any{
+		"user": map[string]any{"age": Gt(10)},
+	})
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected *MismatchError, got %v", err)
+	}
+	if mismatch.Path != "$.user.age" || mismatch.Reason != "expected > 10, got 3" {
+		t.Errorf("unexpected mismatch: %s", mismatch)
+	}
+}
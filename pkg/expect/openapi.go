@@ -0,0 +1,351 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI is an OpenAPI 3 document describing an HTTP service. Attached to an
+// HTTPConnection with WithOpenAPI, it checks every request and response on the
+// connection against the operation they match.
+type OpenAPI struct {
+	doc        map[string]any // the document, with external $refs bundled under $defs
+	operations []*openAPIOperation
+	basePaths  []string // URL paths of servers, tried as prefixes of request paths
+}
+
+// openAPIOperation is one method of a path item.
+type openAPIOperation struct {
+	method     string // upper case
+	path       string // path template, e.g. "/users/{id}"
+	pattern    *regexp.Regexp
+	params     int // number of template parameters; fewer is more specific
+	op         map[string]any
+	parameters []map[string]any // path item and operation parameters, dereferenced
+}
+
+//nolint:gochecknoglobals // read-only lookup table
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// LoadOpenAPI reads an OpenAPI 3 document from a JSON or YAML file. $refs to
+// other files resolve relative to it.
+func LoadOpenAPI(path string) (*OpenAPI, error) {
+	return loadOpenAPI(path, fileSource{})
+}
+
+// ParseOpenAPI parses an OpenAPI 3 document in JSON or YAML. $refs to other
+// files resolve relative to the working directory.
+func ParseOpenAPI(data []byte) (*OpenAPI, error) {
+	ext := ".yaml"
+	if json.Valid(data) {
+		ext = ".json"
+	}
+	doc, err := decodeSchema(data, ext)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: openapi: %w", err)
+	}
+	return newOpenAPI(doc, &schemaBundler{defs: map[string]any{}})
+}
+
+func loadOpenAPI(path string, src fileSource) (*OpenAPI, error) {
+	b := &schemaBundler{src: src, root: src.join("", path), defs: map[string]any{}}
+	doc, err := b.read(b.root)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: openapi: %w", err)
+	}
+	return newOpenAPI(doc, b)
+}
+
+func newOpenAPI(doc any, b *schemaBundler) (*OpenAPI, error) {
+	bundled, err := b.bundle(doc)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: openapi: %w", err)
+	}
+	root, _ := bundled.(map[string]any)
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("go-expect: openapi: not an OpenAPI 3 document")
+	}
+
+	spec := &OpenAPI{doc: root}
+	servers, _ := root["servers"].([]any)
+	for _, s := range servers {
+		server, _ := s.(map[string]any)
+		if u, ok := server["url"].(string); ok {
+			if base := serverBasePath(u); base != "" {
+				spec.basePaths = append(spec.basePaths, base)
+			}
+		}
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item := spec.deref(paths[path])
+		pattern, params := pathPattern(path)
+		for _, method := range openAPIMethods {
+			op := spec.deref(item[method])
+			if op == nil {
+				continue
+			}
+			spec.operations = append(spec.operations, &openAPIOperation{
+				method:     strings.ToUpper(method),
+				path:       path,
+				pattern:    pattern,
+				params:     params,
+				op:         op,
+				parameters: spec.parameters(item, op),
+			})
+		}
+	}
+	return spec, nil
+}
+
+// serverBasePath returns the path of a server URL such as
+// "https://api.example.com/v1", without variables or a trailing slash.
+func serverBasePath(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+		if j := strings.Index(u, "/"); j >= 0 {
+			u = u[j:]
+		} else {
+			u = ""
+		}
+	}
+	if strings.Contains(u, "{") {
+		return ""
+	}
+	return strings.TrimRight(u, "/")
+}
+
+//nolint:gochecknoglobals // compiled once
+var pathParamRe = regexp.MustCompile(`\{[^}/]+\}`)
+
+// pathPattern compiles a path template such as "/users/{id}".
+func pathPattern(path string) (*regexp.Regexp, int) {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	params := pathParamRe.FindAllStringIndex(path, -1)
+	for _, loc := range params {
+		b.WriteString(regexp.QuoteMeta(path[last:loc[0]]))
+		b.WriteString("[^/]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(path[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()), len(params)
+}
+
+// deref follows $refs to the object they point at; nil if v is not an object.
+func (o *OpenAPI) deref(v any) map[string]any {
+	for range maxSchemaDepth {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		v, _ = resolvePointer(o.doc, ref)
+	}
+	return nil
+}
+
+// parameters merges the path item's parameters with the operation's, which
+// override them by name and location.
+func (o *OpenAPI) parameters(item, op map[string]any) []map[string]any {
+	var out []map[string]any
+	for _, source := range []map[string]any{item, op} {
+		list, _ := source["parameters"].([]any)
+		for _, p := range list {
+			param := o.deref(p)
+			if param == nil {
+				continue
+			}
+			out = slices.DeleteFunc(out, func(q map[string]any) bool {
+				return q["name"] == param["name"] && q["in"] == param["in"]
+			})
+			out = append(out, param)
+		}
+	}
+	return out
+}
+
+// operation returns the operation matching method and the request's URL path,
+// preferring the path template with the fewest parameters. The path of the
+// connection URL is a base path, like those of the document's servers.
+func (o *OpenAPI) operation(method, urlPath, connURL string) *openAPIOperation {
+	candidates := []string{urlPath}
+	for _, base := range append([]string{serverBasePath(connURL)}, o.basePaths...) {
+		if base == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(urlPath, base); ok && strings.HasPrefix(rest, "/") {
+			candidates = append(candidates, rest)
+		}
+	}
+	var best *openAPIOperation
+	for _, p := range candidates {
+		for _, op := range o.operations {
+			if op.method == method && op.pattern.MatchString(p) && (best == nil || op.params < best.params) {
+				best = op
+			}
+		}
+	}
+	return best
+}
+
+// ContractError lists the ways an HTTP request and its response break the
+// OpenAPI document of their connection.
+type ContractError struct {
+	// Method and Path identify the operation: Path is its path template, or the
+	// request path when no operation matched.
+	Method string
+	Path   string
+	// Status is the response status code.
+	Status int
+	// Violations describe each breach, e.g. "response body: #/id: expected integer, got string".
+	Violations []string
+}
+
+func (e *ContractError) Error() string {
+	msg := fmt.Sprintf("openapi: %s %s %d: ", e.Method, e.Path, e.Status)
+	if len(e.Violations) == 1 {
+		return msg + e.Violations[0]
+	}
+	return msg + fmt.Sprintf("%d violations:\n  ", len(e.Violations)) + strings.Join(e.Violations, "\n  ")
+}
+
+// check checks a request and its response against the operation they match.
+func (o *OpenAPI) check(connURL string, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	op := o.operation(req.Method, req.URL.Path, connURL)
+	if op == nil {
+		return &ContractError{
+			Method: req.Method, Path: req.URL.Path, Status: resp.StatusCode,
+			Violations: []string{"undocumented endpoint"},
+		}
+	}
+	e := &ContractError{Method: op.method, Path: op.path, Status: resp.StatusCode}
+	e.Violations = append(e.Violations, o.checkRequest(op, req, reqBody)...)
+	e.Violations = append(e.Violations, o.checkResponse(op, resp, respBody)...)
+	if len(e.Violations) > 0 {
+		return e
+	}
+	return nil
+}
+
+func (o *OpenAPI) checkRequest(op *openAPIOperation, req *http.Request, body []byte) []string {
+	var violations []string
+	query := req.URL.Query()
+	for _, param := range op.parameters {
+		name, _ := param["name"].(string)
+		if param["required"] != true {
+			continue
+		}
+		switch param["in"] {
+		case "query":
+			if !query.Has(name) {
+				violations = append(violations,
+					fmt.Sprintf("request query parameter %q: missing required parameter", name))
+			}
+		case "header":
+			if req.Header.Get(name) == "" {
+				violations = append(violations, fmt.Sprintf("request header %s: missing required header", name))
+			}
+		}
+	}
+
+	requestBody := o.deref(op.op["requestBody"])
+	if requestBody == nil {
+		return violations
+	}
+	if len(body) == 0 {
+		if requestBody["required"] == true {
+			violations = append(violations, "request body: missing required body")
+		}
+		return violations
+	}
+	contentType := req.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return append(violations, o.checkContent("request body", requestBody, contentType, body)...)
+}
+
+func (o *OpenAPI) checkResponse(op *openAPIOperation, resp *http.Response, body []byte) []string {
+	responses, _ := op.op["responses"].(map[string]any)
+	response := o.deref(responses[strconv.Itoa(resp.StatusCode)])
+	if response == nil {
+		response = o.deref(responses[fmt.Sprintf("%dXX", resp.StatusCode/100)])
+	}
+	if response == nil {
+		response = o.deref(responses["default"])
+	}
+	if response == nil {
+		return []string{fmt.Sprintf("undocumented response status %d", resp.StatusCode)}
+	}
+
+	var violations []string
+	headers, _ := response["headers"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		if o.deref(headers[name])["required"] == true && resp.Header.Get(name) == "" {
+			violations = append(violations, fmt.Sprintf("response header %s: missing required header", name))
+		}
+	}
+	if len(body) > 0 {
+		contentType := resp.Header.Get("Content-Type")
+		violations = append(violations, o.checkContent("response body", response, contentType, body)...)
+	}
+	return violations
+}
+
+// checkContent checks a body against the schema its content type has in the
+// content map of a request body or response object. Only JSON bodies are
+// validated.
+func (o *OpenAPI) checkContent(what string, object map[string]any, contentType string, body []byte) []string {
+	content, _ := object["content"].(map[string]any)
+	if len(content) == 0 {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	media := o.deref(content[mediaType])
+	if media == nil {
+		media = o.deref(content[strings.Split(mediaType, "/")[0]+"/*"])
+	}
+	if media == nil {
+		media = o.deref(content["*/*"])
+	}
+	if media == nil {
+		return []string{fmt.Sprintf("%s: undocumented content type %q", what, mediaType)}
+	}
+	schema, ok := media["schema"]
+	if !ok || !isJSONMediaType(mediaType) {
+		return nil
+	}
+
+	var actual any
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return []string{fmt.Sprintf("%s: invalid JSON: %v", what, err)}
+	}
+	v := &schemaValidator{root: o.doc, patterns: map[string]*regexp.Regexp{}}
+	v.validate(schema, actual, "")
+	violations := make([]string, len(v.violations))
+	for i, violation := range v.violations {
+		violations[i] = fmt.Sprintf("%s: #%s: %s", what, violation.pointer, violation.message)
+	}
+	return violations
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
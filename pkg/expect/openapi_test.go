@@ -0,0 +1,320 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info: {title: pets, version: "1"}
+servers:
+  - url: https://pets.example.com/v1
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer}}
+      responses:
+        200:
+          description: pets
+          headers:
+            X-Total: {required: true, schema: {type: integer}}
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}
+    post:
+      parameters:
+        - $ref: "#/components/parameters/Tenant"
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/Pet"}
+      responses:
+        201: {$ref: "#/components/responses/Pet"}
+        4XX: {description: client error}
+  /pets/{id}:
+    get:
+      responses:
+        200: {$ref: "#/components/responses/Pet"}
+  /pets/mine:
+    get:
+      responses:
+        200: {description: mine}
+components:
+  parameters:
+    Tenant: {name: X-Tenant, in: header, required: true, schema: {type: string}}
+  responses:
+    Pet:
+      description: a pet
+      content:
+        application/json:
+          schema: {$ref: "#/components/schemas/Pet"}
+  schemas:
+    Pet:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        tag: {type: string, nullable: true}
+`
+
+// petstore serves canned responses keyed by "METHOD path".
+func petstore(t *testing.T) *httptest.Server {
+	t.Helper()
+	responses := map[string]struct {
+		status int
+		header string
+		body   string
+	}{
+		"GET /v1/pets":        {200, "X-Total", `[{"id":1,"name":"rex","tag":null}]`},
+		"GET /v1/pets/1":      {200, "", `{"id":1,"name":"rex"}`},
+		"GET /v1/pets/2":      {200, "", `{"id":"2"}`},
+		"GET /v1/pets/mine":   {200, "", ``},
+		"POST /v1/pets":       {201, "", `{"id":3,"name":"tom"}`},
+		"GET /v1/owners":      {200, "", `[]`},
+		"DELETE /v1/pets/1":   {204, "", ``},
+		"GET /v1/pets/teapot": {418, "", ``},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if resp.header != "" {
+			w.Header().Set(resp.header, "1")
+		}
+		if resp.body != "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(resp.status)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAPI_check(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI error: %v", err)
+	}
+	srv := petstore(t)
+	conn := HTTP("pets", srv.URL+"/v1").WithOpenAPI(spec)
+
+	tenant := map[string]string{"X-Tenant": "acme"}
+	tests := []struct {
+		name string
+		req  *HTTPRequest
+		want []string // violations; none means the exchange conforms
+	}{
+		{
+			name: "conforms",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets", Query: map[string]string{"limit": "1"}},
+		},
+		{
+			name: "missing query parameter",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets"},
+			want: []string{`openapi: GET /pets 200: request query parameter "limit": missing required parameter`},
+		},
+		{
+			name: "path template",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets/1"},
+		},
+		{
+			name: "literal path preferred over template",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets/mine"},
+		},
+		{
+			name: "response body",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets/2"},
+			want: []string{
+				"openapi: GET /pets/{id} 200: 2 violations:",
+				"response body: #/id: expected integer, got string",
+				"response body: #/name: missing required property",
+			},
+		},
+		{
+			name: "request body and header",
+			req:  &HTTPRequest{Method: "POST", Path: "/pets", Body: []byte(`{"name":"tom"}`)},
+			want: []string{
+				"openapi: POST /pets 201: 2 violations:",
+				"request header X-Tenant: missing required header",
+				"request body: #/id: missing required property",
+			},
+		},
+		{
+			name: "missing request body",
+			req:  &HTTPRequest{Method: "POST", Path: "/pets", Header: tenant},
+			want: []string{"request body: missing required body"},
+		},
+		{
+			name: "request body conforms",
+			req:  &HTTPRequest{Method: "POST", Path: "/pets", Header: tenant, Body: []byte(`{"id":3,"name":"tom"}`)},
+		},
+		{
+			name: "undocumented content type",
+			req: &HTTPRequest{
+				Method: "POST", Path: "/pets", Body: []byte(`name=tom`),
+				Header: map[string]string{"X-Tenant": "acme", "Content-Type": "application/x-www-form-urlencoded"},
+			},
+			want: []string{`request body: undocumented content type "application/x-www-form-urlencoded"`},
+		},
+		{
+			name: "undocumented endpoint",
+			req:  &HTTPRequest{Method: "GET", Path: "/owners"},
+			want: []string{"openapi: GET /v1/owners 200: undocumented endpoint"},
+		},
+		{
+			name: "undocumented method",
+			req:  &HTTPRequest{Method: "DELETE", Path: "/pets/1"},
+			want: []string{"openapi: DELETE /v1/pets/1 204: undocumented endpoint"},
+		},
+		{
+			name: "undocumented status",
+			req:  &HTTPRequest{Method: "GET", Path: "/pets/teapot"},
+			want: []string{"openapi: GET /pets/{id} 418: undocumented response status 418"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.req.Run(context.Background(), conn, nil)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var contract *ContractError
+			if !errors.As(err, &contract) {
+				t.Fatalf("expected *ContractError, got %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPI_responseHeader(t *testing.T) {
+	spec, err := ParseOpenAPI([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	// The server URL has no /v1, so the path matches through servers[].url.
+	conn := HTTP("pets", srv.URL).WithOpenAPI(spec)
+	req := &HTTPRequest{Method: "GET", Path: "/v1/pets", Query: map[string]string{"limit": "1"}}
+	_, err = req.Run(context.Background(), conn, nil)
+	if err == nil || err.Error() != "openapi: GET /pets 200: response header X-Total: missing required header" {
+		t.Errorf("expected missing header error, got %v", err)
+	}
+}
+
+func TestParseOpenAPI_invalid(t *testing.T) {
+	tests := map[string]string{
+		"swagger 2":    `{"swagger": "2.0", "paths": {}}`,
+		"bad ref":      `{"openapi": "3.1.0", "paths": {"/a": {"$ref": "#/components/pathItems/missing"}}}`,
+		"remote ref":   `{"openapi": "3.1.0", "paths": {"/a": {"$ref": "https://example.com/a.json"}}}`,
+		"invalid yaml": "openapi: [",
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseOpenAPI([]byte(spec)); err == nil || !strings.HasPrefix(err.Error(), "go-expect: openapi: ") {
+				t.Errorf("expected openapi error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadFile_openapi(t *testing.T) {
+	dir := t.TempDir()
+	srv := petstore(t)
+	files := map[string]string{
+		"api/pets.yaml": `
+openapi: 3.1.0
+paths:
+  /pets/{id}:
+    get:
+      responses:
+        "200":
+          description: a pet
+          content:
+            application/json:
+              schema: {$ref: "schemas/pet.json"}
+`,
+		"api/schemas/pet.json": `{"type": "object", "required": ["id", "name"]}`,
+		"tests/pets.yaml": `
+connections:
+  - name: pets
+    type: http
+    url: http://localhost:1/v1
+    openapi: ../api/pets.yaml
+scenarios:
+  - name: pets
+    steps:
+      - request: {method: GET, endpoint: /pets/1}
+        expect: {status: 200}
+      - request: {method: GET, endpoint: /pets/2}
+        expect: {status: 200}
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suite, err := LoadFile(filepath.Join(dir, "tests", "pets.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	// Retargeting the connection keeps its spec.
+	if err := suite.WithConnectionURL("pets", srv.URL+"/v1"); err != nil {
+		t.Fatal(err)
+	}
+	conn := suite.connections["pets"].(*HTTPConnection)
+	if conn.OpenAPI == nil || conn.URL != srv.URL+"/v1" {
+		t.Fatalf("expected retargeted connection with spec, got %+v", conn)
+	}
+
+	req := suite.scenarios[0].steps[0].Request.(*HTTPRequest)
+	if _, err := req.Run(context.Background(), conn, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	req = suite.scenarios[0].steps[1].Request.(*HTTPRequest)
+	_, err = req.Run(context.Background(), conn, nil)
+	if err == nil || !strings.Contains(err.Error(), "response body: #/name: missing required property") {
+		t.Errorf("expected violation from the referenced schema, got %v", err)
+	}
+
+	t.Run("non-http connection", func(t *testing.T) {
+		_, err := LoadYAML([]byte(`
+connections:
+  - {name: db, type: grpc, url: "localhost:9090", openapi: api.yaml}
+`))
+		if err == nil || !strings.Contains(err.Error(), "openapi is only supported for http connections") {
+			t.Errorf("expected unsupported openapi error, got %v", err)
+		}
+	})
+}
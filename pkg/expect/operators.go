@@ -0,0 +1,312 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Expected bodies may contain operator objects: single-key objects whose key
+// is "$" and the name of a registered operator, such as {"$strict": {...}} or
+// {"$gt": 0}. They are how matchers survive JSON, whether marshalled by the
+// builder or written in a YAML or JSON file. Operators are looked up in the
+// registry (see RegisterMatcher). Other keys starting with "$", such as
+// {"$ref": ...}, are literal, and "$$" escapes an operator name: {"$$gt": 0}
+// matches the literal object {"$gt": 0}.
+
+// operator returns the operator name of a single match mode.
+func (m matchMode) operator() string {
+	switch m {
+	case modeStrict:
+		return "$strict"
+	case modeOrdered:
+		return "$ordered"
+	case modeExactLength:
+		return "$exactly"
+	default:
+		return fmt.Sprintf("$mode%d", m)
+	}
+}
+
+// decodeOperators returns v with every operator object replaced by its matcher.
+func decodeOperators(v any) (any, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		if op, arg, ok := operatorObject(val); ok {
+			return decodeOperator(op, arg)
+		}
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			d, err := decodeOperators(e)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			if strings.HasPrefix(k, "$$") {
+				k = k[1:]
+			}
+			out[k] = d
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, e := range val {
+			d, err := decodeOperators(e)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = d
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// operatorObject reports whether m is a single-key object naming a
+// registered operator.
+func operatorObject(m map[string]any) (op string, arg any, ok bool) {
+	if len(m) != 1 {
+		return "", nil, false
+	}
+	for k, v := range m {
+		op, arg = k, v
+	}
+	if !strings.HasPrefix(op, "$") || strings.HasPrefix(op, "$$") {
+		return "", nil, false
+	}
+	_, err := matchers.matcher(op[1:])
+	return op, arg, err == nil
+}
+
+func decodeOperator(op string, arg any) (any, error) {
+	build, err := matchers.matcher(strings.TrimPrefix(op, "$"))
+	if err != nil {
+		return nil, err
+	}
+	// A JSON schema's own keywords, such as {"$ref": ...}, are not operators.
+	inner := arg
+	if op != "$json_schema" {
+		if inner, err = decodeOperators(arg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	m, err := build(inner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return m, nil
+}
+
+// operatorJSON marshals the operator object {"$name": arg}.
+func operatorJSON(name string, arg any) ([]byte, error) {
+	return json.Marshal(map[string]any{"$" + name: arg})
+}
+
+// builtinMatchers returns the operators for the matchers in this package.
+func builtinMatchers() map[string]MatcherFunc {
+	return map[string]MatcherFunc{
+		"strict":  func(arg any) (Matcher, error) { return Strict(arg), nil },
+		"ordered": func(arg any) (Matcher, error) { return decodeArrayMode(modeOrdered, arg) },
+		"exactly": func(arg any) (Matcher, error) { return decodeArrayMode(modeExactLength, arg) },
+
+		"contains": func(arg any) (Matcher, error) {
+			s, err := stringArg(arg)
+			return Contains(s), err
+		},
+		"matches": func(arg any) (Matcher, error) {
+			s, err := stringArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := regexp.Compile(s); err != nil {
+				return nil, err
+			}
+			return Matches(s), nil
+		},
+		"not_empty": flagMatcher(NotEmpty{}),
+
+		"gt":  func(arg any) (Matcher, error) { n, err := numberArg(arg); return Gt(n), err },
+		"gte": func(arg any) (Matcher, error) { n, err := numberArg(arg); return Gte(n), err },
+		"lt":  func(arg any) (Matcher, error) { n, err := numberArg(arg); return Lt(n), err },
+		"lte": func(arg any) (Matcher, error) { n, err := numberArg(arg); return Lte(n), err },
+
+		"not":      func(arg any) (Matcher, error) { return Not(arg), nil },
+		"nullable": func(arg any) (Matcher, error) { return Nullable(arg), nil },
+		"optional": func(arg any) (Matcher, error) { return Optional(arg), nil },
+		"absent":   flagMatcher(Absent{}),
+		"all": func(arg any) (Matcher, error) {
+			vs, err := arrayArg(arg)
+			return All(vs...), err
+		},
+		"any": func(arg any) (Matcher, error) {
+			vs, err := arrayArg(arg)
+			return Any(vs...), err
+		},
+		"one_of": func(arg any) (Matcher, error) {
+			vs, err := arrayArg(arg)
+			return OneOf(vs...), err
+		},
+
+		"type":        decodeType,
+		"uuid":        decodeUUID,
+		"time_format": decodeTimeFormat,
+		"email":       flagMatcher(Email{}),
+		"url":         flagMatcher(URL{}),
+		"base64":      flagMatcher(Base64{}),
+		"within": func(arg any) (Matcher, error) {
+			s, err := stringArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, err
+			}
+			return Within(d), nil
+		},
+		"after":  func(arg any) (Matcher, error) { s, err := stringArg(arg); return After(s), err },
+		"before": func(arg any) (Matcher, error) { s, err := stringArg(arg); return Before(s), err },
+
+		// Loaded files resolve their schemas against the file first, in
+		// loadFileSchemas; bodies given in Go resolve them like JSONSchema, against
+		// the working directory.
+		"json_schema": func(arg any) (Matcher, error) { return newJSONSchema(arg, fileSource{}) },
+
+		"length": func(arg any) (Matcher, error) {
+			n, err := numberArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			if n != math.Trunc(n) || n < 0 {
+				return nil, fmt.Errorf("expected a non-negative integer, got %v", n)
+			}
+			return Length(int(n)), nil
+		},
+	}
+}
+
+// flagMatcher decodes an operator whose only argument is true, e.g. {"$email": true}.
+func flagMatcher(m Matcher) MatcherFunc {
+	return func(arg any) (Matcher, error) {
+		if arg != true {
+			return nil, fmt.Errorf("expected true, got %s", formatValue(arg))
+		}
+		return m, nil
+	}
+}
+
+func decodeType(arg any) (Matcher, error) {
+	s, err := stringArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	switch s {
+	case "string":
+		return IsString{}, nil
+	case "number":
+		return IsNumber{}, nil
+	case "integer":
+		return IsInteger{}, nil
+	case "boolean":
+		return IsBool{}, nil
+	case "array":
+		return IsArray{}, nil
+	case "object":
+		return IsObject{}, nil
+	case "null":
+		return IsNull{}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q, want string, number, integer, boolean, array, object or null", s)
+	}
+}
+
+// decodeUUID accepts true for any version or a version number from 1 to 8.
+func decodeUUID(arg any) (Matcher, error) {
+	if arg == true {
+		return UUID(0), nil
+	}
+	n, err := numberArg(arg)
+	if err != nil {
+		return nil, fmt.Errorf("expected true or a version number, got %s", jsonType(arg))
+	}
+	if n != math.Trunc(n) || n < 1 || n > 8 {
+		return nil, fmt.Errorf("expected a UUID version from 1 to 8, got %v", n)
+	}
+	return UUID(int(n)), nil
+}
+
+// decodeTimeFormat accepts a layout or the name of one, e.g. "RFC3339".
+func decodeTimeFormat(arg any) (Matcher, error) {
+	s, err := stringArg(arg)
+	if err != nil {
+		return nil, err
+	}
+	if layout, ok := timeLayouts[s]; ok {
+		return TimeFormat(layout), nil
+	}
+	return TimeFormat(s), nil
+}
+
+// decodeArrayMode applies an array mode to an array, or to every array within an object.
+func decodeArrayMode(mode matchMode, arg any) (Matcher, error) {
+	switch val := arg.(type) {
+	case []any:
+		return arrayMode(mode, val), nil
+	case modeMatcher:
+		return modeMatcher{mode: val.mode | mode, value: val.value}, nil
+	case map[string]any:
+		return modeMatcher{mode: mode, value: val}, nil
+	default:
+		return nil, fmt.Errorf("expected array or object, got %s", jsonType(arg))
+	}
+}
+
+func stringArg(arg any) (string, error) {
+	s, ok := arg.(string)
+	if !ok {
+		return "", fmt.Errorf("expected string, got %s", jsonType(arg))
+	}
+	return s, nil
+}
+
+func arrayArg(arg any) ([]any, error) {
+	vs, ok := arg.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected array, got %s", jsonType(arg))
+	}
+	return vs, nil
+}
+
+func numberArg(arg any) (float64, error) {
+	n, ok := toFloat(arg)
+	if !ok {
+		return 0, fmt.Errorf("expected number, got %s", jsonType(arg))
+	}
+	return n, nil
+}
+
+// unwrapModes strips match modes from an expected value, leaving the plain
+// document for diffing.
+func unwrapModes(v any) any {
+	switch val := v.(type) {
+	case modeMatcher:
+		return unwrapModes(val.value)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			out[k] = unwrapModes(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, e := range val {
+			out[i] = unwrapModes(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
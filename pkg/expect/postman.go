@@ -0,0 +1,463 @@
+package expect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LoadPostman converts a Postman collection (format v2.0 or v2.1) into a Suite.
+// Each folder becomes a scenario of its requests, in order, and requests
+// outside folders form a scenario named after the collection. Every origin,
+// or leading {{variable}} such as {{baseUrl}}, becomes an HTTP connection.
+//
+// Collection variables with a value are inlined; other {{var}}s become {var}
+// placeholders, filled by test scripts that call pm.environment.set (or
+// pm.collectionVariables.set) with a field of pm.response.json(), which map
+// onto Save. As scenarios do not share variables, a collection whose folders
+// use variables saved in other folders becomes a single scenario.
+//
+// pm.response.to.have.status(n) in a test script, or else the first saved
+// example response, sets ExpectStatus. Bearer and basic auth are sent as
+// Authorization headers; other scripts and auth types are ignored.
+func LoadPostman(data []byte) (*Suite, error) {
+	var c postmanCollection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("go-expect: parse postman collection: %w", err)
+	}
+	p := &postmanImporter{
+		values: make(map[string]string),
+		setIn:  make(map[string]map[string]bool),
+	}
+	for _, v := range c.Variable {
+		if v.Value != nil && !v.Disabled {
+			p.values[v.Key] = fmt.Sprint(v.Value)
+		}
+	}
+
+	p.collection = c.Info.Name
+	if p.collection == "" {
+		p.collection = "collection"
+	}
+	p.collectSaved("", c.Item)
+	scenarios, err := p.folder("", c.Item, c.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("go-expect: postman: %w", err)
+	}
+	if p.shared && len(scenarios) > 1 {
+		// Scenarios do not share variables, so a collection whose folders pass
+		// values to each other runs as one scenario, as Postman runs it.
+		merged := NewScenario(p.collection)
+		for _, sc := range scenarios {
+			merged.steps = append(merged.steps, sc.steps...)
+		}
+		scenarios = []*Scenario{merged}
+	}
+	return NewSuite().WithConnections(p.conns.conns...).WithScenarios(scenarios...), nil
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+// postmanItem is a request, or a folder of items.
+type postmanItem struct {
+	Name     string          `json:"name"`
+	Item     []postmanItem   `json:"item"`
+	Request  *postmanRequest `json:"request"`
+	Event    []postmanEvent  `json:"event"`
+	Auth     *postmanAuth    `json:"auth"`
+	Response []struct {
+		Code int `json:"code"`
+	} `json:"response"`
+}
+
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanVariable `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+// UnmarshalJSON accepts a request written as just its URL.
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = postmanRequest{Method: http.MethodGet, URL: postmanURL{Raw: raw}}
+		return nil
+	}
+	type plain postmanRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Variable []postmanVariable `json:"variable"` // values of :name path segments
+}
+
+// UnmarshalJSON accepts a URL written as a string.
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = postmanURL{Raw: raw}
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanVariable `json:"urlencoded"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer"`
+	Basic  []postmanVariable `json:"basic"`
+}
+
+// postmanParam returns the value of the named auth parameter.
+func postmanParam(params []postmanVariable, key string) string {
+	for _, p := range params {
+		if p.Key == key && p.Value != nil {
+			return fmt.Sprint(p.Value)
+		}
+	}
+	return ""
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanLines `json:"exec"`
+	} `json:"script"`
+}
+
+// postmanLines is script source, written as a list of lines or a single string.
+type postmanLines []string
+
+func (l *postmanLines) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = postmanLines{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// testScript returns the source of the item's test scripts.
+func (it postmanItem) testScript() string {
+	var lines []string
+	for _, e := range it.Event {
+		if e.Listen == "test" {
+			lines = append(lines, e.Script.Exec...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//nolint:gochecknoglobals // compiled once
+var (
+	postmanVarRe = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+	pmPathVarRe  = regexp.MustCompile(`/:([A-Za-z_]\w*)`)
+	pmStatusRe   = regexp.MustCompile(
+		`pm\.response\.to\.have\.status\((\d{3})\)|pm\.response\.code\)\.to\.(?:eql|equal|be)\((\d{3})\)`)
+	pmJSONVarRe = regexp.MustCompile(
+		`(?:var|let|const)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:pm\.response\.json\(\)|JSON\.parse\(responseBody\))`)
+	pmSetRe = regexp.MustCompile(`pm\.(?:environment|collectionVariables|globals|variables)\.set\(\s*` +
+		`["']([^"']+)["']\s*,\s*` +
+		`((?:pm\.response\.json\(\)|JSON\.parse\(responseBody\)|[A-Za-z_$][\w$]*)` +
+		`(?:\.[A-Za-z_$][\w$]*|\[\d+\]|\[["'][^"']+["']\])*)\s*\)`)
+	jsAccessorRe = regexp.MustCompile(`\.([A-Za-z_$][\w$]*)|\[(\d+)\]|\[["']([^"']+)["']\]`)
+)
+
+// postmanImporter converts the items of a collection.
+type postmanImporter struct {
+	collection string
+	values     map[string]string // collection variables
+	conns      importedConnections
+
+	scenario string                     // name of the scenario being converted
+	setIn    map[string]map[string]bool // variable set by test scripts -> scenarios setting it
+	shared   bool                       // a saved variable is used by another scenario
+}
+
+// collectSaved records the variables every test script in the items of the
+// folder at path sets, and the scenarios setting them, before any is
+// converted, so variables passed between folders are found in any order.
+func (p *postmanImporter) collectSaved(path string, items []postmanItem) {
+	name := path
+	if name == "" {
+		name = p.collection
+	}
+	for _, it := range items {
+		if it.Request == nil {
+			p.collectSaved(strings.TrimPrefix(path+" / "+it.Name, " / "), it.Item)
+			continue
+		}
+		for _, m := range pmSetRe.FindAllStringSubmatch(it.testScript(), -1) {
+			if p.setIn[m[1]] == nil {
+				p.setIn[m[1]] = map[string]bool{}
+			}
+			p.setIn[m[1]][name] = true
+		}
+	}
+}
+
+// expand maps {{var}} onto the value of a collection variable, or onto a
+// {var} placeholder when a script sets it or it has no value.
+func (p *postmanImporter) expand(s string) string {
+	return postmanVarRe.ReplaceAllStringFunc(s, func(m string) string {
+		name := postmanVarRe.FindStringSubmatch(m)[1]
+		in, saved := p.setIn[name]
+		if v, ok := p.values[name]; ok && !saved {
+			return v
+		}
+		if saved && !in[p.scenario] {
+			p.shared = true
+		}
+		return "{" + name + "}"
+	})
+}
+
+// folder converts the requests of the folder at path, e.g. "Users / Admin",
+// into a scenario followed by the scenarios of its sub-folders. The requests
+// outside folders, at path "", form a scenario named after the collection.
+func (p *postmanImporter) folder(path string, items []postmanItem, auth *postmanAuth) ([]*Scenario, error) {
+	name := path
+	if name == "" {
+		name = p.collection
+	}
+	sc := NewScenario(name)
+	var sub []*Scenario
+	for _, it := range items {
+		if it.Request == nil {
+			itemAuth := auth
+			if it.Auth != nil {
+				itemAuth = it.Auth
+			}
+			ss, err := p.folder(strings.TrimPrefix(path+" / "+it.Name, " / "), it.Item, itemAuth)
+			if err != nil {
+				return nil, err
+			}
+			sub = append(sub, ss...)
+			continue
+		}
+		p.scenario = name
+		step, err := p.step(it, auth)
+		if err != nil {
+			return nil, fmt.Errorf("request %q: %w", it.Name, err)
+		}
+		sc.AddStep(step)
+	}
+	if len(sc.steps) == 0 {
+		return sub, nil
+	}
+	return append([]*Scenario{sc}, sub...), nil
+}
+
+func (p *postmanImporter) step(it postmanItem, auth *postmanAuth) (*StepBuilder, error) {
+	r := it.Request
+	conn, path, query := p.url(r.URL)
+	method := strings.ToUpper(r.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	b := HTTPStep(method, path).WithConnection(conn)
+	for _, q := range query {
+		b.WithQuery(q[0], q[1])
+	}
+	for _, h := range r.Header {
+		if !h.Disabled && importHeader(h.Key) {
+			b.WithHeader(http.CanonicalHeaderKey(p.expand(h.Key)), p.expand(fmt.Sprint(h.Value)))
+		}
+	}
+	if r.Auth != nil {
+		auth = r.Auth
+	}
+	p.applyAuth(b, auth)
+	if err := p.applyBody(b, r.Body); err != nil {
+		return nil, err
+	}
+
+	script := it.testScript()
+	if m := pmStatusRe.FindStringSubmatch(script); m != nil {
+		code, _ := strconv.Atoi(m[1] + m[2])
+		b.ExpectStatus(code)
+	} else if len(it.Response) > 0 {
+		b.ExpectStatus(it.Response[0].Code)
+	}
+	p.applySaves(b, script)
+	return b, nil
+}
+
+// url resolves a request URL to its connection, path and query parameters.
+func (p *postmanImporter) url(u postmanURL) (conn, path string, query [][2]string) {
+	raw := u.Raw
+	for _, v := range u.Variable {
+		if v.Value == nil {
+			continue
+		}
+		raw = pmPathVarRe.ReplaceAllStringFunc(raw, func(m string) string {
+			if m[2:] == v.Key {
+				return "/" + fmt.Sprint(v.Value)
+			}
+			return m
+		})
+	}
+
+	// A leading {{variable}}, such as {{baseUrl}}, names the connection.
+	if loc := postmanVarRe.FindStringSubmatchIndex(raw); loc != nil && loc[0] == 0 {
+		name := raw[loc[2]:loc[3]]
+		origin, ok := p.values[name]
+		if !ok {
+			origin = "http://localhost"
+		}
+		rest := p.expand(raw[loc[1]:])
+		origin = strings.TrimRight(origin, "/")
+		path, query = splitPath(rest)
+		return p.conns.name(origin, name), path, query
+	}
+
+	origin, host, path, query := splitURL(p.expand(raw))
+	return p.conns.name(origin, host), path, query
+}
+
+func (p *postmanImporter) applyAuth(b *StepBuilder, auth *postmanAuth) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "bearer":
+		b.WithHeader("Authorization", "Bearer "+p.expand(postmanParam(auth.Bearer, "token")))
+	case "basic":
+		creds := p.expand(postmanParam(auth.Basic, "username")) + ":" + p.expand(postmanParam(auth.Basic, "password"))
+		b.WithHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(creds)))
+	}
+}
+
+func (p *postmanImporter) applyBody(b *StepBuilder, body *postmanBody) error {
+	if body == nil {
+		return nil
+	}
+	req := b.httpReq()
+	switch body.Mode {
+	case "raw":
+		b.WithBody([]byte(p.expand(body.Raw)))
+		if _, ok := req.Header["Content-Type"]; !ok && body.Options.Raw.Language == "json" {
+			b.WithHeader("Content-Type", "application/json")
+		}
+	case "urlencoded":
+		var pairs []string
+		for _, kv := range body.URLEncoded {
+			if !kv.Disabled {
+				pairs = append(pairs, escapeForm(p.expand(kv.Key))+"="+escapeForm(p.expand(fmt.Sprint(kv.Value))))
+			}
+		}
+		b.WithBody([]byte(strings.Join(pairs, "&")))
+		if _, ok := req.Header["Content-Type"]; !ok {
+			b.WithHeader("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case "graphql":
+		if body.GraphQL == nil {
+			return nil
+		}
+		gql := map[string]any{"query": p.expand(body.GraphQL.Query)}
+		if vars := strings.TrimSpace(p.expand(body.GraphQL.Variables)); vars != "" {
+			gql["variables"] = json.RawMessage(vars)
+		}
+		data, err := json.Marshal(gql)
+		if err != nil {
+			return fmt.Errorf("graphql body: %w", err)
+		}
+		b.WithBody(data).WithHeader("Content-Type", "application/json")
+	case "", "none":
+	default:
+		return fmt.Errorf("%s bodies are not supported", body.Mode)
+	}
+	return nil
+}
+
+//nolint:gochecknoglobals // compiled once
+var placeholderRe = regexp.MustCompile(`\{[^{}\s]+\}`)
+
+// escapeForm form-encodes s, keeping {var} placeholders intact for interpolation.
+func escapeForm(s string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringIndex(s, -1) {
+		out.WriteString(url.QueryEscape(s[last:loc[0]]))
+		out.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(url.QueryEscape(s[last:]))
+	return out.String()
+}
+
+// applySaves maps pm.environment.set calls in a test script onto Save.
+func (p *postmanImporter) applySaves(b *StepBuilder, script string) {
+	bodyVars := map[string]bool{}
+	for _, m := range pmJSONVarRe.FindAllStringSubmatch(script, -1) {
+		bodyVars[m[1]] = true
+	}
+	for _, m := range pmSetRe.FindAllStringSubmatch(script, -1) {
+		name, expr := m[1], m[2]
+		var accessors string
+		switch {
+		case strings.HasPrefix(expr, "pm.response.json()"):
+			accessors = strings.TrimPrefix(expr, "pm.response.json()")
+		case strings.HasPrefix(expr, "JSON.parse(responseBody)"):
+			accessors = strings.TrimPrefix(expr, "JSON.parse(responseBody)")
+		default:
+			root := expr
+			if i := strings.IndexAny(expr, ".["); i >= 0 {
+				root, accessors = expr[:i], expr[i:]
+			}
+			if !bodyVars[root] {
+				continue // not a field of the response body
+			}
+		}
+		if field := jsAccessorsToPath(accessors); field != "" {
+			b.Save(field, name)
+		}
+	}
+}
+
+// jsAccessorsToPath converts JavaScript accessors such as `.data[0]["id"]` to
+// a gjson path such as "data.0.id".
+func jsAccessorsToPath(accessors string) string {
+	var parts []string
+	for _, m := range jsAccessorRe.FindAllStringSubmatch(accessors, -1) {
+		part := m[1] + m[2] + m[3]
+		parts = append(parts, strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(part))
+	}
+	return strings.Join(parts, ".")
+}
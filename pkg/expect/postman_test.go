@@ -0,0 +1,230 @@
+package expect
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is an HTTP server that records the requests it receives and
+// answers login with a token that the other endpoints require.
+type recorder struct {
+	mu   sync.Mutex
+	reqs []string // "METHOD /path?query content-type authorization body"
+}
+
+func newRecorder(t *testing.T) (*recorder, *httptest.Server) {
+	t.Helper()
+	rec := &recorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.reqs = append(rec.reqs, strings.Join([]string{
+			r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"), r.Header.Get("Authorization"), string(body),
+		}, " "))
+		rec.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/login":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"token":"abc"}}`))
+		case r.Header.Get("Authorization") == "" || r.Header.Get("Authorization") == "Bearer abc":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return rec, srv
+}
+
+const postmanCollectionJSON = `{
+  "info": {"name": "Shop", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "variable": [
+    {"key": "baseUrl", "value": "http://shop.example.com"},
+    {"key": "tenant", "value": "acme"},
+    {"key": "token", "value": "stale"}
+  ],
+  "item": [
+    {
+      "name": "Login",
+      "request": {
+        "method": "POST",
+        "url": "{{baseUrl}}/login",
+        "header": [{"key": "x-tenant", "value": "{{tenant}}"}, {"key": "Host", "value": "shop"}],
+        "body": {"mode": "raw", "raw": "{\"user\":\"{{tenant}}\"}", "options": {"raw": {"language": "json"}}}
+      },
+      "event": [{"listen": "test", "script": {"exec": [
+        "pm.test('created', () => pm.response.to.have.status(201));",
+        "var body = pm.response.json();",
+        "pm.environment.set(\"token\", body.data[\"token\"]);"
+      ]}}]
+    },
+    {
+      "name": "Orders",
+      "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+      "item": [
+        {
+          "name": "List",
+          "request": {"method": "GET", "url": {"raw": "{{baseUrl}}/orders/:id?page=2", "variable": [{"key": "id", "value": "7"}]}},
+          "response": [{"code": 200}]
+        },
+        {
+          "name": "Search",
+          "request": {
+            "method": "POST",
+            "url": "{{baseUrl}}/graphql",
+            "body": {"mode": "graphql", "graphql": {"query": "{ orders { id } }", "variables": "{\"n\": 1}"}}
+          }
+        },
+        {
+          "name": "Note",
+          "request": {
+            "method": "POST",
+            "url": "{{baseUrl}}/notes",
+            "auth": {"type": "noauth"},
+            "body": {"mode": "urlencoded", "urlencoded": [{"key": "text", "value": "a&b {{token}}"}]}
+          }
+        }
+      ]
+    }
+  ]
+}`
+
+func TestLoadPostman(t *testing.T) {
+	suite, err := LoadPostman([]byte(postmanCollectionJSON))
+	if err != nil {
+		t.Fatalf("LoadPostman error: %v", err)
+	}
+	// Orders uses the token the Login script saves, so it all runs as one scenario.
+	if got := scenarioNames(suite); got != "Shop" {
+		t.Errorf("expected scenarios Shop, got %s", got)
+	}
+
+	rec, srv := newRecorder(t)
+	if err := suite.WithConnectionURL("baseUrl", srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Fatalf("imported suite failed: %v\n%s", err, strings.Join(rec.reqs, "\n"))
+	}
+	want := []string{
+		`POST /login application/json  {"user":"acme"}`,
+		`GET /orders/7?page=2  Bearer abc `,
+		`POST /graphql application/json Bearer abc {"query":"{ orders { id } }","variables":{"n":1}}`,
+		`POST /notes application/x-www-form-urlencoded  text=a%26b+abc`,
+	}
+	if got := strings.Join(rec.reqs, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("expected requests:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+}
+
+func TestLoadPostman_folders(t *testing.T) {
+	suite, err := LoadPostman([]byte(`{
+	  "info": {"name": "c"},
+	  "item": [
+	    {"name": "ping", "request": "http://localhost/ping"},
+	    {"name": "Users", "item": [
+	      {"name": "list", "request": "http://localhost/users"},
+	      {"name": "Admin", "item": [{"name": "list", "request": "http://localhost/admins"}]}
+	    ]},
+	    {"name": "Empty", "item": []}
+	  ]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPostman error: %v", err)
+	}
+	if got := scenarioNames(suite); got != "c,Users,Users / Admin" {
+		t.Errorf("expected scenarios c,Users,Users / Admin, got %s", got)
+	}
+}
+
+func TestLoadPostman_sharedBeforeSet(t *testing.T) {
+	// Orders comes first but uses the token the later Auth folder saves.
+	suite, err := LoadPostman([]byte(`{
+	  "info": {"name": "c"},
+	  "item": [
+	    {"name": "Orders", "item": [
+	      {"name": "list", "request": {"url": "http://localhost/orders", "header": [{"key": "X-Token", "value": "{{token}}"}]}}
+	    ]},
+	    {"name": "Auth", "item": [
+	      {"name": "login", "request": "http://localhost/login", "event": [{"listen": "test", "script": {"exec": [
+	        "pm.environment.set(\"token\", pm.response.json().token);"
+	      ]}}]}
+	    ]}
+	  ]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPostman error: %v", err)
+	}
+	if got := scenarioNames(suite); got != "c" {
+		t.Errorf("expected one scenario c, got %s", got)
+	}
+}
+
+func scenarioNames(s *Suite) string {
+	var names []string
+	for _, sc := range s.Scenarios() {
+		names = append(names, sc.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestLoadPostman_status(t *testing.T) {
+	suite, err := LoadPostman([]byte(`{
+	  "info": {"name": "c"},
+	  "item": [{"name": "ping", "request": "http://localhost/ping", "response": [{"code": 204}]}]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPostman error: %v", err)
+	}
+	_, srv := newRecorder(t)
+	if err := suite.WithConnectionURL("localhost", srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := suite.Run(); err == nil || !strings.Contains(err.Error(), "unexpected status code: 200") {
+		t.Errorf("expected a status mismatch against the saved response, got %v", err)
+	}
+}
+
+func TestLoadPostman_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"invalid json", `{`, "parse postman collection"},
+		{
+			"unsupported body",
+			`{"item": [{"name": "up", "request": {"url": "http://h/", "body": {"mode": "file"}}}]}`,
+			`request "up": file bodies are not supported`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadPostman([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestJSAccessorsToPath(t *testing.T) {
+	tests := map[string]string{
+		".token":              "token",
+		`.data[0]["id"]`:      "data.0.id",
+		`['a.b'].c`:           `a\.b.c`,
+		"":                    "",
+		`["items"][12].price`: "items.12.price",
+	}
+	for in, want := range tests {
+		if got := jsAccessorsToPath(in); got != want {
+			t.Errorf("jsAccessorsToPath(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
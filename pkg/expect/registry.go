@@ -0,0 +1,156 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ConnectionFactory creates a Connection from a file connection's name and url.
+type ConnectionFactory func(name, url string) (Connection, error)
+
+// StepBuilderFunc builds a step from a file step whose connection is of the registered type.
+type StepBuilderFunc func(step FileStep) (*StepBuilder, error)
+
+// MatcherFunc builds a matcher from the argument of an operator object such as
+// {"$name": arg} in an expected body. Operators nested in arg are already decoded.
+type MatcherFunc func(arg any) (Matcher, error)
+
+// FileStep is a step loaded from a YAML or JSON file, handed to the StepBuilderFunc
+// registered for the type of connection it targets.
+type FileStep struct {
+	// Connection is the name of the connection the step targets; empty means the default.
+	Connection string
+	// Request is the raw request block of the step.
+	Request map[string]any
+	// Expect is the raw expect block of the step; nil if absent.
+	Expect map[string]any
+
+	parsed fileStep
+}
+
+// DecodeRequest decodes the raw request block into v, honouring json struct tags.
+func (s FileStep) DecodeRequest(v any) error {
+	return decodeFileBlock(s.Request, v)
+}
+
+// DecodeExpect decodes the raw expect block into v, honouring json struct tags.
+func (s FileStep) DecodeExpect(v any) error {
+	return decodeFileBlock(s.Expect, v)
+}
+
+func decodeFileBlock(block map[string]any, v any) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+//nolint:gochecknoglobals // process-wide registry, like database/sql drivers
+var registry = newTypeRegistry()
+
+type typeRegistry struct {
+	mu    sync.RWMutex
+	conns map[string]ConnectionFactory
+	steps map[string]StepBuilderFunc
+}
+
+func newTypeRegistry() *typeRegistry {
+	r := &typeRegistry{
+		conns: make(map[string]ConnectionFactory),
+		steps: make(map[string]StepBuilderFunc),
+	}
+
+	httpFactory := func(name, url string) (Connection, error) { return HTTP(name, url), nil }
+	for _, t := range []string{"http", "https", ""} {
+		r.conns[t] = httpFactory
+	}
+	r.steps["http"] = func(s FileStep) (*StepBuilder, error) { return buildFileHTTPStep(s.parsed) }
+
+	r.conns["grpc"] = func(name, url string) (Connection, error) { return GRPC(name, url), nil }
+	r.steps["grpc"] = func(s FileStep) (*StepBuilder, error) { return buildFileGRPCStep(s.parsed) }
+
+	for _, driver := range []string{"postgres", "mysql", "sqlite", "sqlite3", "sqlserver"} {
+		r.conns[driver] = func(name, url string) (Connection, error) { return SQL(name, driver, url), nil }
+		r.steps[driver] = func(s FileStep) (*StepBuilder, error) { return buildFileSQLStep(s.parsed) }
+	}
+	return r
+}
+
+// RegisterConnectionType makes a connection type available to YAML and JSON files
+// under the given `type:` name, replacing any existing registration.
+func RegisterConnectionType(name string, factory ConnectionFactory) {
+	if factory == nil {
+		panic("go-expect: RegisterConnectionType factory is nil")
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.conns[name] = factory
+}
+
+// RegisterStepBuilder sets how file steps are built for connections whose Type()
+// returns connType, replacing any existing registration.
+func RegisterStepBuilder(connType string, build StepBuilderFunc) {
+	if build == nil {
+		panic("go-expect: RegisterStepBuilder build is nil")
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.steps[connType] = build
+}
+
+// RegisterMatcher makes a matcher available in expected bodies, in files and
+// ExpectBody alike, as the operator object {"$name": arg}, replacing any existing
+// registration. Matchers used from Go should marshal themselves to that object.
+func RegisterMatcher(name string, build MatcherFunc) {
+	if build == nil {
+		panic("go-expect: RegisterMatcher build is nil")
+	}
+	matchers.mu.Lock()
+	defer matchers.mu.Unlock()
+	matchers.funcs[name] = build
+}
+
+// matcherRegistry is separate from registry because building file steps,
+// which registry refers to, decodes operators.
+type matcherRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]MatcherFunc
+}
+
+//nolint:gochecknoglobals // process-wide registry, like registry above
+var matchers = &matcherRegistry{funcs: builtinMatchers()}
+
+func (r *matcherRegistry) matcher(name string) (MatcherFunc, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	build, ok := r.funcs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown operator %q", "$"+name)
+	}
+	return build, nil
+}
+
+func (r *typeRegistry) connectionFactory(name string) (ConnectionFactory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	factory, ok := r.conns[name]
+	if !ok {
+		return nil, fmt.Errorf("go-expect: unknown connection type %q", name)
+	}
+	return factory, nil
+}
+
+func (r *typeRegistry) stepBuilder(conn Connection) (StepBuilderFunc, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("go-expect: unsupported connection type %T", conn)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	build, ok := r.steps[conn.Type()]
+	if !ok {
+		return nil, fmt.Errorf("go-expect: no step builder registered for connection type %q", conn.Type())
+	}
+	return build, nil
+}
@@ -0,0 +1,114 @@
+package expect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type echoConnection struct{ name, prefix string }
+
+func (c *echoConnection) GetName() string { return c.name }
+func (c *echoConnection) Type() string    { return "echo" }
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+func (r *echoRequest) Label() string { return "echo " + r.Message }
+
+func (r *echoRequest) Do(_ context.Context, conn Connection, vars VarStore) (any, error) {
+	echoConn, ok := conn.(*echoConnection)
+	if !ok {
+		return nil, mismatchedConnection("echo", conn)
+	}
+	return echoConn.prefix + vars.Interpolate(r.Message), nil
+}
+
+type echoExpect struct {
+	Reply string `json:"reply"`
+}
+
+func (e *echoExpect) Check(resp any, err error, _ VarStore) error {
+	if err != nil {
+		return err
+	}
+	if resp != e.Reply {
+		return fmt.Errorf("unexpected reply %v", resp)
+	}
+	return nil
+}
+
+func TestRegistry_customType(t *testing.T) {
+	RegisterConnectionType("echo", func(name, url string) (Connection, error) {
+		return &echoConnection{name: name, prefix: url}, nil
+	})
+	RegisterStepBuilder("echo", func(s FileStep) (*StepBuilder, error) {
+		req := &echoRequest{}
+		if err := s.DecodeRequest(req); err != nil {
+			return nil, err
+		}
+		exp := &echoExpect{}
+		if err := s.DecodeExpect(exp); err != nil {
+			return nil, err
+		}
+		return NewStep(s.Connection, req, exp), nil
+	})
+
+	data := []byte(`
+connections:
+  - name: echo
+    type: echo
+    url: "say: "
+
+scenarios:
+  - name: echo test
+    steps:
+      - request:
+          message: hello
+        expect:
+          reply: "say: hello"
+`)
+	suite, err := LoadYAML(data)
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if label := stepLabel(0, suite.scenarios[0].steps[0]); label != "[1] echo hello" {
+		t.Fatalf("unexpected label %q", label)
+	}
+}
+
+type silentConnection struct{ echoConnection }
+
+func (c *silentConnection) Type() string { return "silent" }
+
+func TestRegistry_unknownStepBuilder(t *testing.T) {
+	RegisterConnectionType("silent", func(name, _ string) (Connection, error) {
+		return &silentConnection{echoConnection{name: name}}, nil
+	})
+	data := []byte(`
+connections:
+  - name: x
+    type: silent
+scenarios:
+  - name: no builder
+    steps:
+      - request:
+          message: hello
+`)
+	if _, err := LoadYAML(data); err == nil || !strings.Contains(err.Error(), "no step builder") {
+		t.Fatalf("expected missing step builder error, got %v", err)
+	}
+}
+
+func TestStep_RunMismatchedConnection(t *testing.T) {
+	step := GET("/").ExpectStatus(200).Build()
+	err := step.Run(t.Context(), GRPC("svc", "localhost:0"), make(VarStore))
+	if err == nil || !strings.Contains(err.Error(), "mismatched connection type") {
+		t.Fatalf("expected mismatched connection error, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package expect
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind identifies what a reporter Event describes.
+type EventKind string
+
+const (
+	EventSuiteStart     EventKind = "suite_start"
+	EventSuiteFinish    EventKind = "suite_finish"
+	EventScenarioStart  EventKind = "scenario_start"
+	EventScenarioFinish EventKind = "scenario_finish"
+	EventStepStart      EventKind = "step_start"
+	EventStepFinish     EventKind = "step_finish"
+)
+
+// Event is a structured notification emitted while a Suite runs.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	// Scenario is the scenario name; empty for suite events.
+	Scenario string
+	// Step is the step label, e.g. "[2] GET /users/{user_id}"; step events only.
+	Step string
+	// Connection is the name of the connection the step ran against; step events only.
+	Connection string
+	// Request summarises the request with variables interpolated; step events only.
+	Request string
+	// Duration is the elapsed time; finish events only.
+	Duration time.Duration
+	// Err is the failure, or nil on success; finish events only.
+	Err error
+
+	// scenarioID distinguishes scenarios that share a name.
+	scenarioID int
+}
+
+// Reporter receives structured events while a Suite runs.
+// Report may be called concurrently when scenarios run in parallel.
+type Reporter interface {
+	Report(e Event)
+}
+
+// WithReporter attaches reporters that receive suite, scenario and step events.
+func (s *Suite) WithReporter(reporters ...Reporter) *Suite {
+	s.reporters = append(s.reporters, reporters...)
+	return s
+}
+
+// multiReporter fans events out to every reporter in order.
+type multiReporter []Reporter
+
+func (m multiReporter) Report(e Event) {
+	for _, r := range m {
+		r.Report(e)
+	}
+}
+
+// reportSummary aggregates events into per-scenario results for the built-in reporters.
+type reportSummary struct {
+	mu        sync.Mutex
+	start     time.Time
+	duration  time.Duration
+	scenarios []*scenarioSummary
+	byID      map[int]*scenarioSummary
+}
+
+type scenarioSummary struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+	err      error
+	failed   *stepSummary // the step that failed the scenario, if any
+	steps    []*stepSummary
+}
+
+type stepSummary struct {
+	label      string
+	connection string
+	request    string
+	duration   time.Duration
+	err        error
+}
+
+// add records e, returning true once the suite has finished.
+func (r *reportSummary) add(e Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.byID == nil {
+		r.byID = make(map[int]*scenarioSummary)
+	}
+
+	switch e.Kind {
+	case EventSuiteStart:
+		// A reporter may be reused, so each run starts a new summary.
+		r.start, r.duration = e.Time, 0
+		r.scenarios, r.byID = nil, make(map[int]*scenarioSummary)
+	case EventSuiteFinish:
+		r.duration = e.Duration
+		return true
+	case EventScenarioStart:
+		sc := &scenarioSummary{name: e.Scenario, start: e.Time}
+		r.scenarios = append(r.scenarios, sc)
+		r.byID[e.scenarioID] = sc
+	case EventScenarioFinish:
+		if sc, ok := r.byID[e.scenarioID]; ok {
+			sc.duration = e.Duration
+			sc.err = e.Err
+		}
+	case EventStepStart:
+		if sc, ok := r.byID[e.scenarioID]; ok {
+			sc.steps = append(sc.steps, &stepSummary{label: e.Step, connection: e.Connection, request: e.Request})
+		}
+	case EventStepFinish:
+		sc, ok := r.byID[e.scenarioID]
+		if !ok || len(sc.steps) == 0 {
+			break
+		}
+		st := sc.steps[len(sc.steps)-1]
+		st.duration = e.Duration
+		st.err = e.Err
+		if e.Err != nil && sc.failed == nil {
+			sc.failed = st
+		}
+	}
+	return false
+}
+
+// failureMessage describes why the scenario failed, naming the failing step when known.
+func (sc *scenarioSummary) failureMessage() string {
+	if sc.failed != nil {
+		return "step " + sc.failed.label + ": " + sc.failed.err.Error()
+	}
+	return sc.err.Error()
+}
+
+// countFailed returns the number of failed scenarios.
+func (r *reportSummary) countFailed() int {
+	n := 0
+	for _, sc := range r.scenarios {
+		if sc.err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// errorString returns err's message, or "" for nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,122 @@
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// JSONReporter writes a machine-readable JSON report when the suite finishes.
+type JSONReporter struct {
+	w       io.Writer
+	summary reportSummary
+	err     error
+}
+
+// NewJSONReporter creates a JSONReporter writing to w.
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{w: w}
+}
+
+// Report implements Reporter.
+func (r *JSONReporter) Report(e Event) {
+	if r.summary.add(e) {
+		r.err = r.write()
+	}
+}
+
+// Err returns the error from writing the report, if any.
+func (r *JSONReporter) Err() error {
+	return r.err
+}
+
+type jsonReport struct {
+	Start      time.Time            `json:"start"`
+	DurationMS int64                `json:"duration_ms"`
+	Passed     int                  `json:"passed"`
+	Failed     int                  `json:"failed"`
+	Scenarios  []jsonScenarioReport `json:"scenarios"`
+}
+
+type jsonScenarioReport struct {
+	Name       string           `json:"name"`
+	Passed     bool             `json:"passed"`
+	DurationMS int64            `json:"duration_ms"`
+	Error      string           `json:"error,omitempty"`
+	Steps      []jsonStepReport `json:"steps"`
+}
+
+type jsonStepReport struct {
+	Label      string        `json:"label"`
+	Connection string        `json:"connection,omitempty"`
+	Request    string        `json:"request"`
+	Passed     bool          `json:"passed"`
+	DurationMS int64         `json:"duration_ms"`
+	Error      string        `json:"error,omitempty"`
+	Mismatch   *jsonMismatch `json:"mismatch,omitempty"`
+}
+
+// jsonMismatch carries the details of a *MismatchError step failure.
+type jsonMismatch struct {
+	Path      string `json:"path"`
+	Reason    string `json:"reason"`
+	Expected  string `json:"expected,omitempty"` // compact JSON
+	Actual    string `json:"actual,omitempty"`   // compact JSON
+	Candidate string `json:"candidate,omitempty"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+func newJSONMismatch(err error) *jsonMismatch {
+	var m *MismatchError
+	if !errors.As(err, &m) {
+		return nil
+	}
+	return &jsonMismatch{
+		Path:      m.Path,
+		Reason:    m.Reason,
+		Expected:  formatValue(m.Expected),
+		Actual:    formatValue(m.Actual),
+		Candidate: m.Candidate,
+		Diff:      m.Diff(),
+	}
+}
+
+func (r *JSONReporter) write() error {
+	r.summary.mu.Lock()
+	defer r.summary.mu.Unlock()
+
+	failed := r.summary.countFailed()
+	doc := jsonReport{
+		Start:      r.summary.start,
+		DurationMS: r.summary.duration.Milliseconds(),
+		Passed:     len(r.summary.scenarios) - failed,
+		Failed:     failed,
+		Scenarios:  make([]jsonScenarioReport, 0, len(r.summary.scenarios)),
+	}
+	for _, sc := range r.summary.scenarios {
+		scr := jsonScenarioReport{
+			Name:       sc.name,
+			Passed:     sc.err == nil,
+			DurationMS: sc.duration.Milliseconds(),
+			Error:      errorString(sc.err),
+			Steps:      make([]jsonStepReport, 0, len(sc.steps)),
+		}
+		for _, st := range sc.steps {
+			scr.Steps = append(scr.Steps, jsonStepReport{
+				Label:      st.label,
+				Connection: st.connection,
+				Request:    st.request,
+				Passed:     st.err == nil,
+				DurationMS: st.duration.Milliseconds(),
+				Error:      errorString(st.err),
+				Mismatch:   newJSONMismatch(st.err),
+			})
+		}
+		doc.Scenarios = append(doc.Scenarios, scr)
+	}
+
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,138 @@
+package expect
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// JUnitReporter writes a JUnit XML report when the suite finishes.
+// Every scenario becomes a test case; failing scenarios carry the label and
+// error of the failing step.
+type JUnitReporter struct {
+	// Name is the testsuite name; empty means "go-expect".
+	Name string
+
+	w       io.Writer
+	summary reportSummary
+	err     error
+}
+
+// NewJUnitReporter creates a JUnitReporter writing to w.
+func NewJUnitReporter(w io.Writer) *JUnitReporter {
+	return &JUnitReporter{w: w}
+}
+
+// Report implements Reporter.
+func (r *JUnitReporter) Report(e Event) {
+	if r.summary.add(e) {
+		r.err = r.write()
+	}
+}
+
+// Err returns the error from writing the report, if any.
+func (r *JUnitReporter) Err() error {
+	return r.err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (r *JUnitReporter) write() error {
+	r.summary.mu.Lock()
+	defer r.summary.mu.Unlock()
+
+	name := r.Name
+	if name == "" {
+		name = "go-expect"
+	}
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(r.summary.scenarios),
+		Failures:  r.summary.countFailed(),
+		Time:      junitSeconds(r.summary.duration),
+		Timestamp: r.summary.start.Format(time.RFC3339),
+	}
+	for _, sc := range r.summary.scenarios {
+		tc := junitTestCase{
+			Name:      sc.name,
+			Classname: name,
+			Time:      junitSeconds(sc.duration),
+			SystemOut: junitSteps(sc),
+		}
+		if sc.err != nil {
+			tc.Failure = &junitFailure{
+				Message: sc.failureMessage(),
+				Type:    "failure",
+				Text:    sc.err.Error(),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(r.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(r.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode junit report: %w", err)
+	}
+	_, err := io.WriteString(r.w, "\n")
+	return err
+}
+
+// junitSteps lists every executed step with its outcome, for the test case's system-out.
+func junitSteps(sc *scenarioSummary) string {
+	var b strings.Builder
+	for _, st := range sc.steps {
+		result := "ok"
+		if st.err != nil {
+			result = "FAIL: " + st.err.Error()
+		}
+		fmt.Fprintf(&b, "%s (%s) %s: %s\n", st.label, st.duration.Round(time.Millisecond), st.request, result)
+	}
+	return b.String()
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
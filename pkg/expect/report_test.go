@@ -0,0 +1,171 @@
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func reportTestSuite(t *testing.T, reporters ...Reporter) *Suite {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithReporter(reporters...).
+		WithScenarios(
+			NewScenario("passes").AddStep(GET("/ok").ExpectStatus(200)),
+			NewScenario("fails").
+				AddStep(GET("/ok").ExpectStatus(200)).
+				AddStep(GET("/missing").ExpectStatus(200)),
+		)
+}
+
+type recordingReporter struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recordingReporter) Report(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestReporter_Events(t *testing.T) {
+	rec := &recordingReporter{}
+	_ = reportTestSuite(t, rec).Run()
+
+	want := []EventKind{
+		EventSuiteStart,
+		EventScenarioStart, EventStepStart, EventStepFinish, EventScenarioFinish,
+		EventScenarioStart, EventStepStart, EventStepFinish, EventStepStart, EventStepFinish, EventScenarioFinish,
+		EventSuiteFinish,
+	}
+	if len(rec.events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(rec.events))
+	}
+	for i, e := range rec.events {
+		if e.Kind != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], e.Kind)
+		}
+	}
+
+	step := rec.events[9]
+	if step.Step != "[2] GET /missing" || step.Connection != "api" || step.Err == nil {
+		t.Errorf("unexpected failing step event: %+v", step)
+	}
+	if rec.events[len(rec.events)-1].Err == nil {
+		t.Error("expected suite finish event to carry the error")
+	}
+}
+
+func TestJUnitReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJUnitReporter(&buf)
+	if err := reportTestSuite(t, r).Run(); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected report error: %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 2 || doc.Failures != 1 || len(doc.Suites) != 1 {
+		t.Fatalf("unexpected totals: %+v", doc)
+	}
+	cases := doc.Suites[0].Cases
+	if cases[0].Name != "passes" || cases[0].Failure != nil {
+		t.Errorf("unexpected passing case: %+v", cases[0])
+	}
+	if cases[1].Name != "fails" || cases[1].Failure == nil {
+		t.Fatalf("unexpected failing case: %+v", cases[1])
+	}
+	if !strings.Contains(cases[1].Failure.Message, "[2] GET /missing") {
+		t.Errorf("expected failure to name the step, got %q", cases[1].Failure.Message)
+	}
+}
+
+func TestJSONReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONReporter(&buf)
+	if err := reportTestSuite(t, r).Run(); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected report error: %v", err)
+	}
+
+	var doc jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if doc.Passed != 1 || doc.Failed != 1 || len(doc.Scenarios) != 2 {
+		t.Fatalf("unexpected totals: %+v", doc)
+	}
+	failed := doc.Scenarios[1]
+	if failed.Passed || len(failed.Steps) != 2 || failed.Steps[1].Passed || failed.Steps[1].Error == "" {
+		t.Errorf("unexpected failing scenario: %+v", failed)
+	}
+	if failed.Steps[0].Request != "GET /ok" {
+		t.Errorf("expected request summary, got %q", failed.Steps[0].Request)
+	}
+}
+
+func TestJSONReporter_reused(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONReporter(&buf)
+	suite := reportTestSuite(t, r)
+	for range 2 {
+		buf.Reset()
+		_ = suite.Run()
+		var doc jsonReport
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid json: %v\n%s", err, buf.String())
+		}
+		if len(doc.Scenarios) != 2 {
+			t.Fatalf("expected only this run's 2 scenarios, got %d", len(doc.Scenarios))
+		}
+	}
+}
+
+func TestTestSuite_Reporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewJSONReporter(&buf)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithReporter(r).
+		WithScenarios(NewScenario("ok").Parallel().AddStep(GET("/").ExpectStatus(200)))
+
+	t.Run("suite", func(t *testing.T) {
+		NewTestSuite(suite).Run(t)
+	})
+
+	var doc jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if doc.Passed != 1 || doc.Failed != 0 {
+		t.Fatalf("unexpected totals: %+v", doc)
+	}
+}
@@ -0,0 +1,105 @@
+package expect
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultRetryInterval is the delay between retry attempts when a RetryPolicy sets no Interval.
+const DefaultRetryInterval = 100 * time.Millisecond
+
+// MaxRetryBackoff caps the delay exponential backoff grows to.
+const MaxRetryBackoff = 5 * time.Minute
+
+// Backoff controls how the delay between retry attempts grows.
+type Backoff string
+
+const (
+	// BackoffConstant waits Interval between every attempt.
+	BackoffConstant Backoff = "constant"
+	// BackoffExponential doubles the delay after every attempt, starting at
+	// Interval, up to MaxRetryBackoff.
+	BackoffExponential Backoff = "exponential"
+)
+
+// RetryPolicy re-executes a step until its expectations pass, for services that
+// become consistent eventually (e.g. a GET right after an asynchronous write).
+type RetryPolicy struct {
+	// Attempts is the maximum number of executions, including the first.
+	// 0 means unlimited when UntilTimeout is set, otherwise a single attempt.
+	Attempts int
+	// Interval is the delay before the second attempt; 0 means DefaultRetryInterval.
+	Interval time.Duration
+	// Backoff is the delay growth strategy; empty means BackoffConstant.
+	Backoff Backoff
+	// UntilTimeout bounds the total time spent retrying; 0 means no deadline.
+	UntilTimeout time.Duration
+}
+
+// delay returns how long to wait after the given (1-based) failed attempt.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	interval := p.Interval
+	if interval == 0 {
+		interval = DefaultRetryInterval
+	}
+	if p.Backoff != BackoffExponential {
+		return interval
+	}
+	d := interval
+	for i := 1; i < attempt && d < MaxRetryBackoff; i++ {
+		d *= 2
+	}
+	return max(min(d, MaxRetryBackoff), interval)
+}
+
+// exhausted reports whether no further attempt should be made after attempt.
+func (p *RetryPolicy) exhausted(attempt int) bool {
+	if p.Attempts > 0 {
+		return attempt >= p.Attempts
+	}
+	return p.UntilTimeout == 0
+}
+
+// runWithRetry executes fn, re-running it according to policy until it passes.
+// A nil policy runs fn exactly once. Each failed attempt is logged and the last
+// error is returned once the attempts or deadline run out, or ctx is done.
+func runWithRetry(ctx context.Context, log *slog.Logger, policy *RetryPolicy, fn func() error) error {
+	if policy == nil {
+		return fn()
+	}
+
+	var deadline time.Time
+	if policy.UntilTimeout > 0 {
+		deadline = time.Now().Add(policy.UntilTimeout)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		log.InfoContext(ctx, "step attempt failed", "attempt", attempt, "error", err)
+
+		if policy.exhausted(attempt) {
+			return fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+		wait := policy.delay(attempt)
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return fmt.Errorf("after %d attempts (timeout %s): %w", attempt, policy.UntilTimeout, err)
+			}
+			wait = min(wait, remaining)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("after %d attempts (%w): %w", attempt, context.Cause(ctx), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_delay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"default interval", RetryPolicy{}, 1, DefaultRetryInterval},
+		{"constant", RetryPolicy{Interval: time.Second}, 3, time.Second},
+		{"exponential first", RetryPolicy{Interval: time.Second, Backoff: BackoffExponential}, 1, time.Second},
+		{"exponential third", RetryPolicy{Interval: time.Second, Backoff: BackoffExponential}, 3, 4 * time.Second},
+		{"exponential capped", RetryPolicy{Interval: 10 * time.Second, Backoff: BackoffExponential}, 40, MaxRetryBackoff},
+		{"interval above cap", RetryPolicy{Interval: time.Hour, Backoff: BackoffExponential}, 5, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.attempt); got != tt.want {
+				t.Errorf("delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithRetry(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	errNotYet := errors.New("not yet")
+
+	t.Run("nil policy runs once", func(t *testing.T) {
+		calls := 0
+		err := runWithRetry(t.Context(), log, nil, func() error { calls++; return errNotYet })
+		if !errors.Is(err, errNotYet) || calls != 1 {
+			t.Fatalf("expected 1 failing call, got %d calls, err %v", calls, err)
+		}
+	})
+
+	t.Run("passes eventually", func(t *testing.T) {
+		calls := 0
+		policy := &RetryPolicy{Attempts: 5, Interval: time.Millisecond}
+		err := runWithRetry(t.Context(), log, policy, func() error {
+			calls++
+			if calls < 3 {
+				return errNotYet
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Fatalf("expected success on 3rd call, got %d calls, err %v", calls, err)
+		}
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		calls := 0
+		policy := &RetryPolicy{Attempts: 3, Interval: time.Millisecond}
+		err := runWithRetry(t.Context(), log, policy, func() error { calls++; return errNotYet })
+		if !errors.Is(err, errNotYet) || calls != 3 {
+			t.Fatalf("expected 3 failing calls, got %d calls, err %v", calls, err)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		policy := &RetryPolicy{Attempts: 100, Interval: time.Hour}
+		err := runWithRetry(ctx, log, policy, func() error { calls++; cancel(); return errNotYet })
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, errNotYet) || calls != 1 {
+			t.Fatalf("expected cancellation after 1 call, got %d calls, err %v", calls, err)
+		}
+	})
+
+	t.Run("until timeout", func(t *testing.T) {
+		calls := 0
+		policy := &RetryPolicy{Interval: 5 * time.Millisecond, UntilTimeout: 30 * time.Millisecond}
+		err := runWithRetry(t.Context(), log, policy, func() error { calls++; return errNotYet })
+		if !errors.Is(err, errNotYet) || calls < 2 {
+			t.Fatalf("expected several failing calls, got %d calls, err %v", calls, err)
+		}
+	})
+}
+
+func TestScenario_RunRetry(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(NewScenario("eventually").AddStep(
+			GET("/item").ExpectStatus(200).WithRetry(RetryPolicy{Attempts: 5, Interval: time.Millisecond}),
+		))
+
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", hits.Load())
+	}
+}
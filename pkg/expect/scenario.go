@@ -1,23 +1,28 @@
 package expect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 )
 
 // AfterFunc is a cleanup function run after all steps complete.
-type AfterFunc func() error
+// Its context is never cancelled by the scenario's timeout, so cleanup can still run.
+type AfterFunc func(ctx context.Context) error
 
 // BeforeFunc is a setup function run before steps execute.
-type BeforeFunc func() error
+type BeforeFunc func(ctx context.Context) error
 
 // Scenario is a named sequence of steps executed against one or more connections.
 type Scenario struct {
-	Name   string
-	steps  []Step
-	before []BeforeFunc
-	after  []AfterFunc
+	Name     string
+	steps    []Step
+	before   []BeforeFunc
+	after    []AfterFunc
+	parallel bool
+	timeout  time.Duration
 }
 
 // NewScenario creates a new Scenario with the given name.
@@ -43,70 +48,200 @@ func (s *Scenario) After(fn AfterFunc) *Scenario {
 	return s
 }
 
+// Parallel marks the scenario as safe to run concurrently with other parallel scenarios.
+// Parallel scenarios run after all serial scenarios have finished.
+func (s *Scenario) Parallel() *Scenario {
+	s.parallel = true
+	return s
+}
+
+// WithTimeout bounds the total run time of the scenario's before-funcs and steps.
+func (s *Scenario) WithTimeout(d time.Duration) *Scenario {
+	s.timeout = d
+	return s
+}
+
+// stepRunner executes a single labelled step. Suite.Run calls fn directly,
+// while TestSuite wraps each step in its own subtest.
+type stepRunner func(label string, fn func() error) error
+
+func runStepDirect(_ string, fn func() error) error {
+	return fn()
+}
+
+// scenarioEnv is what a scenario needs from the suite running it.
+type scenarioEnv struct {
+	log         *slog.Logger
+	defaultConn Connection
+	connections map[string]Connection
+	reporter    Reporter
+	runStep     stepRunner
+	id          int        // distinguishes scenarios in reporter events
+	secrets     *secretSet // the suite's secrets; nil has the default patterns only
+	seed        *int64     // seeds generator placeholders; nil draws at random
+}
+
+func (env scenarioEnv) report(e Event) {
+	if env.reporter == nil {
+		return
+	}
+	e.scenarioID = env.id
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	env.reporter.Report(e)
+}
+
 // Run executes steps sequentially, stopping on the first failure.
 // after-funcs always execute regardless of before or step failures.
 func (s *Scenario) Run(log *slog.Logger, defaultConn Connection, connections map[string]Connection, vars VarStore) error {
-	log = log.With("scenario", s.Name)
-	log.Info("starting scenario")
+	return s.RunContext(context.Background(), log, defaultConn, connections, vars)
+}
+
+// RunContext is like Run but aborts the remaining steps once ctx is done.
+// The scenario's timeout, if any, is applied on top of ctx.
+func (s *Scenario) RunContext(
+	ctx context.Context,
+	log *slog.Logger,
+	defaultConn Connection,
+	connections map[string]Connection,
+	vars VarStore,
+) error {
+	return s.run(ctx, scenarioEnv{
+		log:         log,
+		defaultConn: defaultConn,
+		connections: connections,
+		runStep:     runStepDirect,
+	}, vars)
+}
+
+func (s *Scenario) run(ctx context.Context, env scenarioEnv, vars VarStore) error {
+	st := env.newVarState(s.Name, vars)
+	ctx = withVarState(ctx, st)
+	log := redactLogger(env.log, st).With("scenario", s.Name)
+	log.InfoContext(ctx, "starting scenario")
+	start := time.Now()
+	env.report(Event{Kind: EventScenarioStart, Time: start, Scenario: s.Name})
+
+	afterCtx := context.WithoutCancel(ctx)
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
 
 	var errs []error
 
 	for _, fn := range s.before {
-		if err := fn(); err != nil {
+		if err := fn(ctx); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
 	if len(errs) == 0 {
 		for i, step := range s.steps {
-			conn := defaultConn
-			if step.Connection != "" {
-				if c, ok := connections[step.Connection]; ok {
-					conn = c
-				}
+			if err := ctx.Err(); err != nil {
+				errs = append(errs, fmt.Errorf("step %s: %w", stepLabel(i, step), context.Cause(ctx)))
+				break
 			}
-
-			label := stepLabel(i, step)
-			log.Info("step", "step", label)
-
-			if err := step.Run(conn, vars); err != nil {
-				log.Error("step failed", "step", label, "error", err)
-				errs = append(errs, fmt.Errorf("step %s: %w", label, err))
+			if err := s.runStep(ctx, log, env, i, step, st); err != nil {
+				errs = append(errs, err)
 				break
 			}
 		}
 	}
 
 	for _, fn := range s.after {
-		if err := fn(); err != nil {
+		if err := fn(afterCtx); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
-	if len(errs) > 0 {
-		log.Error("scenario failed", "errors", len(errs))
+	err := st.redactError(errors.Join(errs...))
+	if err != nil {
+		log.ErrorContext(afterCtx, "scenario failed", "errors", len(errs))
 	} else {
-		log.Info("scenario passed")
+		log.InfoContext(afterCtx, "scenario passed")
+	}
+	env.report(Event{Kind: EventScenarioFinish, Scenario: s.Name, Duration: time.Since(start), Err: err})
+	return err
+}
+
+// runStep executes the ith step against its connection, retrying per its
+// policy, with the scenario's variables and their state st.
+func (s *Scenario) runStep(
+	ctx context.Context,
+	log *slog.Logger,
+	env scenarioEnv,
+	i int,
+	step Step,
+	st *varState,
+) error {
+	conn := stepConnection(step, env.defaultConn, env.connections)
+
+	st.startStep()
+	st.markSecret(secretSaves(step)...)
+	label := st.redact(stepLabel(i, step))
+	log.InfoContext(ctx, "step", "step", label)
+
+	start := time.Now()
+	stepEvent := Event{Scenario: s.Name, Step: label, Request: requestSummary(step, st)}
+	if conn != nil {
+		stepEvent.Connection = conn.GetName()
+	}
+	stepEvent.Kind, stepEvent.Time = EventStepStart, start
+	env.report(stepEvent)
+
+	err := env.runStep(label, func() error {
+		err := runWithRetry(ctx, log.With("step", label), step.Retry, func() error {
+			// Requests and expectations may set variables of their own.
+			defer st.varsChanged()
+			return step.Run(ctx, conn, st.vars)
+		})
+		if err != nil {
+			return st.redactError(withReplay(err, step, conn, st.interpolator()))
+		}
+		return nil
+	})
+
+	stepEvent.Kind, stepEvent.Time = EventStepFinish, time.Now()
+	stepEvent.Duration, stepEvent.Err = time.Since(start), err
+	env.report(stepEvent)
+
+	if err != nil {
+		log.ErrorContext(ctx, "step failed", "step", label, "error", err)
+		return fmt.Errorf("step %s: %w", label, err)
 	}
-	return errors.Join(errs...)
+	return nil
+}
+
+// requestSummary describes the step's request with variables interpolated
+// and secrets masked.
+func requestSummary(step Step, st *varState) string {
+	if step.Request == nil {
+		return ""
+	}
+	return st.redact(st.interpolator().interpolate(step.Request.Label()))
+}
+
+// secretSaves returns the names of the variables the step saves as secret.
+func secretSaves(step Step) []string {
+	s, ok := step.Expect.(saver)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, entry := range s.saves() {
+		if entry.Secret {
+			names = append(names, entry.As)
+		}
+	}
+	return names
 }
 
 func stepLabel(i int, s Step) string {
 	if s.Request == nil {
 		return fmt.Sprintf("[%d] (no request)", i+1)
 	}
-	switch req := s.Request.(type) {
-	case *HTTPRequest:
-		return fmt.Sprintf("[%d] %s %s", i+1, req.Method, req.Path)
-	case *GRPCRequest:
-		return fmt.Sprintf("[%d] grpc %s", i+1, req.FullMethod)
-	case *SQLRequest:
-		stmt := req.Statement
-		if len(stmt) > 40 {
-			stmt = stmt[:40] + "..."
-		}
-		return fmt.Sprintf("[%d] sql %s", i+1, stmt)
-	default:
-		return fmt.Sprintf("[%d]", i+1)
-	}
+	return fmt.Sprintf("[%d] %s", i+1, s.Request.Label())
 }
@@ -0,0 +1,69 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScenario_RunContextTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var afterErr error
+	sc := NewScenario("slow").
+		WithTimeout(50 * time.Millisecond).
+		AddStep(GET("/slow").ExpectStatus(200)).
+		After(func(ctx context.Context) error {
+			afterErr = ctx.Err()
+			return nil
+		})
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Now()
+	err := sc.RunContext(t.Context(), log, HTTP("api", srv.URL), nil, make(VarStore))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("scenario was not aborted, took %v", elapsed)
+	}
+	if afterErr != nil {
+		t.Fatalf("expected after-func context to outlive the timeout, got %v", afterErr)
+	}
+}
+
+func TestScenario_RunContextHooks(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(t.Context(), ctxKey{}, "value")
+
+	var before, after any
+	sc := NewScenario("hooks").
+		Before(func(ctx context.Context) error {
+			before = ctx.Value(ctxKey{})
+			return nil
+		}).
+		After(func(ctx context.Context) error {
+			after = ctx.Value(ctxKey{})
+			return nil
+		})
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := sc.RunContext(ctx, log, nil, nil, make(VarStore)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if before != "value" || after != "value" {
+		t.Fatalf("expected hooks to receive context values, got before=%v after=%v", before, after)
+	}
+}
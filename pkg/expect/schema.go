@@ -1,54 +1,156 @@
 package expect
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
 type expectFile struct {
-	Connections []fileConnection `yaml:"connections" json:"connections"`
-	Scenarios   []fileScenario   `yaml:"scenarios"   json:"scenarios"`
+	Connections  []fileConnection           `yaml:"connections,omitempty"  json:"connections,omitempty"`
+	Scenarios    []fileScenario             `yaml:"scenarios,omitempty"    json:"scenarios,omitempty"`
+	Environments map[string]fileEnvironment `yaml:"environments,omitempty" json:"environments,omitempty"`
+
+	src fileSource
 }
 
 type fileConnection struct {
-	Name string `yaml:"name" json:"name"`
-	Type string `yaml:"type" json:"type"`
-	URL  string `yaml:"url"  json:"url"`
+	Name    string `yaml:"name,omitempty"    json:"name,omitempty"`
+	Type    string `yaml:"type,omitempty"    json:"type,omitempty"`
+	URL     string `yaml:"url,omitempty"     json:"url,omitempty"`
+	OpenAPI string `yaml:"openapi,omitempty" json:"openapi,omitempty"` // http only; path relative to the file
+}
+
+// fileEnvironment overrides connection URLs, by connection name, and seeds
+// variables and secrets when its environment is selected; see WithEnvironment.
+type fileEnvironment struct {
+	Connections map[string]string `yaml:"connections,omitempty" json:"connections,omitempty"`
+	Vars        map[string]any    `yaml:"vars,omitempty"        json:"vars,omitempty"`
+	Secrets     map[string]any    `yaml:"secrets,omitempty"     json:"secrets,omitempty"` // vars masked in logs
 }
 
 type fileScenario struct {
-	Name  string     `yaml:"name"  json:"name"`
-	Steps []fileStep `yaml:"steps" json:"steps"`
+	Name     string     `yaml:"name,omitempty"     json:"name,omitempty"`
+	Parallel bool       `yaml:"parallel,omitempty" json:"parallel,omitempty"`
+	Timeout  string     `yaml:"timeout,omitempty"  json:"timeout,omitempty"`
+	Steps    []fileStep `yaml:"steps,omitempty"    json:"steps,omitempty"`
 }
 
 type fileStep struct {
-	Request *fileRequest     `yaml:"request" json:"request"`
-	Expect  *fileExpectation `yaml:"expect"  json:"expect"`
+	Request *fileRequest     `yaml:"request,omitempty" json:"request,omitempty"`
+	Expect  *fileExpectation `yaml:"expect,omitempty"  json:"expect,omitempty"`
+	Retry   *fileRetry       `yaml:"retry,omitempty"   json:"retry,omitempty"`
+
+	// raw keeps the untyped request and expect blocks for registered step builders.
+	raw rawFileStep
+	// src resolves files the step refers to, relative to its file.
+	src fileSource
+}
+
+type rawFileStep struct {
+	Request map[string]any `yaml:"request,omitempty" json:"request,omitempty"`
+	Expect  map[string]any `yaml:"expect,omitempty"  json:"expect,omitempty"`
+}
+
+func (s *fileStep) UnmarshalYAML(node *yaml.Node) error {
+	type plain fileStep
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	return node.Decode(&s.raw)
+}
+
+func (s *fileStep) UnmarshalJSON(data []byte) error {
+	type plain fileStep
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &s.raw)
+}
+
+type fileRetry struct {
+	Attempts     int    `yaml:"attempts,omitempty"      json:"attempts,omitempty"`
+	Interval     string `yaml:"interval,omitempty"      json:"interval,omitempty"`
+	Backoff      string `yaml:"backoff,omitempty"       json:"backoff,omitempty"`
+	UntilTimeout string `yaml:"until_timeout,omitempty" json:"until_timeout,omitempty"`
 }
 
 type fileRequest struct {
-	Connection string            `yaml:"connection" json:"connection"`
-	Method     string            `yaml:"method"     json:"method"`
-	Endpoint   string            `yaml:"endpoint"   json:"endpoint"`
-	Body       any               `yaml:"body"       json:"body"`
-	Header     map[string]string `yaml:"header"     json:"header"`
-	Query      map[string]string `yaml:"query"      json:"query"`
+	Connection string            `yaml:"connection,omitempty" json:"connection,omitempty"`
+	Method     string            `yaml:"method,omitempty"     json:"method,omitempty"`
+	Endpoint   string            `yaml:"endpoint,omitempty"   json:"endpoint,omitempty"`
+	Body       any               `yaml:"body,omitempty"       json:"body,omitempty"`
+	Header     map[string]string `yaml:"header,omitempty"     json:"header,omitempty"`
+	Query      map[string]string `yaml:"query,omitempty"      json:"query,omitempty"`
+
+	// gRPC streaming fields
+	Messages []any `yaml:"messages,omitempty" json:"messages,omitempty"`
 
 	// SQL-specific fields
-	Statement string `yaml:"statement" json:"statement"`
-	Params    []any  `yaml:"params"    json:"params"`
-	Exec      bool   `yaml:"exec"      json:"exec"`
+	Statement string `yaml:"statement,omitempty" json:"statement,omitempty"`
+	Params    []any  `yaml:"params,omitempty"    json:"params,omitempty"`
+	Exec      bool   `yaml:"exec,omitempty"      json:"exec,omitempty"`
+
+	// rawBody is sent as is, instead of Body as JSON; set by .http files.
+	rawBody []byte
 }
 
 type fileExpectation struct {
-	Status int               `yaml:"status" json:"status"`
-	Code   string            `yaml:"code"   json:"code"`
-	Header map[string]string `yaml:"header" json:"header"`
-	Body   any               `yaml:"body"   json:"body"`
-	Save   []fileSaveEntry   `yaml:"save"   json:"save"`
+	Status int               `yaml:"status,omitempty" json:"status,omitempty"`
+	Code   string            `yaml:"code,omitempty"   json:"code,omitempty"`
+	Header map[string]string `yaml:"header,omitempty" json:"header,omitempty"`
+	Body   any               `yaml:"body,omitempty"   json:"body,omitempty"`
+	Match  fileMatch         `yaml:"match,omitempty"  json:"match,omitempty"`  // strict, ordered and/or exact-length
+	Schema any               `yaml:"schema,omitempty" json:"schema,omitempty"` // JSON Schema file path or inline schema
+	Assert []string          `yaml:"assert,omitempty" json:"assert,omitempty"` // expressions that must hold
+	Save   []fileSaveEntry   `yaml:"save,omitempty"   json:"save,omitempty"`
+
+	// gRPC streaming fields
+	Messages     []any `yaml:"messages,omitempty"      json:"messages,omitempty"`
+	MessageCount *int  `yaml:"message_count,omitempty" json:"message_count,omitempty"`
+	Unordered    bool  `yaml:"unordered,omitempty"     json:"unordered,omitempty"`
 
 	// SQL-specific fields
-	RowCount     *int  `yaml:"row_count"     json:"row_count"`
-	RowsAffected *int  `yaml:"rows_affected" json:"rows_affected"`
-	Rows         []any `yaml:"rows"          json:"rows"`
+	RowCount     *int  `yaml:"row_count,omitempty"     json:"row_count,omitempty"`
+	RowsAffected *int  `yaml:"rows_affected,omitempty" json:"rows_affected,omitempty"`
+	Rows         []any `yaml:"rows,omitempty"          json:"rows,omitempty"`
 }
 
 type fileSaveEntry struct {
-	Field string `yaml:"field" json:"field"`
-	As    string `yaml:"as"    json:"as"`
+	Field   string `yaml:"field,omitempty"   json:"field,omitempty"`
+	As      string `yaml:"as,omitempty"      json:"as,omitempty"`
+	Message *int   `yaml:"message,omitempty" json:"message,omitempty"` // gRPC streams: message index to save from
+	Secret  bool   `yaml:"secret,omitempty"  json:"secret,omitempty"`  // mask the value in logs and reports
+}
+
+// entry converts the save entry, saving Field from the Message'th message of
+// gRPC streams when it is set.
+func (sv fileSaveEntry) entry() SaveEntry {
+	e := SaveEntry{Field: sv.Field, As: sv.As, Secret: sv.Secret}
+	if sv.Message != nil {
+		e.Field = fmt.Sprintf("%d.%s", *sv.Message, sv.Field)
+	}
+	return e
+}
+
+// fileMatch lists the match modes of an expectation; a single mode may be
+// written as a plain string.
+type fileMatch []string
+
+func (m *fileMatch) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*m = fileMatch{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(m))
+}
+
+func (m *fileMatch) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*m = fileMatch{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(m))
 }
@@ -0,0 +1,106 @@
+package expect
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// Scripter is implemented by Requesters that can be replayed from a shell.
+// HTTP requests render as curl, gRPC requests as grpcurl, and SQL requests as
+// psql, mysql or sqlite3 commands.
+type Scripter interface {
+	// Script renders the request against conn as a shell command, with
+	// variables from vars interpolated. conn may be nil.
+	Script(conn Connection, vars VarStore) string
+}
+
+// scripter is implemented by the built-in requests, which render with an
+// interpolator, such as that of a running scenario.
+type scripter interface {
+	script(conn Connection, in interpolator) string
+}
+
+// Script renders the scenario as a shell script that replays each step's
+// request, with variables from vars interpolated, such as those a run saved.
+// Secret values are masked as ***; see Suite.Script for the suite's secrets.
+// Steps whose request does not implement Scripter appear as comments.
+func (s *Scenario) Script(defaultConn Connection, connections map[string]Connection, vars VarStore) string {
+	return s.script(scenarioEnv{defaultConn: defaultConn, connections: connections}, vars)
+}
+
+// Script renders a scenario of the suite as a shell script against the
+// suite's connections, masking the suite's secrets; see Scenario.Script.
+func (s *Suite) Script(sc *Scenario, vars VarStore) string {
+	return sc.script(s.scenarioEnv(0, s.log, runStepDirect), vars)
+}
+
+// script renders the scenario in env, masking the secrets env knows about and
+// those its steps save. It interpolates into a copy of vars, so generators
+// saved with as leave the caller's variables alone.
+func (s *Scenario) script(env scenarioEnv, vars VarStore) string {
+	vars = maps.Clone(vars)
+	if vars == nil {
+		vars = VarStore{}
+	}
+	st := env.newVarState(s.Name, vars)
+	in := st.interpolator()
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Scenario: %s\n", s.Name)
+	for i, step := range s.steps {
+		st.startStep()
+		st.markSecret(secretSaves(step)...)
+		b.WriteString("\n# " + in.interpolate(stepLabel(i, step)) + "\n")
+		if cmd := stepScript(step, stepConnection(step, env.defaultConn, env.connections), in); cmd != "" {
+			b.WriteString(cmd + "\n")
+		} else {
+			b.WriteString("# (no shell equivalent)\n")
+		}
+	}
+	return st.redact(b.String())
+}
+
+// stepConnection returns the connection the step runs against.
+func stepConnection(step Step, defaultConn Connection, connections map[string]Connection) Connection {
+	if c, ok := connections[step.Connection]; ok && step.Connection != "" {
+		return c
+	}
+	return defaultConn
+}
+
+// stepScript renders the step's request, or "" when it has no shell
+// equivalent. The built-in requests interpolate with in, so generators keep
+// the values of the step's run.
+func stepScript(step Step, conn Connection, in interpolator) string {
+	switch s := step.Request.(type) {
+	case scripter:
+		return s.script(conn, in)
+	case Scripter:
+		return s.Script(conn, in.vars)
+	}
+	return ""
+}
+
+// withReplay adds the shell command that replays a failed step to its error.
+func withReplay(err error, step Step, conn Connection, in interpolator) error {
+	cmd := stepScript(step, conn, in)
+	if cmd == "" {
+		return err
+	}
+	return fmt.Errorf("%w\nreplay:\n  %s", err, strings.ReplaceAll(cmd, "\n", "\n  "))
+}
+
+// shellQuote quotes s as a single shell word.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellCommand joins the parts of a command, such as "-H 'Accept: */*'", one
+// per line.
+func shellCommand(parts ...string) string {
+	return strings.Join(parts, " \\\n  ")
+}
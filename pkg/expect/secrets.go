@@ -0,0 +1,265 @@
+package expect
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Secret variables, such as tokens and passwords, are still sent in requests,
+// but their values are masked as *** in logs, step labels, errors, reports and
+// scripts. A variable is secret when it is saved with SaveEntry.Secret, named
+// with Suite.WithSecrets, or when its name matches a secret pattern; see
+// Suite.WithSecretPatterns. A running scenario keeps what it knows about its
+// secrets in its varState.
+
+// redacted replaces secret values.
+const redacted = "***"
+
+// minSecretLength is the length below which secret values are not masked, as
+// masking a short value, such as 1 or "ab", would hide unrelated text too.
+const minSecretLength = 4
+
+// defaultSecretPatterns match the names of variables that are secret unless
+// marked otherwise, case-insensitively.
+//
+//nolint:gochecknoglobals // read-only pattern list
+var defaultSecretPatterns = []string{
+	"token", "*_token", "password", "*_password", "secret", "*_secret", "api_key", "*_api_key", "apikey",
+}
+
+// secretSet is what a scenario knows about its secrets.
+type secretSet struct {
+	names    map[string]bool // variables marked secret
+	patterns []string        // name patterns of secret variables
+	values   []string        // secret values from outside variables, such as the environment
+}
+
+func newSecretSet() *secretSet {
+	return &secretSet{names: map[string]bool{}, patterns: defaultSecretPatterns}
+}
+
+// isSecret reports whether the variable name is one of the secrets.
+func (s *secretSet) isSecret(name string) bool {
+	return s.names[name] || isSecretName(name, s.patterns)
+}
+
+func isSecretName(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// currentRedactor returns a replacer masking the secret values of the
+// scenario's variables, or nil when there are none. It is kept until a
+// variable is set or marked secret.
+func (st *varState) currentRedactor() *strings.Replacer {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.redactorFresh {
+		st.redactor, st.redactorFresh = newRedactor(st.vars, st.secrets), true
+	}
+	return st.redactor
+}
+
+// newRedactor returns a replacer masking the secret values of vars, or nil
+// when there are none. Values are also masked in their URL- and JSON-escaped
+// forms, as they appear in paths, queries and bodies.
+func newRedactor(vars VarStore, secrets *secretSet) *strings.Replacer {
+	values := slices.Clone(secrets.values)
+	for name, val := range vars {
+		if secrets.isSecret(name) {
+			values = appendSecretValues(values, val)
+		}
+	}
+	values = slices.DeleteFunc(values, func(s string) bool { return len(s) < minSecretLength })
+	if len(values) == 0 {
+		return nil
+	}
+	var forms []string
+	for _, s := range values {
+		quoted, _ := json.Marshal(s)
+		forms = append(forms, s, url.QueryEscape(s), url.PathEscape(s), string(quoted[1:len(quoted)-1]))
+	}
+	// Longer values first, so a secret containing another is masked whole.
+	slices.SortFunc(forms, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+	forms = slices.Compact(forms)
+	oldnew := make([]string, 0, 2*len(forms))
+	for _, s := range forms {
+		oldnew = append(oldnew, s, redacted)
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// appendSecretValues appends the strings and numbers in val, at any depth.
+func appendSecretValues(values []string, val any) []string {
+	switch x := val.(type) {
+	case nil, bool:
+	case string:
+		values = append(values, x)
+	case map[string]any:
+		for _, e := range x {
+			values = appendSecretValues(values, e)
+		}
+	case []any:
+		for _, e := range x {
+			values = appendSecretValues(values, e)
+		}
+	default:
+		values = append(values, varString(x))
+	}
+	return values
+}
+
+// redact masks the secret values in s.
+func (st *varState) redact(s string) string {
+	if r := st.currentRedactor(); r != nil {
+		return r.Replace(s)
+	}
+	return s
+}
+
+// redactValue masks the secret values in the strings of a decoded JSON value.
+func redactValue(r *strings.Replacer, val any) any {
+	switch x := val.(type) {
+	case string:
+		return r.Replace(x)
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, e := range x {
+			out[r.Replace(k)] = redactValue(r, e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = redactValue(r, e)
+		}
+		return out
+	}
+	return val
+}
+
+// redactError masks the secret values in err's message, and in the
+// *MismatchError it may wrap. It returns err itself when there are none.
+func (st *varState) redactError(err error) error {
+	r := st.currentRedactor()
+	if err == nil || r == nil {
+		return err
+	}
+	msg := r.Replace(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg, r: r}
+}
+
+// redactedError is an error with the secret values in its message masked.
+type redactedError struct {
+	err error
+	msg string
+	r   *strings.Replacer
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+// Unwrap keeps errors.Is working, such as for context.DeadlineExceeded.
+func (e *redactedError) Unwrap() error { return e.err }
+
+// As gives a masked copy of the *MismatchError the error wraps, so reports
+// built from its details do not show secret values either.
+func (e *redactedError) As(target any) bool {
+	t, ok := target.(**MismatchError)
+	if !ok {
+		return false
+	}
+	var m *MismatchError
+	if !errors.As(e.err, &m) {
+		return false
+	}
+	c := *m
+	c.Reason = e.r.Replace(m.Reason)
+	c.Expected, c.Actual = redactValue(e.r, m.Expected), redactValue(e.r, m.Actual)
+	c.expectedBody, c.actualBody = redactValue(e.r, m.expectedBody), redactValue(e.r, m.actualBody)
+	*t = &c
+	return true
+}
+
+// redactHandler masks the secret values of a scenario's variables in the
+// messages and attributes of log records.
+type redactHandler struct {
+	h  slog.Handler
+	st *varState
+}
+
+// redactLogger returns log, masking the secret values of the scenario st is
+// the state of.
+func redactLogger(log *slog.Logger, st *varState) *slog.Logger {
+	return slog.New(&redactHandler{h: log.Handler(), st: st})
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, rec slog.Record) error {
+	r := h.st.currentRedactor()
+	if r == nil {
+		return h.h.Handle(ctx, rec)
+	}
+	out := slog.NewRecord(rec.Time, rec.Level, r.Replace(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(r, a))
+		return true
+	})
+	return h.h.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if r := h.st.currentRedactor(); r != nil {
+		redactedAttrs := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			redactedAttrs[i] = redactAttr(r, a)
+		}
+		attrs = redactedAttrs
+	}
+	return &redactHandler{h: h.h.WithAttrs(attrs), st: h.st}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{h: h.h.WithGroup(name), st: h.st}
+}
+
+func redactAttr(r *strings.Replacer, a slog.Attr) slog.Attr {
+	val := a.Value.Resolve()
+	switch val.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.Replace(val.String()))
+	case slog.KindGroup:
+		group := val.Group()
+		attrs := make([]any, len(group))
+		for i, ga := range group {
+			attrs[i] = redactAttr(r, ga)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		s := fmt.Sprint(val.Any())
+		if masked := r.Replace(s); masked != s {
+			return slog.String(a.Key, masked)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: val}
+}
@@ -0,0 +1,178 @@
+package expect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestVarStore_redact(t *testing.T) {
+	vars := VarStore{
+		"auth_token": "s3cr3t/+x",
+		"Password":   "hunter2",
+		"API_KEY":    "k-123",
+		"user":       "alice",
+		"session":    map[string]any{"id": "sess-1", "tags": []any{"t-99"}},
+		"pin":        "0000",
+		"otp_secret": float64(42),
+	}
+	st := scenarioEnv{}.newVarState("redact", vars)
+	st.markSecret("session")
+	tests := []struct {
+		in, want string
+	}{
+		{"Bearer s3cr3t/+x", "Bearer ***"},
+		{"/items?t=s3cr3t%2F%2Bx", "/items?t=***"},
+		{"/items/s3cr3t%2F+x", "/items/***"},
+		{`{"p":"hunter2","user":"alice"}`, `{"p":"***","user":"alice"}`},
+		{"k-123 sess-1 t-99", "*** *** ***"},
+		{"pin 0000", "pin 0000"},
+		{"answer 42", "answer 42"},
+	}
+	for _, tt := range tests {
+		if got := st.redact(tt.in); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+	if r := st.currentRedactor(); st.currentRedactor() != r {
+		t.Error("expected the redactor to be kept")
+	}
+	st.markSecret("user")
+	if got := st.redact("alice"); got != redacted {
+		t.Errorf("expected a variable marked secret to be masked, got %q", got)
+	}
+	vars["API_KEY"] = "k-456"
+	st.varsChanged()
+	if got := st.redact("k-123 k-456"); got != "k-123 ***" {
+		t.Errorf("expected the new value masked, got %q", got)
+	}
+	plain := scenarioEnv{}.newVarState("plain", VarStore{"user": "alice"})
+	if got := plain.redact("alice"); got != "alice" {
+		t.Errorf("expected nothing masked without secrets, got %q", got)
+	}
+	if _, ok := vars["session"]; !ok || len(vars) != 7 {
+		t.Errorf("expected the secrets kept out of the variables, got %v", vars)
+	}
+}
+
+func TestSuite_secrets(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization")+r.Header.Get("X-Api-Key"))
+		mu.Unlock()
+		if r.URL.Path == "/login" {
+			_, _ = w.Write([]byte(`{"token": "tok-123", "session": "sess-456"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"user": "alice", "session": "sess-456"}`))
+	}))
+	defer srv.Close()
+
+	var logs, report bytes.Buffer
+	suite := NewSuite().
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))).
+		WithReporter(NewJSONReporter(&report)).
+		WithSecrets(map[string]any{"key": "key-789"}).
+		WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(NewScenario("login").
+			AddStep(POST("/login").WithHeader("X-Api-Key", "{key}").
+				Save("token", "token").SaveSecret("session", "sess")).
+			AddStep(GET("/me?session={sess}").WithHeader("Authorization", "Bearer {token}").
+				ExpectBody(map[string]any{"user": "bob"})))
+
+	err := suite.RunContext(context.Background())
+	if err == nil {
+		t.Fatal("expected the second step to fail")
+	}
+	if got := strings.Join(seen, ","); got != "key-789,Bearer tok-123" {
+		t.Errorf("expected the real secrets to be sent, got %q", got)
+	}
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected a mismatch, got %v", err)
+	}
+	for name, out := range map[string]string{
+		"error": err.Error(), "diff": mismatch.Diff(), "logs": logs.String(), "report": report.String(),
+	} {
+		for _, secret := range []string{"tok-123", "sess-456", "key-789"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s shows the secret %q:\n%s", name, secret, out)
+			}
+		}
+		if !strings.Contains(out, redacted) {
+			t.Errorf("%s has nothing masked:\n%s", name, out)
+		}
+	}
+}
+
+func TestStepBuilder_saveSecrets(t *testing.T) {
+	vars := VarStore{}
+	st := scenarioEnv{}.newVarState("saves", vars)
+
+	sqlStep := SQLStep("db", "SELECT 1").SaveSQL("id", "id").SaveSQLSecret("pin", "pin").Build()
+	result := &SQLResult{Rows: []map[string]any{{"id": "id-1", "pin": "pin-1"}}}
+	if err := sqlStep.Expect.(*SQLExpect).Validate(result, vars); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	grpcStep := GRPCRawCall("svc", "/a.B/C", nil).SaveGRPCSecret("key", "key").Build()
+	if err := grpcStep.Expect.(*GRPCExpect).Validate([]byte(`{"key":"key-1"}`), nil, vars); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st.markSecret(secretSaves(sqlStep)...)
+	st.markSecret(secretSaves(grpcStep)...)
+	if got := st.redact("id-1 pin-1 key-1"); got != "id-1 *** ***" {
+		t.Errorf("expected the secret saves masked, got %q", got)
+	}
+}
+
+func TestLoadYAML_secrets(t *testing.T) {
+	t.Setenv("GO_EXPECT_TEST_TOKEN", "env-token")
+	suite, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:1
+environments:
+  dev:
+    secrets:
+      signing: sig-1
+scenarios:
+  - name: secrets
+    steps:
+      - request:
+          method: GET
+          endpoint: /${GO_EXPECT_TEST_TOKEN}
+        expect:
+          save:
+            - field: id
+              as: id
+              secret: true
+`), WithEnvironment("dev"))
+	if err != nil {
+		t.Fatalf("LoadYAML error: %v", err)
+	}
+	sc := suite.scenarios[0]
+	if save := sc.steps[0].Expect.(*HTTPExpect).Save; !save[0].Secret {
+		t.Errorf("expected a secret save entry, got %+v", save)
+	}
+	vars := suite.newVars()
+	st := suite.scenarioEnv(0, suite.log, runStepDirect).newVarState(sc.Name, vars)
+	vars["id"] = "id-1"
+	st.markSecret(secretSaves(sc.steps[0])...)
+	if got := st.redact("env-token sig-1 id-1"); got != "*** *** ***" {
+		t.Errorf("expected the environment's secrets masked, got %q", got)
+	}
+	if got := st.redact(stepLabel(0, sc.steps[0])); got != "[1] GET /***" {
+		t.Errorf("expected the step label masked, got %q", got)
+	}
+}
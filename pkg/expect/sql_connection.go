@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -17,6 +18,8 @@ type SQLConnection struct {
 	Driver  string
 	Timeout time.Duration // per-query timeout; 0 means use DefaultSQLTimeout
 	DB      *sql.DB
+
+	mu sync.Mutex
 }
 
 // SQL creates a SQLConnection with an explicit driver.
@@ -28,33 +31,52 @@ func (c *SQLConnection) Type() string    { return c.Driver }
 func (c *SQLConnection) GetName() string { return c.Name }
 
 // Dial opens the underlying database connection, dialling if necessary.
+// It is safe for concurrent use.
 func (c *SQLConnection) Dial() error {
-	if c.DB != nil {
-		return nil
-	}
-	db, err := sql.Open(c.Driver, c.DSN)
-	if err != nil {
-		return fmt.Errorf("sql open %q: %w", c.DSN, err)
-	}
-	c.DB = db
-	return nil
+	_, err := c.db()
+	return err
 }
 
 // Close closes the database connection.
 func (c *SQLConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.DB != nil {
-		return c.DB.Close()
+		err := c.DB.Close()
+		c.DB = nil
+		return err
 	}
 	return nil
 }
 
+// withURL returns a new connection to dsn, keeping the driver and timeout.
+func (c *SQLConnection) withURL(dsn string) Connection {
+	return &SQLConnection{Name: c.Name, DSN: dsn, Driver: c.Driver, Timeout: c.Timeout}
+}
+
+// db returns the underlying *sql.DB, opening it if necessary.
+func (c *SQLConnection) db() (*sql.DB, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.DB != nil {
+		return c.DB, nil
+	}
+	db, err := sql.Open(c.Driver, c.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql open %q: %w", c.DSN, err)
+	}
+	c.DB = db
+	return db, nil
+}
+
 // QueryContext executes a query and returns rows as []map[string]any.
 func (c *SQLConnection) QueryContext(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
-	if err := c.Dial(); err != nil {
+	db, err := c.db()
+	if err != nil {
 		return nil, err
 	}
 
-	rows, err := c.DB.QueryContext(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("sql query: %w", err)
 	}
@@ -87,11 +109,12 @@ func (c *SQLConnection) QueryContext(ctx context.Context, query string, args ...
 
 // ExecContext executes a statement and returns the number of rows affected.
 func (c *SQLConnection) ExecContext(ctx context.Context, query string, args ...any) (int64, error) {
-	if err := c.Dial(); err != nil {
+	db, err := c.db()
+	if err != nil {
 		return 0, err
 	}
 
-	result, err := c.DB.ExecContext(ctx, query, args...)
+	result, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, fmt.Errorf("sql exec: %w", err)
 	}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // SQLRequest describes a SQL query or exec to run against a SQLConnection.
@@ -19,25 +23,38 @@ type SQLResult struct {
 	RowsAffected int64
 }
 
+// Label describes the request as "sql <statement>", truncated to 40 characters.
+func (r *SQLRequest) Label() string {
+	stmt := r.Statement
+	if len(stmt) > 40 {
+		stmt = stmt[:40] + "..."
+	}
+	return "sql " + stmt
+}
+
+// Do implements Requester; conn must be a *SQLConnection.
+func (r *SQLRequest) Do(ctx context.Context, conn Connection, vars VarStore) (any, error) {
+	sqlConn, ok := conn.(*SQLConnection)
+	if !ok {
+		return nil, mismatchedConnection("SQL", conn)
+	}
+	return r.Run(ctx, sqlConn, vars)
+}
+
 // Run executes the SQL request against conn, interpolating variables from vars.
-func (r *SQLRequest) Run(conn *SQLConnection, vars VarStore) (*SQLResult, error) {
+// The connection timeout is applied on top of ctx.
+func (r *SQLRequest) Run(ctx context.Context, conn *SQLConnection, vars VarStore) (*SQLResult, error) {
 	timeout := conn.Timeout
 	if timeout == 0 {
 		timeout = DefaultSQLTimeout
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	stmt := vars.Interpolate(r.Statement)
+	in := newInterpolator(ctx, vars)
+	stmt := in.interpolate(r.Statement)
 
-	params := make([]any, len(r.Params))
-	for i, p := range r.Params {
-		if s, ok := p.(string); ok {
-			params[i] = vars.Interpolate(s)
-		} else {
-			params[i] = p
-		}
-	}
+	params := r.params(in)
 
 	if r.Exec {
 		affected, err := conn.ExecContext(ctx, stmt, params...)
@@ -54,14 +71,43 @@ func (r *SQLRequest) Run(conn *SQLConnection, vars VarStore) (*SQLResult, error)
 	return &SQLResult{Rows: rows}, nil
 }
 
+// params interpolates the statement parameters. A parameter that is exactly
+// "{key}" takes the saved value with its type; objects and arrays are passed
+// as JSON text.
+func (r *SQLRequest) params(in interpolator) []any {
+	params := make([]any, len(r.Params))
+	for i, p := range r.Params {
+		params[i] = in.interpolateValue(p)
+		switch params[i].(type) {
+		case map[string]any, []any:
+			params[i] = varString(params[i])
+		}
+	}
+	return params
+}
+
 // SQLExpect validates a SQL result.
 type SQLExpect struct {
 	RowCount     *int
 	RowsAffected *int64
 	Rows         []ExpectBody
+	Schema       Matcher  // if set, matched against every row, e.g. JSONSchema
+	Assert       []string // expressions over rows and rows_affected that must hold
 	Save         []SaveEntry
 }
 
+// Check implements Expectation; resp must be a *SQLResult.
+func (e *SQLExpect) Check(resp any, err error, vars VarStore) error {
+	if err != nil {
+		return err
+	}
+	result, ok := resp.(*SQLResult)
+	if !ok {
+		return mismatchedResponse("SQL", resp)
+	}
+	return e.Validate(result, vars)
+}
+
 // Validate checks the result against expectations, saving extracted values into vars.
 func (e *SQLExpect) Validate(result *SQLResult, vars VarStore) error {
 	if e.RowCount != nil {
@@ -76,6 +122,18 @@ func (e *SQLExpect) Validate(result *SQLResult, vars VarStore) error {
 		}
 	}
 
+	if e.Schema != nil {
+		for i, row := range result.Rows {
+			actualJSON, err := json.Marshal(row)
+			if err != nil {
+				return fmt.Errorf("marshal actual row [%d]: %w", i, err)
+			}
+			if err := matchJSON(actualJSON, e.Schema, vars); err != nil {
+				return fmt.Errorf("row [%d]: %w", i, err)
+			}
+		}
+	}
+
 	for i, expectedRow := range e.Rows {
 		if i >= len(result.Rows) {
 			return fmt.Errorf("expected row [%d] but only got %d rows", i, len(result.Rows))
@@ -84,11 +142,23 @@ func (e *SQLExpect) Validate(result *SQLResult, vars VarStore) error {
 		if err != nil {
 			return fmt.Errorf("marshal actual row [%d]: %w", i, err)
 		}
-		if err := expectedRow.Validate(actualJSON); err != nil {
+		if err := expectedRow.validate(actualJSON, vars); err != nil {
 			return fmt.Errorf("row [%d]: %w", i, err)
 		}
 	}
 
+	if len(e.Assert) > 0 {
+		// Rows are compared as JSON, as above, whatever types the driver scanned.
+		var rows any = []any{}
+		if data, err := json.Marshal(result.Rows); err == nil && result.Rows != nil {
+			rows = exprJSON(data)
+		}
+		bindings := map[string]any{"rows": rows, "rows_affected": result.RowsAffected}
+		if err := checkAssertions(e.Assert, vars, bindings); err != nil {
+			return err
+		}
+	}
+
 	if len(e.Save) > 0 && vars != nil && len(result.Rows) > 0 {
 		firstRow, err := json.Marshal(result.Rows[0])
 		if err == nil {
@@ -98,3 +168,156 @@ func (e *SQLExpect) Validate(result *SQLResult, vars VarStore) error {
 
 	return nil
 }
+
+// saves returns the variables the expectation saves.
+func (e *SQLExpect) saves() []SaveEntry { return e.Save }
+
+//nolint:gochecknoglobals // compiled once
+var (
+	sqlDollarParamRe = regexp.MustCompile(`^\$\d+`)
+	pgPasswordRe     = regexp.MustCompile(`\s*\bpassword\s*=\s*(?:'(?:[^'\\]|\\.)*'|\S*)`)
+	mysqlDSNRe       = regexp.MustCompile(`^(?:([^:@]*)(?::([^@]*))?@)?(?:\w+\(([^)]*)\))?/([^?]*)`)
+)
+
+// Script implements Scripter as a psql, mysql or sqlite3 command, with the
+// statement's parameters inlined as SQL literals. The DSN's password is left
+// out, and the command prompts for it instead.
+func (r *SQLRequest) Script(conn Connection, vars VarStore) string {
+	return r.script(conn, interpolator{vars: vars})
+}
+
+func (r *SQLRequest) script(conn Connection, in interpolator) string {
+	c, ok := conn.(*SQLConnection)
+	if !ok {
+		return ""
+	}
+	stmt := r.inlineParams(c.Driver, in)
+	switch c.Driver {
+	case "postgres", "pgx":
+		head := "psql "
+		dsn, hadPassword := stripPostgresPassword(c.DSN)
+		if hadPassword {
+			head += "-W "
+		}
+		return shellCommand(head+shellQuote(dsn), "-c "+shellQuote(stmt))
+	case "mysql":
+		m := mysqlDSNRe.FindStringSubmatch(c.DSN)
+		if m == nil {
+			return ""
+		}
+		head := "mysql"
+		if host, port, ok := strings.Cut(m[3], ":"); m[3] != "" {
+			head += " -h " + shellQuote(host)
+			if ok {
+				head += " -P " + shellQuote(port)
+			}
+		}
+		if m[1] != "" {
+			head += " -u " + shellQuote(m[1])
+		}
+		if m[2] != "" {
+			head += " -p"
+		}
+		return shellCommand(head+" "+shellQuote(m[4]), "-e "+shellQuote(stmt))
+	case "sqlite", "sqlite3":
+		return "sqlite3 " + shellQuote(strings.TrimPrefix(c.DSN, "file:")) + " " + shellQuote(stmt)
+	}
+	return ""
+}
+
+// stripPostgresPassword removes the password from a postgres URL or keyword
+// DSN, reporting whether it had one.
+func stripPostgresPassword(dsn string) (string, bool) {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.User != nil {
+		if _, ok := u.User.Password(); !ok {
+			return dsn, false
+		}
+		u.User = url.User(u.User.Username())
+		return u.String(), true
+	}
+	if !pgPasswordRe.MatchString(dsn) {
+		return dsn, false
+	}
+	return strings.TrimSpace(pgPasswordRe.ReplaceAllString(dsn, "")), true
+}
+
+// inlineParams interpolates the statement and replaces its $n or ?
+// placeholders with the parameters as SQL literals. Placeholders within
+// quoted literals and identifiers, such as 'why?', are left alone.
+func (r *SQLRequest) inlineParams(driver string, in interpolator) string {
+	stmt := in.interpolate(r.Statement)
+	params := r.params(in)
+	literal := func(i int) string {
+		if i < 0 || i >= len(params) {
+			return ""
+		}
+		return sqlLiteral(params[i])
+	}
+	dollar := driver == "postgres" || driver == "pgx"
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(stmt); {
+		switch ch := stmt[i]; {
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := sqlQuotedEnd(stmt, i, driver == "mysql")
+			b.WriteString(stmt[i:end])
+			i = end
+			continue
+		case dollar && ch == '$':
+			m := sqlDollarParamRe.FindString(stmt[i:])
+			k, _ := strconv.Atoi(strings.TrimPrefix(m, "$"))
+			if lit := literal(k - 1); lit != "" {
+				b.WriteString(lit)
+				i += len(m)
+				continue
+			}
+		case !dollar && ch == '?':
+			if lit := literal(n); lit != "" {
+				b.WriteString(lit)
+				n++
+				i++
+				continue
+			}
+		}
+		b.WriteByte(stmt[i])
+		i++
+	}
+	return b.String()
+}
+
+// sqlQuotedEnd returns the index just past the quoted literal or identifier
+// starting at stmt[start]. A doubled quote stands for itself, and with
+// backslashes, as in MySQL, a backslash escapes the next character.
+func sqlQuotedEnd(stmt string, start int, backslashes bool) int {
+	quote := stmt[start]
+	for i := start + 1; i < len(stmt); i++ {
+		switch stmt[i] {
+		case '\\':
+			if backslashes {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(stmt)
+}
+
+// sqlLiteral formats v as a SQL literal.
+func sqlLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "'" + strings.ReplaceAll(string(v), "'", "''") + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return fmt.Sprint(v)
+	}
+}
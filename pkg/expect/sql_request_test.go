@@ -109,6 +109,26 @@ func TestNormalizeValue(t *testing.T) {
 	}
 }
 
+func TestSQLRequest_inlineParams(t *testing.T) {
+	tests := []struct {
+		driver, stmt string
+		params       []any
+		want         string
+	}{
+		{"sqlite", "WHERE note = 'why?' AND id = ?", []any{7}, "WHERE note = 'why?' AND id = 7"},
+		{"sqlite", `SELECT "a?", 'it''s ?' WHERE id = ?`, []any{7}, `SELECT "a?", 'it''s ?' WHERE id = 7`},
+		{"mysql", `SELECT 'a\'?' FROM t WHERE id = ?`, []any{"x"}, `SELECT 'a\'?' FROM t WHERE id = 'x'`},
+		{"postgres", "SELECT '$1' || $1, $2", []any{"a", nil}, "SELECT '$1' || 'a', NULL"},
+		{"postgres", "SELECT $3", []any{1}, "SELECT $3"},
+	}
+	for _, tt := range tests {
+		r := &SQLRequest{Statement: tt.stmt, Params: tt.params}
+		if got := r.inlineParams(tt.driver, interpolator{}); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.stmt, tt.want, got)
+		}
+	}
+}
+
 func TestBuildFileSQLStep(t *testing.T) {
 	t.Run("query with row expectations", func(t *testing.T) {
 		rowCount := 1
@@ -1,96 +1,54 @@
 package expect
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 )
 
+// Requester is a request that can be executed against a Connection.
+// Implement it together with Expectation to add a protocol to go-expect.
+type Requester interface {
+	// Do executes the request against conn, interpolating variables from vars.
+	// The response and error are handed to the step's Expectation unchanged.
+	Do(ctx context.Context, conn Connection, vars VarStore) (any, error)
+	// Label is a short description of the request used in logs and test names.
+	Label() string
+}
+
+// Expectation validates the outcome of a Requester.
+type Expectation interface {
+	// Check validates the response and error returned by Requester.Do,
+	// saving any extracted values into vars.
+	Check(resp any, err error, vars VarStore) error
+}
+
 // Step is a single request/response pair within a scenario.
 type Step struct {
 	Connection string
-	Request    any
-	Expect     any
+	Request    Requester
+	Expect     Expectation
+	Retry      *RetryPolicy // nil means the step runs once
 }
 
 // Run executes the step against the given connection, applying variable interpolation.
-func (s *Step) Run(conn Connection, vars VarStore) error {
+// ctx bounds the request; cancelling it aborts an in-flight call.
+func (s *Step) Run(ctx context.Context, conn Connection, vars VarStore) error {
 	if s.Request == nil {
 		return nil
 	}
-	switch req := s.Request.(type) {
-	case *HTTPRequest:
-		httpConn, ok := conn.(*HTTPConnection)
-		if !ok {
-			return fmt.Errorf("mismatched connection type for HTTP request: %T", conn)
-		}
-		resp, err := req.Run(httpConn, vars)
-		if err != nil {
-			return err
-		}
-		return s.validateHTTP(resp, vars)
-
-	case *GRPCRequest:
-		grpcConn, ok := conn.(*GRPCConnection)
-		if !ok {
-			return fmt.Errorf("mismatched connection type for gRPC request: %T", conn)
-		}
-		respBytes, grpcErr := req.Run(grpcConn, vars)
-		return s.validateGRPC(respBytes, grpcErr, vars)
-
-	case *SQLRequest:
-		sqlConn, ok := conn.(*SQLConnection)
-		if !ok {
-			return fmt.Errorf("mismatched connection type for SQL request: %T", conn)
-		}
-		result, err := req.Run(sqlConn, vars)
-		if err != nil {
-			return err
-		}
-		return s.validateSQL(result, vars)
-
-	default:
-		return fmt.Errorf("unsupported request type: %T", s.Request)
-	}
-}
-
-func (s *Step) validateHTTP(resp *http.Response, vars VarStore) error {
+	resp, err := s.Request.Do(ctx, conn, vars)
 	if s.Expect == nil {
-		return nil
-	}
-	switch exp := s.Expect.(type) {
-	case *HTTPExpect:
-		return exp.Validate(resp, vars)
-	case HTTPExpect:
-		return exp.Validate(resp, vars)
-	default:
-		return fmt.Errorf("mismatched expect type for HTTP request: %T", s.Expect)
+		return err
 	}
+	return s.Expect.Check(resp, err, vars)
 }
 
-func (s *Step) validateSQL(result *SQLResult, vars VarStore) error {
-	if s.Expect == nil {
-		return nil
-	}
-	switch exp := s.Expect.(type) {
-	case *SQLExpect:
-		return exp.Validate(result, vars)
-	case SQLExpect:
-		return exp.Validate(result, vars)
-	default:
-		return fmt.Errorf("mismatched expect type for SQL request: %T", s.Expect)
-	}
+// mismatchedConnection reports a step routed to a connection of the wrong type.
+func mismatchedConnection(protocol string, conn Connection) error {
+	return fmt.Errorf("mismatched connection type for %s request: %T", protocol, conn)
 }
 
-func (s *Step) validateGRPC(respBytes []byte, grpcErr error, vars VarStore) error {
-	if s.Expect == nil {
-		return grpcErr
-	}
-	switch exp := s.Expect.(type) {
-	case *GRPCExpect:
-		return exp.Validate(respBytes, grpcErr, vars)
-	case GRPCExpect:
-		return exp.Validate(respBytes, grpcErr, vars)
-	default:
-		return fmt.Errorf("mismatched expect type for gRPC request: %T", s.Expect)
-	}
+// mismatchedResponse reports an expectation handed a response of the wrong type.
+func mismatchedResponse(protocol string, resp any) error {
+	return fmt.Errorf("mismatched response type for %s expectation: %T", protocol, resp)
 }
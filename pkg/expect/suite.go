@@ -1,10 +1,17 @@
 package expect
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Connection is a named connection to a service under test.
@@ -19,6 +26,18 @@ type Suite struct {
 	connections map[string]Connection
 	defaultConn Connection
 	log         *slog.Logger
+	parallelism int
+	reporters   multiReporter
+	seed        *int64   // seeds generator placeholders; nil draws at random
+	vars        VarStore // every scenario starts with a copy
+
+	secretNames    []string          // variables secret in every scenario
+	secretPatterns []string          // added to defaultSecretPatterns
+	env            map[string]string // environment variables the files were loaded with
+
+	coverageOn  bool
+	coverageMin float64
+	coverage    *Coverage // report of the last run
 }
 
 // NewSuite creates an empty Suite.
@@ -35,6 +54,51 @@ func (s *Suite) WithLogger(log *slog.Logger) *Suite {
 	return s
 }
 
+// WithParallelism sets the maximum number of parallel scenarios run at once.
+// n < 1 resets to the default of runtime.GOMAXPROCS(0).
+func (s *Suite) WithParallelism(n int) *Suite {
+	s.parallelism = n
+	return s
+}
+
+// WithSeed makes generator placeholders, such as {$uuid} or {$randInt 1 100},
+// draw from a source seeded with seed, so a run can be reproduced. Each
+// scenario has its own source, derived from the seed and the scenario's name,
+// so the values do not depend on which scenarios run or in what order. Times,
+// such as {$now}, are not affected.
+func (s *Suite) WithSeed(seed int64) *Suite {
+	s.seed = &seed
+	return s
+}
+
+// WithVars sets variables every scenario starts with, such as those of an
+// environment. Values a scenario saves override them for that scenario only.
+// Calling WithVars again adds to the variables.
+func (s *Suite) WithVars(vars map[string]any) *Suite {
+	if s.vars == nil {
+		s.vars = make(VarStore, len(vars))
+	}
+	maps.Copy(s.vars, vars)
+	return s
+}
+
+// WithSecrets is like WithVars, but marks the variables as secret, so their
+// values are masked as *** wherever they are logged or reported.
+func (s *Suite) WithSecrets(vars map[string]any) *Suite {
+	s.secretNames = append(s.secretNames, slices.Collect(maps.Keys(vars))...)
+	return s.WithVars(vars)
+}
+
+// WithSecretPatterns adds patterns, such as "*_key", matching the names of
+// secret variables, case-insensitively, in path.Match syntax. Variables named
+// token, password, secret or api_key, or ending in _token, _password, _secret
+// or _api_key, are secret by default. Environment variables a file was loaded
+// with are secret by the same patterns.
+func (s *Suite) WithSecretPatterns(patterns ...string) *Suite {
+	s.secretPatterns = append(s.secretPatterns, patterns...)
+	return s
+}
+
 // WithConnections registers one or more named connections.
 // The first connection registered becomes the default for steps with no explicit connection.
 func (s *Suite) WithConnections(conns ...Connection) *Suite {
@@ -47,6 +111,58 @@ func (s *Suite) WithConnections(conns ...Connection) *Suite {
 	return s
 }
 
+// WithConnectionURL points the named connection at url, recreating it with the
+// factory registered for its type. Built-in connections keep their other
+// settings, such as an OpenAPI document, gRPC dial options or a SQL timeout.
+// The replaced connection is closed if it can be. Steps keep resolving it by
+// name, so this is how a loaded file is retargeted without editing it.
+func (s *Suite) WithConnectionURL(name, url string) error {
+	old, ok := s.connections[name]
+	if !ok {
+		return fmt.Errorf("go-expect: unknown connection %q", name)
+	}
+	var conn Connection
+	if c, ok := old.(interface{ withURL(url string) Connection }); ok {
+		conn = c.withURL(url)
+	} else {
+		factory, err := registry.connectionFactory(old.Type())
+		if err != nil {
+			return err
+		}
+		if conn, err = factory(name, url); err != nil {
+			return fmt.Errorf("go-expect: connection %q: %w", name, err)
+		}
+	}
+	s.connections[name] = conn
+	if s.defaultConn.GetName() == name {
+		s.defaultConn = conn
+	}
+	if c, ok := old.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("go-expect: close connection %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Connections returns the suite's connections sorted by name.
+func (s *Suite) Connections() []Connection {
+	conns := slices.Collect(maps.Values(s.connections))
+	slices.SortFunc(conns, func(a, b Connection) int { return strings.Compare(a.GetName(), b.GetName()) })
+	return conns
+}
+
+// Filter keeps only the scenarios for which keep returns true.
+func (s *Suite) Filter(keep func(*Scenario) bool) *Suite {
+	s.scenarios = slices.DeleteFunc(s.scenarios, func(sc *Scenario) bool { return !keep(sc) })
+	return s
+}
+
+// Scenarios returns the suite's scenarios in run order.
+func (s *Suite) Scenarios() []*Scenario {
+	return slices.Clone(s.scenarios)
+}
+
 // WithScenarios appends scenarios to the suite.
 func (s *Suite) WithScenarios(scenarios ...*Scenario) *Suite {
 	s.scenarios = append(s.scenarios, scenarios...)
@@ -54,13 +170,110 @@ func (s *Suite) WithScenarios(scenarios ...*Scenario) *Suite {
 }
 
 // Run executes all scenarios. Each scenario gets its own fresh VarStore.
+// Serial scenarios run first, in order; scenarios marked Parallel then run
+// concurrently in a worker pool bounded by WithParallelism.
 func (s *Suite) Run() error {
-	var errs []error
-	for _, sc := range s.scenarios {
-		vars := make(VarStore)
-		if err := sc.Run(s.log, s.defaultConn, s.connections, vars); err != nil {
-			errs = append(errs, fmt.Errorf("scenario %q: %w", sc.Name, err))
+	return s.RunContext(context.Background())
+}
+
+// RunContext is like Run but stops once ctx is done: in-flight requests are
+// aborted and scenarios that have not started yet are reported as not run.
+// With WithCoverage, the run also fails below the coverage threshold.
+func (s *Suite) RunContext(ctx context.Context) error {
+	start := time.Now()
+	s.reporters.Report(Event{Kind: EventSuiteStart, Time: start})
+	ctx, rec := s.startCoverage(ctx)
+
+	errs := make([]error, len(s.scenarios))
+	var parallel []int
+	for i, sc := range s.scenarios {
+		if sc.parallel {
+			parallel = append(parallel, i)
+			continue
 		}
+		errs[i] = s.runScenario(ctx, i, sc)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.maxParallel(), len(parallel)) {
+		wg.Go(func() {
+			for i := range jobs {
+				errs[i] = s.runScenario(ctx, i, s.scenarios[i])
+			}
+		})
+	}
+	for _, i := range parallel {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	err := errors.Join(append(errs, s.finishCoverage(context.WithoutCancel(ctx), rec))...)
+	s.reporters.Report(Event{Kind: EventSuiteFinish, Time: time.Now(), Duration: time.Since(start), Err: err})
+	return err
+}
+
+func (s *Suite) runScenario(ctx context.Context, i int, sc *Scenario) error {
+	env := s.scenarioEnv(i, s.log, runStepDirect)
+	if err := ctx.Err(); err != nil {
+		err = fmt.Errorf("not run: %w", context.Cause(ctx))
+		env.report(Event{Kind: EventScenarioStart, Scenario: sc.Name})
+		env.report(Event{Kind: EventScenarioFinish, Scenario: sc.Name, Err: err})
+		return fmt.Errorf("scenario %q: %w", sc.Name, err)
+	}
+	if err := sc.run(ctx, env, s.newVars()); err != nil {
+		return fmt.Errorf("scenario %q: %w", sc.Name, err)
+	}
+	return nil
+}
+
+// scenarioEnv returns the environment for running the ith scenario of the suite.
+func (s *Suite) scenarioEnv(i int, log *slog.Logger, runStep stepRunner) scenarioEnv {
+	env := scenarioEnv{
+		log:         log,
+		defaultConn: s.defaultConn,
+		connections: s.connections,
+		runStep:     runStep,
+		id:          i + 1,
+		secrets:     s.secrets(),
+		seed:        s.seed,
+	}
+	if len(s.reporters) > 0 {
+		env.reporter = s.reporters
+	}
+	return env
+}
+
+// newVars returns the fresh VarStore a scenario of the suite starts with.
+func (s *Suite) newVars() VarStore {
+	vars := maps.Clone(s.vars)
+	if vars == nil {
+		vars = make(VarStore)
+	}
+	return vars
+}
+
+// secrets returns what a scenario of the suite starts knowing about its
+// secrets: the names and patterns of secret variables, and the values of the
+// secret environment variables the files were loaded with.
+func (s *Suite) secrets() *secretSet {
+	set := newSecretSet()
+	set.patterns = append(slices.Clone(defaultSecretPatterns), s.secretPatterns...)
+	for _, name := range s.secretNames {
+		set.names[name] = true
+	}
+	for name, val := range s.env {
+		if val != "" && isSecretName(name, set.patterns) {
+			set.values = append(set.values, val)
+		}
+	}
+	return set
+}
+
+func (s *Suite) maxParallel() int {
+	if s.parallelism < 1 {
+		return runtime.GOMAXPROCS(0)
 	}
-	return errors.Join(errs...)
+	return s.parallelism
 }
@@ -0,0 +1,157 @@
+package expect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestSuite_RunParallel(t *testing.T) {
+	var inflight, peak, hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		hits.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().WithConnections(HTTP("api", srv.URL)).WithParallelism(2)
+	for range 6 {
+		suite.WithScenarios(NewScenario("parallel").Parallel().AddStep(GET("/").ExpectStatus(200)))
+	}
+	suite.WithScenarios(NewScenario("serial").AddStep(GET("/").ExpectStatus(200)))
+
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hits.Load() != 7 {
+		t.Fatalf("expected 7 requests, got %d", hits.Load())
+	}
+	if peak.Load() > 2 {
+		t.Fatalf("expected at most 2 concurrent scenarios, got %d", peak.Load())
+	}
+}
+
+func TestSuite_RunParallelErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(
+			NewScenario("ok").Parallel().AddStep(GET("/").ExpectStatus(200)),
+			NewScenario("bad").Parallel().AddStep(GET("/").ExpectStatus(404)),
+		)
+
+	if err := suite.Run(); err == nil {
+		t.Fatal("expected error from failing parallel scenario")
+	}
+}
+
+func TestSuite_RunContextCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(NewScenario("never").AddStep(GET("/").ExpectStatus(200)))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := suite.RunContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestSuite_WithConnectionURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite, err := LoadYAML([]byte(`
+connections:
+  - name: api
+    type: http
+    url: http://localhost:1
+scenarios:
+  - name: default connection
+    steps:
+      - request:
+          method: GET
+          endpoint: /
+        expect:
+          status: 200
+`))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := suite.WithConnectionURL("api", srv.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := suite.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := suite.WithConnectionURL("missing", srv.URL); err == nil {
+		t.Fatal("expected error for unknown connection")
+	}
+}
+
+func TestSuite_WithConnectionURL_settings(t *testing.T) {
+	grpcConn := GRPC("svc", "localhost:1", grpc.WithUserAgent("go-expect-test"))
+	if err := grpcConn.Dial(); err != nil {
+		t.Fatal(err)
+	}
+	sqlConn := SQL("db", "postgres", "postgres://localhost/a")
+	sqlConn.Timeout = 3 * time.Second
+	suite := NewSuite().WithConnections(grpcConn, sqlConn)
+
+	if err := suite.WithConnectionURL("svc", "localhost:2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := suite.WithConnectionURL("db", "postgres://localhost/b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g := suite.connections["svc"].(*GRPCConnection)
+	if g.Addr != "localhost:2" || len(g.opts) != 1 {
+		t.Errorf("expected the dial options kept, got %s with %d options", g.Addr, len(g.opts))
+	}
+	if grpcConn.conn != nil {
+		t.Error("expected the replaced gRPC connection to be closed")
+	}
+	if s := suite.connections["db"].(*SQLConnection); s.DSN != "postgres://localhost/b" || s.Timeout != 3*time.Second {
+		t.Errorf("expected the timeout kept, got %s with %v", s.DSN, s.Timeout)
+	}
+}
+
+func TestSuite_Filter(t *testing.T) {
+	suite := NewSuite().WithScenarios(NewScenario("a"), NewScenario("b"), NewScenario("ab"))
+	suite.Filter(func(sc *Scenario) bool { return strings.HasPrefix(sc.Name, "a") })
+
+	var names []string
+	for _, sc := range suite.Scenarios() {
+		names = append(names, sc.Name)
+	}
+	if strings.Join(names, ",") != "a,ab" {
+		t.Fatalf("expected a,ab, got %v", names)
+	}
+}
@@ -1,8 +1,13 @@
 package expect
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"testing"
+	"time"
 )
 
 // TestSuite wraps Suite for use with Go's testing package.
@@ -15,12 +20,92 @@ func NewTestSuite(suite *Suite) *TestSuite {
 	return &TestSuite{suite: suite}
 }
 
-// Run executes all scenarios, routing log output through t and reporting
-// failures via t.Errorf so all scenarios always run (non-fatal).
+// Run executes every scenario as a subtest named after the scenario, and every
+// step as a nested subtest named after its label, so individual scenarios can be
+// targeted with go test -run. Log output is routed through each subtest and
+// failures are reported via t.Error so all scenarios always run (non-fatal).
+// Scenarios marked Parallel call t.Parallel and are additionally bounded by the
+// suite's WithParallelism setting. Reporters receive the suite-finish event once
+// every subtest, including parallel ones, has completed, and so does the
+// coverage check of WithCoverage.
 func (s *TestSuite) Run(t *testing.T) {
 	t.Helper()
-	s.suite.WithLogger(slog.New(slog.NewTextHandler(t.Output(), nil)))
-	if err := s.suite.Run(); err != nil {
-		t.Errorf("suite failures:\n%v", err)
+	start := time.Now()
+	s.suite.reporters.Report(Event{Kind: EventSuiteStart, Time: start})
+	ctx, rec := s.suite.startCoverage(context.Background())
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	t.Cleanup(func() {
+		if err := s.suite.finishCoverage(ctx, rec); err != nil {
+			t.Error(err)
+			errs = append(errs, err)
+		}
+		s.suite.reporters.Report(Event{
+			Kind:     EventSuiteFinish,
+			Time:     time.Now(),
+			Duration: time.Since(start),
+			Err:      errors.Join(errs...),
+		})
+	})
+
+	sem := make(chan struct{}, s.suite.maxParallel())
+	for i, sc := range s.suite.scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			if sc.parallel {
+				t.Parallel()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+			if err := s.runScenario(t, i, sc, rec); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("scenario %q: %w", sc.Name, err))
+				mu.Unlock()
+			}
+		})
+	}
+}
+
+func (s *TestSuite) runScenario(t *testing.T, i int, sc *Scenario, rec *coverageRecorder) error {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(t.Output(), nil))
+
+	// Step failures are reported by their own subtest; anything else
+	// (before/after hooks) is reported on the scenario.
+	var stepErr error
+	env := s.suite.scenarioEnv(i, log, func(label string, fn func() error) error {
+		var err error
+		t.Run(label, func(t *testing.T) {
+			if err = fn(); err != nil {
+				t.Error(err)
+			}
+		})
+		stepErr = err
+		return err
+	})
+	ctx := t.Context()
+	if rec != nil {
+		ctx = context.WithValue(ctx, coverageKey{}, rec)
+	}
+	err := sc.run(ctx, env, s.suite.newVars())
+
+	for _, e := range unwrapJoined(err) {
+		if stepErr == nil || !errors.Is(e, stepErr) {
+			t.Error(e)
+		}
+	}
+	return err
+}
+
+// unwrapJoined flattens an errors.Join result into its component errors.
+func unwrapJoined(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
 	}
+	return []error{err}
 }
@@ -0,0 +1,72 @@
+package expect
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTestSuite_Run(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithScenarios(
+			NewScenario("first").
+				AddStep(GET("/a").ExpectStatus(200)).
+				AddStep(GET("/b").ExpectStatus(200)),
+			NewScenario("second").
+				AddStep(GET("/c").ExpectStatus(200)),
+		)
+
+	NewTestSuite(suite).Run(t)
+
+	if hits.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", hits.Load())
+	}
+}
+
+func TestTestSuite_RunParallel(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	suite := NewSuite().
+		WithConnections(HTTP("api", srv.URL)).
+		WithParallelism(2).
+		WithScenarios(
+			NewScenario("a").Parallel().AddStep(GET("/a").ExpectStatus(200)),
+			NewScenario("b").Parallel().AddStep(GET("/b").ExpectStatus(200)),
+			NewScenario("c").Parallel().AddStep(GET("/c").ExpectStatus(200)),
+		)
+
+	t.Run("suite", func(t *testing.T) {
+		NewTestSuite(suite).Run(t)
+	})
+
+	if hits.Load() != 3 {
+		t.Fatalf("expected 3 requests, got %d", hits.Load())
+	}
+}
+
+func TestUnwrapJoined(t *testing.T) {
+	if errs := unwrapJoined(nil); errs != nil {
+		t.Fatalf("expected nil, got %v", errs)
+	}
+	if errs := unwrapJoined(http.ErrNoCookie); len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	if errs := unwrapJoined(errors.Join(http.ErrNoCookie, http.ErrNoLocation)); len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d", len(errs))
+	}
+}
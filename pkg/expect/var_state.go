@@ -0,0 +1,101 @@
+package expect
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+// varState is what a running scenario keeps about its variables besides their
+// values: which are secret, the random source of its generators and the values
+// they made for the step running. The runner keeps it beside the scenario's
+// VarStore and hands it to requests in their context, so the variables stay
+// plain values that hooks, reports and scripts can range over.
+type varState struct {
+	vars VarStore   // the scenario's variables
+	rand *rand.Rand // seeded source of generators; nil draws at random
+
+	mu            sync.Mutex        // guards the fields below, used by loggers and requests too
+	secrets       *secretSet        // what the scenario knows about its secrets
+	generated     map[string]any    // generator placeholder -> value, for the step running
+	redactor      *strings.Replacer // masks the secret values; see varState.currentRedactor
+	redactorFresh bool              // no variable was set or marked secret since redactor was made
+}
+
+// newVarState returns the state a scenario starts with in env, with vars.
+func (env scenarioEnv) newVarState(scenario string, vars VarStore) *varState {
+	st := &varState{vars: vars, secrets: env.secrets, generated: map[string]any{}}
+	if st.secrets == nil {
+		st.secrets = newSecretSet()
+	}
+	if env.seed != nil {
+		st.rand = seededRand(*env.seed, scenario)
+	}
+	return st
+}
+
+// varStateKey is the context key of the state of the running scenario.
+type varStateKey struct{}
+
+// withVarState returns ctx carrying st, for the requests of a step.
+func withVarState(ctx context.Context, st *varState) context.Context {
+	return context.WithValue(ctx, varStateKey{}, st)
+}
+
+// varStateFrom returns the state ctx carries, or nil outside a scenario run.
+func varStateFrom(ctx context.Context) *varState {
+	st, _ := ctx.Value(varStateKey{}).(*varState)
+	return st
+}
+
+// interpolator returns the interpolator of the scenario's variables.
+func (st *varState) interpolator() interpolator {
+	return interpolator{vars: st.vars, st: st}
+}
+
+// startStep forgets the values generated for the previous step, so each step
+// makes its own but sends, retries, reports and replays the same ones.
+func (st *varState) startStep() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.generated = map[string]any{}
+}
+
+// generate returns the value generated for a placeholder in the running step,
+// making it with gen the first time.
+func (st *varState) generate(key string, gen func(r *rand.Rand) (any, bool)) (any, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if val, ok := st.generated[key]; ok {
+		return val, true
+	}
+	r := st.rand
+	if r == nil {
+		r = unseededRand
+	}
+	val, ok := gen(r)
+	if ok {
+		st.generated[key] = val
+		st.redactorFresh = false // gen may have saved a variable
+	}
+	return val, ok
+}
+
+// markSecret marks variables as secret.
+func (st *varState) markSecret(names ...string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, name := range names {
+		st.secrets.names[name] = true
+	}
+	st.redactorFresh = false
+}
+
+// varsChanged makes the redactor be made again, as variables were set or
+// changed.
+func (st *varState) varsChanged() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.redactorFresh = false
+}
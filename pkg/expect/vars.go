@@ -1,23 +1,204 @@
 package expect
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // VarStore holds variables that can be set by one step and consumed by later steps.
 type VarStore map[string]any
 
+//nolint:gochecknoglobals // compiled once
+var placeholderExprRe = regexp.MustCompile(`\{[^{}]+\}`)
+
 // Interpolate replaces all {key} placeholders in s with values from the store.
-// Unknown keys are left as-is.
+// A placeholder may also hold an expression over the variables, such as
+// {count + 1}, {upper(name)} or {user.address.city}; see expr.go. Expressions
+// start and end without whitespace. Unknown keys and expressions that fail are
+// left as-is. Saved objects and arrays are
+// written as JSON.
 func (v VarStore) Interpolate(s string) string {
-	for key, val := range v {
-		s = strings.ReplaceAll(s, "{"+key+"}", fmt.Sprintf("%v", val))
-	}
-	return s
+	return interpolator{vars: v}.interpolate(s)
 }
 
 // InterpolateBytes replaces {key} placeholders in a byte slice.
 func (v VarStore) InterpolateBytes(b []byte) []byte {
 	return []byte(v.Interpolate(string(b)))
 }
+
+// InterpolateJSON replaces {key} placeholders in a JSON document, keeping the
+// type of saved values: a string value that is exactly "{key}" becomes the
+// value as JSON, such as 2, true, null or an object, while placeholders within
+// longer strings are substituted as text. The rest of the document is kept
+// byte for byte. b that is not valid JSON is interpolated as InterpolateBytes.
+func (v VarStore) InterpolateJSON(b []byte) []byte {
+	return interpolator{vars: v}.interpolateJSON(b)
+}
+
+// interpolator interpolates with the variables of a store and, within a
+// running scenario, its state, so generators draw from the scenario's random
+// source and keep their values for the step. st is nil outside a run.
+type interpolator struct {
+	vars VarStore
+	st   *varState
+}
+
+// newInterpolator returns the interpolator of vars in the run ctx belongs
+// to, if any.
+func newInterpolator(ctx context.Context, vars VarStore) interpolator {
+	return interpolator{vars: vars, st: varStateFrom(ctx)}
+}
+
+// interpolate is VarStore.Interpolate.
+func (in interpolator) interpolate(s string) string {
+	if !strings.ContainsRune(s, '{') {
+		return s
+	}
+	return placeholderExprRe.ReplaceAllStringFunc(s, func(p string) string {
+		if val, ok := in.resolve(p[1 : len(p)-1]); ok {
+			return varString(val)
+		}
+		return p
+	})
+}
+
+// interpolateJSON is VarStore.InterpolateJSON.
+func (in interpolator) interpolateJSON(b []byte) []byte {
+	if !bytes.ContainsRune(b, '{') {
+		return b
+	}
+	if !json.Valid(b) {
+		return []byte(in.interpolate(string(b)))
+	}
+	var out bytes.Buffer
+	for i := 0; i < len(b); {
+		if b[i] != '"' {
+			out.WriteByte(b[i])
+			i++
+			continue
+		}
+		end := jsonStringEnd(b, i)
+		rest := bytes.TrimLeft(b[end:], " \t\r\n")
+		isKey := len(rest) > 0 && rest[0] == ':'
+		out.Write(in.interpolateJSONString(b[i:end], isKey))
+		i = end
+	}
+	return out.Bytes()
+}
+
+// interpolateJSONString interpolates a JSON string literal, quotes included.
+// Only values, not object keys, may change type.
+func (in interpolator) interpolateJSONString(lit []byte, isKey bool) []byte {
+	var s string
+	if !bytes.ContainsRune(lit, '{') || json.Unmarshal(lit, &s) != nil {
+		return lit
+	}
+	if val, ok := in.lookup(s); ok && !isKey {
+		if data, err := json.Marshal(val); err == nil {
+			return data
+		}
+	}
+	interpolated := in.interpolate(s)
+	if interpolated == s {
+		return lit
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(interpolated); err != nil {
+		return lit
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// interpolateValue interpolates a string parameter, such as a SQL parameter:
+// exactly "{key}" becomes the saved value with its type, and placeholders
+// within longer strings are substituted as text. Other values are unchanged.
+func (in interpolator) interpolateValue(p any) any {
+	s, ok := p.(string)
+	if !ok {
+		return p
+	}
+	if val, ok := in.lookup(s); ok {
+		return val
+	}
+	return in.interpolate(s)
+}
+
+// lookup returns the value of s when s is exactly one placeholder that resolves.
+func (in interpolator) lookup(s string) (any, bool) {
+	if len(s) < 3 || s[0] != '{' || s[len(s)-1] != '}' || strings.ContainsAny(s[1:len(s)-1], "{}") {
+		return nil, false
+	}
+	return in.resolve(s[1 : len(s)-1])
+}
+
+// resolve returns the value of a placeholder's contents: a generated value
+// for $generators (see generators.go), a variable of that exact name, or else
+// the value of the expression. Text that only holds literals, such as the {2}
+// of a regular expression, is not an expression, nor is text with leading or
+// trailing whitespace, such as the { id } of a GraphQL query, and a path to a
+// field that is not there does not resolve.
+func (in interpolator) resolve(key string) (any, bool) {
+	if strings.HasPrefix(key, "$") {
+		return in.generate(key)
+	}
+	if val, ok := in.vars[key]; ok {
+		return val, true
+	}
+	if key != strings.TrimSpace(key) {
+		return nil, false
+	}
+	c := compileExpr(key)
+	if c.err != nil || !c.refs {
+		return nil, false
+	}
+	val, err := c.node.eval(&exprScope{vars: in.vars})
+	if err != nil {
+		return nil, false
+	}
+	if val == nil {
+		switch c.node.(type) {
+		case exprMember, exprIndex:
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+// varString formats a value for substitution into text. Decoded JSON objects,
+// arrays and null are written as JSON, rather than in Go syntax, numbers
+// without exponents and times as RFC 3339.
+func varString(val any) string {
+	switch x := val.(type) {
+	case map[string]any, []any, nil:
+		if data, err := json.Marshal(val); err == nil {
+			return string(data)
+		}
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// jsonStringEnd returns the index just past the JSON string literal starting
+// at the quote b[start].
+func jsonStringEnd(b []byte, start int) int {
+	for i := start + 1; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,104 @@
+package expect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVarStore_InterpolateJSON(t *testing.T) {
+	vars := VarStore{
+		"n":    float64(2),
+		"ok":   true,
+		"none": nil,
+		"id":   "abc",
+		"user": map[string]any{"name": "alice"},
+		"tags": []any{"a", "b"},
+		"q":    `say "hi"`,
+	}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"number", `{"n": "{n}"}`, `{"n": 2}`},
+		{"bool and null", `["{ok}", "{none}"]`, `[true, null]`},
+		{"object", `{"user":"{user}","tags":"{tags}"}`, `{"user":{"name":"alice"},"tags":["a","b"]}`},
+		{"string", `{"id": "{id}"}`, `{"id": "abc"}`},
+		{"embedded", `{"path": "/users/{id}/n/{n}"}`, `{"path": "/users/abc/n/2"}`},
+		{"embedded object", `{"s": "u={user}"}`, `{"s": "u={\"name\":\"alice\"}"}`},
+		{"escaped", `{"s": "q: {q}"}`, `{"s": "q: say \"hi\""}`},
+		{"keys stay strings", `{"{n}": "{n}"}`, `{"2": 2}`},
+		{"unknown", `{"x": "{missing}"}`, `{"x": "{missing}"}`},
+		{"formatting kept", "{\n  \"a\" : \"{n}\",\n  \"b\": \"<\\u00e9>\"\n}", "{\n  \"a\" : 2,\n  \"b\": \"<\\u00e9>\"\n}"},
+		{"not json", `id={id}&n={n}`, `id=abc&n=2`},
+		{"unquoted placeholder", `{"n": {n}}`, `{"n": 2}`},
+		{"expression", `{"n": "{n * 10}", "name": "{upper(user.name)}"}`, `{"n": 20, "name": "ALICE"}`},
+		{"graphql query", `{"query":"{ user { id } }"}`, `{"query":"{ user { id } }"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(vars.InterpolateJSON([]byte(tt.in))); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVarStore_Interpolate(t *testing.T) {
+	vars := VarStore{"user": map[string]any{"id": float64(1)}, "n": float64(3), "none": nil}
+	if got := vars.Interpolate("/u/{user}/{n}/{none}"); got != `/u/{"id":1}/3/null` {
+		t.Errorf("unexpected interpolation %q", got)
+	}
+}
+
+func TestVarStore_Interpolate_expressions(t *testing.T) {
+	vars := VarStore{
+		"count":          float64(41),
+		"name":           "alice",
+		"user":           map[string]any{"address": map[string]any{"city": "Paris"}},
+		"items":          []any{"a", "b"},
+		"big":            float64(1234567),
+		"login.response": "dotted",
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"{count + 1}", "42"},
+		{"{upper(name)}", "ALICE"},
+		{"{len(items)}", "2"},
+		{"{user.address.city}", "Paris"},
+		{"/n/{big}/{big + 1}", "/n/1234567/1234568"},
+		{"{login.response}", "dotted"},
+		{"{user.address.zip}", "{user.address.zip}"},
+		{"{missing + 1}", "{missing + 1}"},
+		{`^\d{2}$ {"a": 1}`, `^\d{2}$ {"a": 1}`},
+		{"{{name}}", "{alice}"},
+		{"{ count + 1 }", "{ count + 1 }"},
+	}
+	for _, tt := range tests {
+		if got := vars.Interpolate(tt.in); got != tt.want {
+			t.Errorf("Interpolate(%q): expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+	if got := (VarStore{}).Interpolate("{now | unix}"); len(got) != 10 {
+		t.Errorf("expected a Unix time, got %q", got)
+	}
+}
+
+func TestInterpolator_interpolateValue(t *testing.T) {
+	in := interpolator{vars: VarStore{"n": float64(2), "id": "abc"}}
+	tests := []struct {
+		in, want any
+	}{
+		{"{n}", float64(2)},
+		{"id-{id}", "id-abc"},
+		{"{missing}", "{missing}"},
+		{"{n + 1}", float64(3)},
+		{7, 7},
+	}
+	for _, tt := range tests {
+		if got := in.interpolateValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("interpolateValue(%v): expected %#v, got %#v", tt.in, tt.want, got)
+		}
+	}
+}